@@ -1,77 +1,295 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"pag-simples/internal/auth"
+	"pag-simples/internal/config"
+	"pag-simples/internal/http/authn"
 	"pag-simples/internal/http/handlers"
 	"pag-simples/internal/http/routes"
+	"pag-simples/internal/idempotency"
+	"pag-simples/internal/outbox"
 	"pag-simples/internal/transfer"
 	"pag-simples/internal/user"
 	"pag-simples/internal/wallet"
 	"pag-simples/pkg/authorization"
+	"pag-simples/pkg/notification"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/shopspring/decimal"
 )
 
-func initializeData(userRepo *user.MemoryUserRepository, walletRepo *wallet.MemoryWalletRepository) {
-	userRepo.SaveUser(&user.User{
-		ID:             1,
-		FullName:       "João Silva",
-		DocumentNumber: "12345678901",
-		Email:          "joao@email.com",
-		Password:       "senha123",
-		UserType:       user.CommonUser,
-	})
-	userRepo.SaveUser(&user.User{
-		ID:             2,
-		FullName:       "Maria Oliveira",
-		DocumentNumber: "98765432100",
-		Email:          "maria@email.com",
-		Password:       "senha456",
-		UserType:       user.CommonUser,
-	})
-	userRepo.SaveUser(&user.User{
-		ID:             3,
-		FullName:       "Loja Exemplo",
-		DocumentNumber: "12345678000100",
-		Email:          "loja@email.com",
-		Password:       "senha789",
-		UserType:       user.Merchant,
+func authorizationConfig(cfg config.AuthorizerConfig) authorization.Config {
+	return authorization.Config{
+		BaseURL: cfg.URL,
+		Timeout: cfg.Timeout,
+		Retry: authorization.RetryPolicy{
+			MaxAttempts:    cfg.MaxAttempts,
+			InitialBackoff: authorization.DefaultConfig.Retry.InitialBackoff,
+			MaxBackoff:     authorization.DefaultConfig.Retry.MaxBackoff,
+		},
+		Breaker: authorization.BreakerConfig{
+			FailureThreshold: cfg.BreakerThreshold,
+			OpenTimeout:      cfg.BreakerTimeout,
+		},
+	}
+}
+
+// newAuthorizationService consulta o motor de regras de cfg.RulesFile, quando
+// definido, antes do autorizador externo. O motor também é devolvido, ou nil
+// sem arquivo de regras, para conferir os limites de volume na liquidação.
+func newAuthorizationService(ctx context.Context, cfg config.AuthorizerConfig, history authorization.History) (authorization.AuthorizationService, *authorization.RulesEngine) {
+	remote := authorization.NewAuthorizationService(authorizationConfig(cfg))
+
+	if cfg.RulesFile == "" {
+		return remote, nil
+	}
+
+	engine, err := authorization.NewRulesEngine(cfg.RulesFile, history)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Regras de autorização carregadas de %s, versão %s", cfg.RulesFile, engine.Version())
+	go engine.Watch(ctx, cfg.RulesReload)
+
+	return authorization.Chain(engine, remote), engine
+}
+
+// newNotificationService registra um Notifier por canal. Sem servidor SMTP,
+// os e-mails só são registrados no log.
+func newNotificationService(cfg config.NotifierConfig) *notification.Service {
+	service := notification.NewService(notification.DefaultTemplates())
+	service.Register(notification.ChannelSMS, notification.NewSMSNotifier(cfg.SMSURL))
+	service.Register(notification.ChannelWebhook, notification.WebhookNotifier{})
+	service.Register(notification.ChannelNone, notification.LogNotifier{})
+
+	if cfg.SMTP.Addr == "" {
+		log.Println("notifier.smtp.addr não definido, e-mails serão apenas registrados no log")
+		service.Register(notification.ChannelEmail, notification.LogNotifier{})
+		return service
+	}
+
+	service.Register(notification.ChannelEmail, &notification.SMTPNotifier{
+		Addr:     cfg.SMTP.Addr,
+		From:     cfg.SMTP.From,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
 	})
+	return service
+}
+
+// authConfig gera uma chave aleatória quando auth.secret não é definido.
+func authConfig(cfg config.AuthConfig) (auth.Config, error) {
+	secret := []byte(cfg.Secret)
+	if len(secret) == 0 {
+		log.Println("auth.secret não definido, usando uma chave aleatória: os tokens deixam de valer ao reiniciar")
+		secret = make([]byte, config.MinAuthSecretLength)
+		if _, err := rand.Read(secret); err != nil {
+			return auth.Config{}, fmt.Errorf("erro ao gerar chave dos tokens: %v", err)
+		}
+	}
+
+	return auth.Config{
+		Secret:     secret,
+		AccessTTL:  cfg.AccessTTL,
+		RefreshTTL: cfg.RefreshTTL,
+	}, nil
+}
+
+// configureLogging devolve a função que fecha o arquivo de log, se houver.
+func configureLogging(cfg config.LoggingConfig) (func(), error) {
+	switch cfg.Output {
+	case "stderr":
+		log.SetOutput(os.Stderr)
+	case "stdout":
+		log.SetOutput(os.Stdout)
+	default:
+		file, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir arquivo de log %s: %v", cfg.Output, err)
+		}
+		log.SetOutput(file)
+		return func() { file.Close() }, nil
+	}
+	return func() {}, nil
+}
 
-	walletRepo.CreateWallet(1, decimal.NewFromFloat(1000.0))
-	walletRepo.CreateWallet(2, decimal.NewFromFloat(500.0))
-	walletRepo.CreateWallet(3, decimal.NewFromFloat(2000.0))
+func purgeIdempotencyKeys(service *idempotency.IdempotencyService, interval time.Duration) {
+	for range time.Tick(interval) {
+		purged, err := service.PurgeExpired()
+		if err != nil {
+			log.Printf("Erro ao remover chaves de idempotência expiradas: %v", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("%d chaves de idempotência expiradas removidas", purged)
+		}
+	}
+}
+
+// resumeInterval é a frequência com que cada instância procura transferências
+// pendentes com o lease vencido, como as de uma réplica que caiu.
+const resumeInterval = time.Minute
+
+// resumeTransfers roda Resume na subida e depois a cada interval, até ctx
+// terminar.
+func resumeTransfers(ctx context.Context, service transfer.TransferUsecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		resumed, err := service.Resume()
+		if err != nil {
+			log.Printf("Erro ao retomar transferências pendentes: %v", err)
+		} else if resumed > 0 {
+			log.Printf("%d transferências interrompidas retomadas", resumed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func main() {
-	userRepo := user.NewMemoryUserRepository()
-	walletRepo := wallet.NewMemoryWalletRepository()
-	transferRepo := transfer.NewMemoryTransferRepository()
-	authorizationService := authorization.NewAuthorizationService()
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	closeLog, err := configureLogging(cfg.Logging)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeLog()
+
+	repos, err := newRepositories(cfg.Storage.Driver, cfg.Storage.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repos.close()
 
-	userService := user.NewUserService(userRepo)
-	walletService := wallet.NewWalletService(walletRepo)
+	notificationService := newNotificationService(cfg.Notifier)
+	userService := user.NewUserService(repos.users, user.WithPasswordReset(repos.resets, notificationService), user.WithSessionRevoker(repos.tokens))
+	walletService := wallet.NewWalletService(repos.ledger)
+
+	rulesCtx, stopRules := context.WithCancel(context.Background())
+	defer stopRules()
+	authorizationService, rules := newAuthorizationService(rulesCtx, cfg.Authorizer, transfer.NewAuthorizationHistory(repos.transfers, walletService))
 
 	userHandler := handlers.NewUserHandler(userService, walletService)
 
-	transferService := transfer.NewTransferService(userService, walletRepo, transferRepo, authorizationService)
-	transferHandler := handlers.NewTransferHandler(transferService)
+	authOptions, err := authConfig(cfg.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authService := auth.NewAuthService(userService, repos.tokens, authOptions)
+	authHandler := handlers.NewAuthHandler(authService)
+
+	transferOptions := []transfer.Option{transfer.WithLowBalanceThreshold(cfg.Limits.LowBalanceThreshold)}
+	var workers *transfer.WorkerPool
+	if cfg.Limits.TransferWorkers > 0 {
+		workers = transfer.NewWorkerPool(cfg.Limits.TransferWorkers, cfg.Limits.TransferQueueSize)
+		transferOptions = append(transferOptions, transfer.WithWorkerPool(workers))
+	}
+	if rules != nil {
+		transferOptions = append(transferOptions, transfer.WithLimitChecker(rules))
+	}
+
+	transferService := transfer.NewTransferService(userService, walletService, repos.transfers, repos.unitOfWork, authorizationService, transferOptions...)
+	idempotencyService := idempotency.NewIdempotencyService(repos.idempotency, cfg.Limits.IdempotencyTTL)
+	go purgeIdempotencyKeys(idempotencyService, cfg.Limits.IdempotencyTTL)
+
+	transferHandler := handlers.NewTransferHandler(transferService, idempotencyService)
 
-	initializeData(userRepo, walletRepo)
+	outboxService := outbox.NewOutboxService(repos.outbox, outbox.DefaultBackoffPolicy)
+	outboxService.Handle(transfer.NotificationTopic, transfer.NotificationHandler(userService, notificationService))
+	outboxHandler := handlers.NewOutboxHandler(outboxService)
+
+	if err := seed(userService, walletService, cfg.Seed); err != nil {
+		log.Fatal(err)
+	}
 
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	if cfg.Logging.Requests {
+		r.Use(middleware.Logger)
+	}
 	r.Use(middleware.Recoverer)
 
-	routes.ConfigureUserRoutes(r, userHandler)
-	routes.ConfigureTransferRoutes(r, transferHandler)
+	authenticate := authn.Middleware(authService)
+	routes.ConfigureAuthRoutes(r, authHandler)
+	routes.ConfigureUserRoutes(r, userHandler, authenticate)
+	routes.ConfigureTransferRoutes(r, transferHandler, authenticate)
+	routes.ConfigureOutboxRoutes(r, outboxHandler, authenticate)
+	routes.ConfigureDebugRoutes(r, authenticate)
 
-	log.Println("Servidor rodando em http://localhost:8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatal(err)
+	server := &http.Server{Addr: cfg.HTTP.Addr, Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Sem workers, as transferências interrompidas são marcadas como failed.
+	resumeCtx, stopResume := context.WithCancel(context.Background())
+	resumeDone := make(chan struct{})
+	go func() {
+		defer close(resumeDone)
+		resumeTransfers(resumeCtx, transferService, resumeInterval)
+	}()
+
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		outboxService.Run(dispatcherCtx, cfg.Notifier.DispatchInterval)
+	}()
+
+	go func() {
+		log.Printf("Servidor rodando em %s", cfg.HTTP.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Encerrando servidor")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Erro ao encerrar o servidor: %v", err)
 	}
+
+	// A retomada para antes dos workers, que deixam de aceitar jobs.
+	stopResume()
+	<-resumeDone
+
+	// Transferências já aceitas terminam antes de o banco ser fechado. Passado
+	// o prazo, as autorizações em andamento são canceladas e as que nem
+	// começaram são retomadas quando o lease delas vencer.
+	if workers != nil {
+		workersCtx, cancelWorkers := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		workers.Shutdown(workersCtx)
+		cancelWorkers()
+	}
+
+	// O dispatcher para depois dos workers; o que ficar pendente é entregue
+	// na próxima execução.
+	stopDispatcher()
+	<-dispatcherDone
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"pag-simples/internal/config"
+	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
+)
+
+// seed cadastra os usuários de seeds, com as wallets, apenas quando ainda não
+// há nenhum usuário; reinícios não duplicam nem sobrescrevem dados.
+func seed(userService user.UserUsecase, walletService wallet.WalletUseCase, seeds []config.SeedUser) error {
+	users, err := userService.GetAllUsers()
+	if err != nil {
+		return fmt.Errorf("erro ao verificar dados iniciais: %v", err)
+	}
+	if len(users) > 0 {
+		return nil
+	}
+
+	for _, s := range seeds {
+		err := userService.SaveUser(&user.User{
+			ID:             s.ID,
+			FullName:       s.FullName,
+			DocumentNumber: s.DocumentNumber,
+			Email:          s.Email,
+			Password:       s.Password,
+			UserType:       user.UserType(s.UserType),
+			Role:           user.Role(s.Role),
+		})
+		if err != nil {
+			return fmt.Errorf("erro ao cadastrar usuário inicial %d: %v", s.ID, err)
+		}
+		if err := walletService.CreateWallet(s.ID, s.Balance); err != nil {
+			return fmt.Errorf("erro ao criar wallet do usuário inicial %d: %v", s.ID, err)
+		}
+	}
+	return nil
+}
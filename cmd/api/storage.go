@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"pag-simples/internal/auth"
+	"pag-simples/internal/database"
+	"pag-simples/internal/idempotency"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/transfer"
+	"pag-simples/internal/user"
+)
+
+type repositories struct {
+	users       user.UserRepository
+	resets      user.PasswordResetRepository
+	tokens      auth.RefreshTokenRepository
+	ledger      ledger.LedgerRepository
+	transfers   transfer.TransferRepository
+	unitOfWork  transfer.UnitOfWork
+	idempotency idempotency.IdempotencyRepository
+	outbox      outbox.OutboxRepository
+	close       func()
+}
+
+func newRepositories(driver string, dsn string) (*repositories, error) {
+	if driver == "" || driver == "memory" {
+		log.Println("Usando armazenamento em memória")
+
+		transferRepo := transfer.NewMemoryTransferRepository()
+		ledgerRepo := ledger.NewMemoryLedgerRepository()
+		outboxRepo := outbox.NewMemoryOutboxRepository()
+		return &repositories{
+			users:       user.NewMemoryUserRepository(),
+			resets:      user.NewMemoryPasswordResetRepository(),
+			tokens:      auth.NewMemoryRefreshTokenRepository(),
+			ledger:      ledgerRepo,
+			transfers:   transferRepo,
+			unitOfWork:  transfer.NewMemoryUnitOfWork(transferRepo, ledgerRepo, outboxRepo),
+			idempotency: idempotency.NewMemoryIdempotencyRepository(),
+			outbox:      outboxRepo,
+			close:       func() {},
+		}, nil
+	}
+
+	if dsn == "" {
+		return nil, fmt.Errorf("DATABASE_URL é obrigatório para o armazenamento %s", driver)
+	}
+
+	db, err := database.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &repositories{
+		users:       user.NewSQLUserRepository(db),
+		resets:      user.NewSQLPasswordResetRepository(db),
+		tokens:      auth.NewSQLRefreshTokenRepository(db),
+		ledger:      ledger.NewSQLLedgerRepository(db),
+		transfers:   transfer.NewSQLTransferRepository(db),
+		unitOfWork:  transfer.NewSQLUnitOfWork(db),
+		idempotency: idempotency.NewSQLIdempotencyRepository(db),
+		outbox:      outbox.NewSQLOutboxRepository(db),
+		close:       func() { db.Close() },
+	}, nil
+}
@@ -0,0 +1,101 @@
+package apperrors
+
+type Code string
+
+const (
+	CodeInvalidRequest               Code = "invalid_request"
+	CodeInvalidAmount                Code = "invalid_amount"
+	CodeUserNotFound                 Code = "user_not_found"
+	CodeUserAlreadyExists            Code = "user_already_exists"
+	CodePayerNotFound                Code = "payer_not_found"
+	CodePayeeNotFound                Code = "payee_not_found"
+	CodeWalletNotFound               Code = "wallet_not_found"
+	CodeTransferNotFound             Code = "transfer_not_found"
+	CodeOutboxMessageNotFound        Code = "outbox_message_not_found"
+	CodeWalletAlreadyExists          Code = "wallet_already_exists"
+	CodeInsufficientFunds            Code = "insufficient_funds"
+	CodeMerchantCannotPay            Code = "merchant_cannot_pay"
+	CodeAuthorizationDenied          Code = "authorization_denied"
+	CodeAuthorizerUnavailable        Code = "authorizer_unavailable"
+	CodeConcurrentUpdate             Code = "concurrent_update"
+	CodeTransferNotRefundable        Code = "transfer_not_refundable"
+	CodeRefundExceedsAmount          Code = "refund_exceeds_amount"
+	CodeInvalidStatusTransition      Code = "invalid_status_transition"
+	CodeAsyncUnavailable             Code = "async_unavailable"
+	CodeIdempotencyKeyReused         Code = "idempotency_key_reused"
+	CodeIdempotencyRequestInProgress Code = "idempotency_request_in_progress"
+	CodeInvalidCredentials           Code = "invalid_credentials"
+	CodeInvalidResetToken            Code = "invalid_reset_token"
+	CodeUnauthorized                 Code = "unauthorized"
+	CodeInvalidRefreshToken          Code = "invalid_refresh_token"
+	CodeForbidden                    Code = "forbidden"
+	CodeInternal                     Code = "internal_error"
+)
+
+var (
+	ErrInvalidRequest          = New(CodeInvalidRequest, "requisição inválida")
+	ErrInvalidAmount           = New(CodeInvalidAmount, "valor inválido")
+	ErrUserNotFound            = New(CodeUserNotFound, "usuário não encontrado")
+	ErrUserAlreadyExists       = New(CodeUserAlreadyExists, "usuário já cadastrado")
+	ErrPayerNotFound           = New(CodePayerNotFound, "pagador não encontrado")
+	ErrPayeeNotFound           = New(CodePayeeNotFound, "recebedor não encontrado")
+	ErrWalletNotFound          = New(CodeWalletNotFound, "wallet não encontrada")
+	ErrWalletAlreadyExists     = New(CodeWalletAlreadyExists, "wallet já existe")
+	ErrTransferNotFound        = New(CodeTransferNotFound, "transferência não encontrada")
+	ErrOutboxMessageNotFound   = New(CodeOutboxMessageNotFound, "mensagem não encontrada")
+	ErrInsufficientFunds       = New(CodeInsufficientFunds, "saldo insuficiente para a transferência")
+	ErrMerchantCannotPay       = New(CodeMerchantCannotPay, "um lojista não pode realizar transferências")
+	ErrAuthorizationDenied     = New(CodeAuthorizationDenied, "transferência não autorizada")
+	ErrAuthorizerUnavailable   = New(CodeAuthorizerUnavailable, "falha na autorização")
+	ErrConcurrentUpdate        = New(CodeConcurrentUpdate, "transferência não concluída por alterações concorrentes")
+	ErrTransferNotRefundable   = New(CodeTransferNotRefundable, "transferência não pode ser estornada")
+	ErrRefundExceedsAmount     = New(CodeRefundExceedsAmount, "valor do estorno excede o saldo estornável da transferência")
+	ErrInvalidStatusTransition = New(CodeInvalidStatusTransition, "mudança de status inválida")
+	ErrAsyncUnavailable        = New(CodeAsyncUnavailable, "processamento assíncrono indisponível")
+	ErrInvalidCredentials      = New(CodeInvalidCredentials, "e-mail ou senha inválidos")
+	ErrInvalidResetToken       = New(CodeInvalidResetToken, "token de redefinição de senha inválido ou expirado")
+	ErrUnauthorized            = New(CodeUnauthorized, "autenticação necessária")
+	ErrInvalidRefreshToken     = New(CodeInvalidRefreshToken, "refresh token inválido, expirado ou revogado")
+	ErrForbidden               = New(CodeForbidden, "acesso negado")
+	ErrInternal                = New(CodeInternal, "erro interno")
+)
+
+// Error é um erro de domínio com código estável. Dois erros com o mesmo código
+// são equivalentes para errors.Is, mesmo com mensagens ou detalhes diferentes.
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+}
+
+func New(code Code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	merged := make(map[string]interface{}, len(e.Details)+len(details))
+	for key, value := range e.Details {
+		merged[key] = value
+	}
+	for key, value := range details {
+		merged[key] = value
+	}
+
+	return &Error{
+		Code:    e.Code,
+		Message: e.Message,
+		Details: merged,
+	}
+}
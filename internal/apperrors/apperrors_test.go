@@ -0,0 +1,26 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorsWithSameCodeMatch(t *testing.T) {
+	ledgerErr := New(CodeInsufficientFunds, "saldo insuficiente na conta")
+	wrapped := fmt.Errorf("falha ao registrar lançamento: %w", ledgerErr)
+
+	assert.True(t, errors.Is(wrapped, ErrInsufficientFunds))
+	assert.False(t, errors.Is(wrapped, ErrPayerNotFound))
+}
+
+func TestWithDetailsKeepsSentinelUntouched(t *testing.T) {
+	err := ErrInsufficientFunds.WithDetails(map[string]interface{}{"payer": 1})
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds))
+	assert.Equal(t, ErrInsufficientFunds.Message, err.Error())
+	assert.Equal(t, 1, err.Details["payer"])
+	assert.Nil(t, ErrInsufficientFunds.Details)
+}
@@ -0,0 +1,131 @@
+package authtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/auth"
+	"pag-simples/internal/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunRefreshTokenRepositoryTests recebe também o repositório de usuários, já
+// que todo refresh token pertence a um usuário existente.
+func RunRefreshTokenRepositoryTests(t *testing.T, newRepos func(t *testing.T) (user.UserRepository, auth.RefreshTokenRepository)) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	newToken := func(t *testing.T, users user.UserRepository, hash string, family string) *auth.RefreshToken {
+		saved := &user.User{
+			FullName:       hash,
+			DocumentNumber: "doc-" + hash,
+			Email:          hash + "@email.com",
+			UserType:       user.CommonUser,
+		}
+		require.NoError(t, users.SaveUser(saved))
+
+		return &auth.RefreshToken{
+			TokenHash: hash,
+			UserID:    saved.ID,
+			FamilyID:  family,
+			ExpiresAt: now.Add(time.Hour),
+			CreatedAt: now,
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		users, tokens := newRepos(t)
+		token := newToken(t, users, "hash", "familia")
+		require.NoError(t, tokens.CreateRefreshToken(token))
+
+		found, err := tokens.GetRefreshToken("hash")
+		require.NoError(t, err)
+		assert.Equal(t, token, found)
+
+		_, err = tokens.GetRefreshToken("outro")
+		assert.True(t, errors.Is(err, auth.ErrInvalidRefreshToken), "GetRefreshToken: %v", err)
+	})
+
+	t.Run("RevokesOnlyOnce", func(t *testing.T) {
+		users, tokens := newRepos(t)
+		require.NoError(t, tokens.CreateRefreshToken(newToken(t, users, "hash", "familia")))
+
+		revoked, err := tokens.RevokeRefreshToken("hash", now)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = tokens.RevokeRefreshToken("hash", now.Add(time.Second))
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		found, err := tokens.GetRefreshToken("hash")
+		require.NoError(t, err)
+		assert.True(t, found.RevokedAt.Equal(now))
+	})
+
+	t.Run("RevokeFamily", func(t *testing.T) {
+		users, tokens := newRepos(t)
+		require.NoError(t, tokens.CreateRefreshToken(newToken(t, users, "a1", "a")))
+		require.NoError(t, tokens.CreateRefreshToken(newToken(t, users, "a2", "a")))
+		require.NoError(t, tokens.CreateRefreshToken(newToken(t, users, "b1", "b")))
+
+		require.NoError(t, tokens.RevokeFamily("a", now))
+
+		for hash, revoked := range map[string]bool{"a1": true, "a2": true, "b1": false} {
+			found, err := tokens.GetRefreshToken(hash)
+			require.NoError(t, err)
+			assert.Equal(t, revoked, found.Revoked(), hash)
+		}
+	})
+
+	t.Run("RevokeUser", func(t *testing.T) {
+		users, tokens := newRepos(t)
+		a := newToken(t, users, "a1", "a")
+		require.NoError(t, tokens.CreateRefreshToken(a))
+		// Segunda família do mesmo usuário, como um login em outro aparelho.
+		require.NoError(t, tokens.CreateRefreshToken(&auth.RefreshToken{
+			TokenHash: "a2", UserID: a.UserID, FamilyID: "a-outra", ExpiresAt: now.Add(time.Hour), CreatedAt: now,
+		}))
+		require.NoError(t, tokens.CreateRefreshToken(newToken(t, users, "b1", "b")))
+
+		require.NoError(t, tokens.RevokeUser(a.UserID, now))
+
+		for hash, revoked := range map[string]bool{"a1": true, "a2": true, "b1": false} {
+			found, err := tokens.GetRefreshToken(hash)
+			require.NoError(t, err)
+			assert.Equal(t, revoked, found.Revoked(), hash)
+		}
+	})
+
+	t.Run("ConcurrentRevoke", func(t *testing.T) {
+		users, tokens := newRepos(t)
+		require.NoError(t, tokens.CreateRefreshToken(newToken(t, users, "hash", "familia")))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		revocations := 0
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				revoked, err := tokens.RevokeRefreshToken("hash", now.Add(time.Duration(i)*time.Millisecond))
+				if err != nil {
+					t.Error(fmt.Errorf("RevokeRefreshToken: %w", err))
+					return
+				}
+				if revoked {
+					mu.Lock()
+					revocations++
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, revocations)
+	})
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+)
+
+var (
+	ErrUnauthorized        = apperrors.ErrUnauthorized
+	ErrInvalidRefreshToken = apperrors.ErrInvalidRefreshToken
+)
+
+// RefreshToken é um refresh token emitido. Só o hash do token é gravado. Os
+// tokens obtidos a partir de um mesmo login formam uma família, revogada
+// inteira no logout ou quando um token já usado é apresentado de novo.
+type RefreshToken struct {
+	TokenHash string
+	UserID    int
+	FamilyID  string
+	ExpiresAt time.Time
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+func (t *RefreshToken) Revoked() bool {
+	return !t.RevokedAt.IsZero()
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(token *RefreshToken) error
+	GetRefreshToken(tokenHash string) (*RefreshToken, error)
+	// RevokeRefreshToken revoga o token e devolve false se ele já estava
+	// revogado, o que garante que cada token seja trocado uma única vez.
+	RevokeRefreshToken(tokenHash string, now time.Time) (bool, error)
+	RevokeFamily(familyID string, now time.Time) error
+	// RevokeUser revoga todas as famílias do usuário, como na troca de senha.
+	RevokeUser(userID int, now time.Time) error
+}
+
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]RefreshToken
+}
+
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{
+		tokens: make(map[string]RefreshToken),
+	}
+}
+
+func (r *MemoryRefreshTokenRepository) CreateRefreshToken(token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token.TokenHash]; exists {
+		return fmt.Errorf("refresh token já existe")
+	}
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) GetRefreshToken(tokenHash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, exists := r.tokens[tokenHash]
+	if !exists {
+		return nil, ErrInvalidRefreshToken
+	}
+	return &token, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeRefreshToken(tokenHash string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, exists := r.tokens[tokenHash]
+	if !exists || token.Revoked() {
+		return false, nil
+	}
+
+	token.RevokedAt = now
+	r.tokens[tokenHash] = token
+	return true, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.FamilyID == familyID && !token.Revoked() {
+			token.RevokedAt = now
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeUser(userID int, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.UserID == userID && !token.Revoked() {
+			token.RevokedAt = now
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package auth_test
+
+import (
+	"testing"
+
+	"pag-simples/internal/auth"
+	"pag-simples/internal/auth/authtest"
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/user"
+)
+
+func TestMemoryRefreshTokenRepository(t *testing.T) {
+	authtest.RunRefreshTokenRepositoryTests(t, func(t *testing.T) (user.UserRepository, auth.RefreshTokenRepository) {
+		return user.NewMemoryUserRepository(), auth.NewMemoryRefreshTokenRepository()
+	})
+}
+
+func TestSQLRefreshTokenRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			authtest.RunRefreshTokenRepositoryTests(t, func(t *testing.T) (user.UserRepository, auth.RefreshTokenRepository) {
+				db := databasetest.Open(t, driver)
+				return user.NewSQLUserRepository(db), auth.NewSQLRefreshTokenRepository(db)
+			})
+		})
+	}
+}
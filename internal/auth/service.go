@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pag-simples/internal/user"
+)
+
+type Config struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// TokenPair é a resposta de login e refresh.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type AuthService struct {
+	users      user.UserUsecase
+	tokens     RefreshTokenRepository
+	signer     *Signer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewAuthService(users user.UserUsecase, tokens RefreshTokenRepository, config Config) *AuthService {
+	return &AuthService{
+		users:      users,
+		tokens:     tokens,
+		signer:     NewSigner(config.Secret),
+		accessTTL:  config.AccessTTL,
+		refreshTTL: config.RefreshTTL,
+		now:        time.Now,
+	}
+}
+
+func (s *AuthService) Login(email string, password string) (*TokenPair, error) {
+	authenticated, err := s.users.Authenticate(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Login do usuário %d", authenticated.ID)
+	return s.issue(authenticated.ID, family)
+}
+
+// Refresh troca um refresh token por um novo par. Cada refresh token vale uma
+// única vez: apresentar de novo um token já trocado indica que ele vazou, e
+// toda a família é revogada.
+func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	now := s.now()
+	hash := hashToken(refreshToken)
+
+	token, err := s.tokens.GetRefreshToken(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Revoked() {
+		s.revokeReused(token, now)
+		return nil, ErrInvalidRefreshToken
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.tokens.RevokeRefreshToken(hash, now)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		// Outra requisição trocou o mesmo token entre a leitura e a revogação.
+		s.revokeReused(token, now)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issue(token.UserID, token.FamilyID)
+}
+
+func (s *AuthService) revokeReused(token *RefreshToken, now time.Time) {
+	log.Printf("Refresh token reutilizado pelo usuário %d, revogando a família %s", token.UserID, token.FamilyID)
+	if err := s.tokens.RevokeFamily(token.FamilyID, now); err != nil {
+		log.Printf("Erro ao revogar a família %s: %v", token.FamilyID, err)
+	}
+}
+
+// Logout revoga todos os refresh tokens do login que emitiu refreshToken.
+// Tokens desconhecidos não são erro; access tokens já emitidos valem até
+// expirar.
+func (s *AuthService) Logout(refreshToken string) error {
+	token, err := s.tokens.GetRefreshToken(hashToken(refreshToken))
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Logout do usuário %d", token.UserID)
+	return s.tokens.RevokeFamily(token.FamilyID, s.now())
+}
+
+// Authenticate recusa também os access tokens emitidos antes da última troca
+// de senha do usuário. iat tem precisão de segundos, então um token emitido no
+// mesmo segundo da troca ainda vale.
+func (s *AuthService) Authenticate(accessToken string) (*user.User, error) {
+	claims, err := s.signer.Verify(accessToken, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated, err := s.users.GetUser(claims.UserID)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !authenticated.PasswordChangedAt.IsZero() && claims.IssuedAt < authenticated.PasswordChangedAt.Unix() {
+		log.Printf("Access token do usuário %d emitido antes da troca de senha", authenticated.ID)
+		return nil, ErrUnauthorized
+	}
+	return authenticated, nil
+}
+
+func (s *AuthService) issue(userID int, family string) (*TokenPair, error) {
+	now := s.now()
+
+	accessToken, err := s.signer.Sign(Claims{
+		UserID:    userID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokens.CreateRefreshToken(&RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		UserID:    userID,
+		FamilyID:  family,
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func randomToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("erro ao gerar token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
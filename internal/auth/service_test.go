@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pag-simples/internal/user"
+	"pag-simples/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuthService(t *testing.T) (*AuthService, *MemoryRefreshTokenRepository) {
+	users := user.NewUserService(user.NewMemoryUserRepository())
+	require.NoError(t, users.SaveUser(&user.User{
+		FullName:       "João",
+		DocumentNumber: "52998224725",
+		Email:          "joao@email.com",
+		Password:       "senha123",
+		UserType:       user.CommonUser,
+	}))
+
+	tokens := NewMemoryRefreshTokenRepository()
+	service := NewAuthService(users, tokens, Config{
+		Secret:     []byte("segredo"),
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	return service, tokens
+}
+
+func TestLogin(t *testing.T) {
+	service, _ := newTestAuthService(t)
+
+	pair, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", pair.TokenType)
+	assert.Equal(t, 60, pair.ExpiresIn)
+
+	authenticated, err := service.Authenticate(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, authenticated.ID)
+
+	_, err = service.Login("joao@email.com", "errada123")
+	assert.True(t, errors.Is(err, user.ErrInvalidCredentials), "Login: %v", err)
+}
+
+func TestAuthenticateExpiredAccessToken(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	pair, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = service.Authenticate(pair.AccessToken)
+	assert.True(t, errors.Is(err, ErrUnauthorized), "Authenticate: %v", err)
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	service, _ := newTestAuthService(t)
+
+	first, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+
+	second, err := service.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	_, err = service.Authenticate(second.AccessToken)
+	assert.NoError(t, err)
+
+	_, err = service.Refresh("desconhecido")
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "token desconhecido: %v", err)
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	service, _ := newTestAuthService(t)
+
+	first, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+	other, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+
+	second, err := service.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+
+	_, err = service.Refresh(first.RefreshToken)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "token reutilizado: %v", err)
+
+	_, err = service.Refresh(second.RefreshToken)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "família revogada: %v", err)
+
+	_, err = service.Refresh(other.RefreshToken)
+	assert.NoError(t, err, "outros logins não são afetados")
+}
+
+func TestRefreshExpiredToken(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	pair, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = service.Refresh(pair.RefreshToken)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "Refresh: %v", err)
+}
+
+func TestLogout(t *testing.T) {
+	service, _ := newTestAuthService(t)
+
+	first, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+	second, err := service.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, service.Logout(second.RefreshToken))
+
+	_, err = service.Refresh(second.RefreshToken)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "Refresh: %v", err)
+
+	assert.NoError(t, service.Logout("desconhecido"))
+}
+
+type discardSender struct {
+	token string
+}
+
+func (s *discardSender) Send(_ notification.Recipient, _ notification.Event, data notification.Data) error {
+	s.token = data.Token
+	return nil
+}
+
+func TestPasswordUpdateRevokesSessions(t *testing.T) {
+	tokens := NewMemoryRefreshTokenRepository()
+	sender := &discardSender{}
+	users := user.NewUserService(user.NewMemoryUserRepository(),
+		user.WithPasswordReset(user.NewMemoryPasswordResetRepository(), sender),
+		user.WithSessionRevoker(tokens),
+	)
+	require.NoError(t, users.SaveUser(&user.User{
+		FullName:       "João",
+		DocumentNumber: "52998224725",
+		Email:          "joao@email.com",
+		Password:       "senha123",
+		UserType:       user.CommonUser,
+	}))
+	service := NewAuthService(users, tokens, Config{Secret: []byte("segredo"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+
+	phone, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+	laptop, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+
+	require.NoError(t, users.ChangePassword(1, "senha123", "novasenha1"))
+	for name, pair := range map[string]*TokenPair{"celular": phone, "notebook": laptop} {
+		_, err := service.Refresh(pair.RefreshToken)
+		assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "%s após troca: %v", name, err)
+	}
+
+	pair, err := service.Login("joao@email.com", "novasenha1")
+	require.NoError(t, err)
+	require.NoError(t, users.RequestPasswordReset("joao@email.com"))
+	require.NoError(t, users.ResetPassword(sender.token, "redefinida1"))
+	_, err = service.Refresh(pair.RefreshToken)
+	assert.True(t, errors.Is(err, ErrInvalidRefreshToken), "após redefinição: %v", err)
+}
+
+func TestPasswordChangeRejectsEarlierAccessTokens(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	users := service.users
+	now := time.Now().Add(-5 * time.Second)
+	service.now = func() time.Time { return now }
+
+	before, err := service.Login("joao@email.com", "senha123")
+	require.NoError(t, err)
+	_, err = service.Authenticate(before.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, users.ChangePassword(1, "senha123", "novasenha1"))
+	_, err = service.Authenticate(before.AccessToken)
+	assert.True(t, errors.Is(err, ErrUnauthorized), "token anterior à troca: %v", err)
+
+	now = time.Now()
+	after, err := service.Login("joao@email.com", "novasenha1")
+	require.NoError(t, err)
+	authenticated, err := service.Authenticate(after.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, authenticated.ID)
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pag-simples/internal/database"
+)
+
+type SQLRefreshTokenRepository struct {
+	db database.Executor
+}
+
+func NewSQLRefreshTokenRepository(db database.Executor) *SQLRefreshTokenRepository {
+	return &SQLRefreshTokenRepository{
+		db: db,
+	}
+}
+
+func (r *SQLRefreshTokenRepository) CreateRefreshToken(token *RefreshToken) error {
+	_, err := r.db.Exec(
+		"INSERT INTO refresh_tokens (token_hash, user_id, family_id, expires_at, revoked_at, created_at) VALUES ($1, $2, $3, $4, 0, $5)",
+		token.TokenHash, token.UserID, token.FamilyID, token.ExpiresAt.UnixMilli(), token.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar refresh token do usuário %d: %v", token.UserID, err)
+	}
+	return nil
+}
+
+func (r *SQLRefreshTokenRepository) GetRefreshToken(tokenHash string) (*RefreshToken, error) {
+	var expiresAt, revokedAt, createdAt int64
+	token := &RefreshToken{TokenHash: tokenHash}
+	err := r.db.QueryRow(
+		"SELECT user_id, family_id, expires_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1",
+		tokenHash,
+	).Scan(&token.UserID, &token.FamilyID, &expiresAt, &revokedAt, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar refresh token: %v", err)
+	}
+
+	token.ExpiresAt = time.UnixMilli(expiresAt)
+	if revokedAt != 0 {
+		token.RevokedAt = time.UnixMilli(revokedAt)
+	}
+	token.CreatedAt = time.UnixMilli(createdAt)
+	return token, nil
+}
+
+func (r *SQLRefreshTokenRepository) RevokeRefreshToken(tokenHash string, now time.Time) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE token_hash = $2 AND revoked_at = 0",
+		now.UnixMilli(), tokenHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar refresh token: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar refresh token: %v", err)
+	}
+	return affected == 1, nil
+}
+
+func (r *SQLRefreshTokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at = 0",
+		now.UnixMilli(), familyID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao revogar refresh tokens da família %s: %v", familyID, err)
+	}
+	return nil
+}
+
+func (r *SQLRefreshTokenRepository) RevokeUser(userID int, now time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at = 0",
+		now.UnixMilli(), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao revogar refresh tokens do usuário %d: %v", userID, err)
+	}
+	return nil
+}
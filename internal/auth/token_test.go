@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := NewSigner([]byte("segredo"))
+	now := time.Unix(1_700_000_000, 0)
+
+	token, err := signer.Sign(Claims{UserID: 7, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+
+	claims, err := signer.Verify(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+}
+
+func TestSignerRejectsInvalidTokens(t *testing.T) {
+	signer := NewSigner([]byte("segredo"))
+	now := time.Unix(1_700_000_000, 0)
+
+	token, err := signer.Sign(Claims{UserID: 7, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	forged, err := NewSigner([]byte("outro segredo")).Sign(Claims{UserID: 1, ExpiresAt: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	unsigned := `eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.` + parts[1] + "."
+
+	cases := map[string]struct {
+		token string
+		now   time.Time
+	}{
+		"vazio":             {"", now},
+		"malformado":        {"a.b", now},
+		"outra chave":       {forged, now},
+		"sem assinatura":    {unsigned, now},
+		"conteúdo alterado": {parts[0] + "." + parts[1] + "x." + parts[2], now},
+		"expirado":          {token, now.Add(time.Minute)},
+	}
+	for name, c := range cases {
+		_, err := signer.Verify(c.token, c.now)
+		assert.True(t, errors.Is(err, ErrUnauthorized), "%s: %v", name, err)
+	}
+}
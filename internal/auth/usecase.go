@@ -0,0 +1,11 @@
+package auth
+
+import "pag-simples/internal/user"
+
+type AuthUsecase interface {
+	Login(email string, password string) (*TokenPair, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	Logout(refreshToken string) error
+	// Authenticate devolve o dono de um access token válido.
+	Authenticate(accessToken string) (*user.User, error)
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Config reúne tudo o que cmd/api precisa para subir. Os valores vêm, em
+// ordem crescente de precedência, de Default, do arquivo YAML, das variáveis
+// de ambiente e das flags.
+type Config struct {
+	HTTP       HTTPConfig       `yaml:"http"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Authorizer AuthorizerConfig `yaml:"authorizer"`
+	Notifier   NotifierConfig   `yaml:"notifier"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Limits     LimitsConfig     `yaml:"limits"`
+	Logging    LoggingConfig    `yaml:"logging"`
+	// Seed só é lido do arquivo e só é aplicado com o banco vazio.
+	Seed []SeedUser `yaml:"seed"`
+}
+
+type HTTPConfig struct {
+	Addr            string        `yaml:"addr"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type StorageConfig struct {
+	Driver string `yaml:"driver"`
+	URL    string `yaml:"url"`
+}
+
+type AuthorizerConfig struct {
+	URL              string        `yaml:"url"`
+	Timeout          time.Duration `yaml:"timeout"`
+	MaxAttempts      int           `yaml:"max_attempts"`
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerTimeout   time.Duration `yaml:"breaker_timeout"`
+	// RulesFile vazio desativa o motor de regras local.
+	RulesFile   string        `yaml:"rules_file"`
+	RulesReload time.Duration `yaml:"rules_reload"`
+}
+
+type NotifierConfig struct {
+	SMSURL           string        `yaml:"sms_url"`
+	SMTP             SMTPConfig    `yaml:"smtp"`
+	DispatchInterval time.Duration `yaml:"dispatch_interval"`
+}
+
+// SMTPConfig com Addr vazio faz os e-mails irem só para o log.
+type SMTPConfig struct {
+	Addr     string `yaml:"addr"`
+	From     string `yaml:"from"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type AuthConfig struct {
+	// Secret assina os access tokens. Vazio, uma chave aleatória é gerada a
+	// cada início e os tokens emitidos deixam de valer ao reiniciar.
+	Secret     string        `yaml:"secret"`
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+// MinAuthSecretLength é o tamanho mínimo de auth.secret, em bytes.
+const MinAuthSecretLength = 32
+
+type LimitsConfig struct {
+	// TransferWorkers igual a zero desativa o modo assíncrono de POST /transfer.
+	TransferWorkers   int `yaml:"transfer_workers"`
+	TransferQueueSize int `yaml:"transfer_queue_size"`
+	// LowBalanceThreshold igual a zero desativa o aviso de saldo baixo.
+	LowBalanceThreshold decimal.Decimal `yaml:"low_balance_threshold"`
+	IdempotencyTTL      time.Duration   `yaml:"idempotency_ttl"`
+}
+
+type LoggingConfig struct {
+	// Output é stderr, stdout ou o caminho de um arquivo.
+	Output   string `yaml:"output"`
+	Requests bool   `yaml:"requests"`
+}
+
+type SeedUser struct {
+	ID             int             `yaml:"id"`
+	FullName       string          `yaml:"full_name"`
+	DocumentNumber string          `yaml:"document_number"`
+	Email          string          `yaml:"email"`
+	Password       string          `yaml:"password"`
+	UserType       string          `yaml:"user_type"`
+	Role           string          `yaml:"role"`
+	Balance        decimal.Decimal `yaml:"balance"`
+}
+
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Driver: "memory",
+		},
+		Authorizer: AuthorizerConfig{
+			URL:              "https://util.devi.tools/api/v2",
+			Timeout:          5 * time.Second,
+			MaxAttempts:      3,
+			BreakerThreshold: 5,
+			BreakerTimeout:   30 * time.Second,
+			RulesReload:      10 * time.Second,
+		},
+		Notifier: NotifierConfig{
+			SMSURL: "https://util.devi.tools/api/v1/notify",
+			SMTP: SMTPConfig{
+				From: "nao-responda@pag-simples.local",
+			},
+			DispatchInterval: time.Second,
+		},
+		Auth: AuthConfig{
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 30 * 24 * time.Hour,
+		},
+		Limits: LimitsConfig{
+			TransferWorkers:     4,
+			TransferQueueSize:   100,
+			LowBalanceThreshold: decimal.NewFromInt(100),
+			IdempotencyTTL:      24 * time.Hour,
+		},
+		Logging: LoggingConfig{
+			Output:   "stderr",
+			Requests: true,
+		},
+	}
+}
+
+// Validate devolve todos os problemas encontrados, um por linha.
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr é obrigatório")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout deve ser positivo")
+
+	switch c.Storage.Driver {
+	case "memory":
+	case "sqlite", "postgres":
+		check(c.Storage.URL != "", "storage.url é obrigatório para o armazenamento %s", c.Storage.Driver)
+	default:
+		check(false, "storage.driver %q não suportado: use memory, sqlite ou postgres", c.Storage.Driver)
+	}
+
+	check(validURL(c.Authorizer.URL), "authorizer.url %q não é uma URL http(s) válida", c.Authorizer.URL)
+	check(c.Authorizer.Timeout > 0, "authorizer.timeout deve ser positivo")
+	check(c.Authorizer.MaxAttempts >= 1, "authorizer.max_attempts deve ser pelo menos 1")
+	check(c.Authorizer.BreakerThreshold >= 1, "authorizer.breaker_threshold deve ser pelo menos 1")
+	check(c.Authorizer.BreakerTimeout > 0, "authorizer.breaker_timeout deve ser positivo")
+	check(c.Authorizer.RulesReload > 0, "authorizer.rules_reload deve ser positivo")
+
+	check(validURL(c.Notifier.SMSURL), "notifier.sms_url %q não é uma URL http(s) válida", c.Notifier.SMSURL)
+	check(c.Notifier.SMTP.Addr == "" || c.Notifier.SMTP.From != "", "notifier.smtp.from é obrigatório com notifier.smtp.addr")
+	check(c.Notifier.DispatchInterval > 0, "notifier.dispatch_interval deve ser positivo")
+
+	check(c.Auth.Secret == "" || len(c.Auth.Secret) >= MinAuthSecretLength, "auth.secret deve ter pelo menos %d bytes", MinAuthSecretLength)
+	check(c.Auth.AccessTTL > 0, "auth.access_ttl deve ser positivo")
+	check(c.Auth.RefreshTTL > c.Auth.AccessTTL, "auth.refresh_ttl deve ser maior que auth.access_ttl")
+
+	check(c.Limits.TransferWorkers >= 0, "limits.transfer_workers não pode ser negativo")
+	check(c.Limits.TransferWorkers == 0 || c.Limits.TransferQueueSize >= 1, "limits.transfer_queue_size deve ser pelo menos 1")
+	check(!c.Limits.LowBalanceThreshold.IsNegative(), "limits.low_balance_threshold não pode ser negativo")
+	check(c.Limits.IdempotencyTTL > 0, "limits.idempotency_ttl deve ser positivo")
+
+	check(c.Logging.Output != "", "logging.output é obrigatório")
+
+	ids := make(map[int]bool, len(c.Seed))
+	for i, seed := range c.Seed {
+		check(seed.ID > 0, "seed[%d].id deve ser positivo", i)
+		check(!ids[seed.ID], "seed[%d].id %d repetido", i, seed.ID)
+		ids[seed.ID] = true
+		check(!seed.Balance.IsNegative(), "seed[%d].balance não pode ser negativo", i)
+	}
+
+	return errors.Join(problems...)
+}
+
+func validURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
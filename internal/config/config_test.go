@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(name string) string { return values[name] }
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	config, err := Load(nil, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, Default(), config)
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfig(t, `
+http:
+  addr: ":9090"
+storage:
+  driver: sqlite
+  url: file:pag.db
+authorizer:
+  timeout: 2s
+limits:
+  low_balance_threshold: "50.5"
+seed:
+  - id: 1
+    full_name: João Silva
+    document_number: "12345678901"
+    email: joao@email.com
+    password: senha123
+    user_type: common_user
+    balance: 1000
+`)
+
+	config, err := Load([]string{"-config", path}, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", config.HTTP.Addr)
+	assert.Equal(t, "sqlite", config.Storage.Driver)
+	assert.Equal(t, "file:pag.db", config.Storage.URL)
+	assert.Equal(t, 2*time.Second, config.Authorizer.Timeout)
+	assert.Equal(t, Default().Authorizer.URL, config.Authorizer.URL, "chaves ausentes mantêm o padrão")
+	assert.True(t, config.Limits.LowBalanceThreshold.Equal(decimal.RequireFromString("50.5")))
+	require.Len(t, config.Seed, 1)
+	assert.Equal(t, "João Silva", config.Seed[0].FullName)
+	assert.True(t, config.Seed[0].Balance.Equal(decimal.NewFromInt(1000)))
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, "http:\n  addr: \":9090\"\nlimits:\n  transfer_workers: 2\n  transfer_queue_size: 10\n")
+
+	config, err := Load(
+		[]string{"-limits.transfer_workers", "8"},
+		env(map[string]string{
+			ConfigFileEnv:           path,
+			"HTTP_ADDR":             ":7070",
+			"TRANSFER_WORKERS":      "6",
+			"LOG_REQUESTS":          "false",
+			"LOW_BALANCE_THRESHOLD": "0",
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, ":7070", config.HTTP.Addr, "ambiente sobrepõe o arquivo")
+	assert.Equal(t, 8, config.Limits.TransferWorkers, "flag sobrepõe o ambiente")
+	assert.Equal(t, 10, config.Limits.TransferQueueSize, "arquivo sobrepõe o padrão")
+	assert.False(t, config.Logging.Requests)
+	assert.True(t, config.Limits.LowBalanceThreshold.IsZero())
+}
+
+func TestLoadRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		file     string
+		expected string
+	}{
+		{"UnknownKey", nil, nil, "http:\n  adr: \":9090\"\n", "field adr not found"},
+		{"MalformedFile", nil, nil, "http: [", "erro ao ler configuração"},
+		{"MissingFile", []string{"-config", "/inexistente.yaml"}, nil, "", "erro ao abrir configuração /inexistente.yaml"},
+		{"InvalidEnv", nil, map[string]string{"AUTHORIZER_TIMEOUT": "cinco"}, "", `AUTHORIZER_TIMEOUT inválido: "cinco"`},
+		{"InvalidFlag", []string{"-authorizer.max_attempts", "x"}, nil, "", `-authorizer.max_attempts inválido: "x"`},
+		{"UnknownFlag", []string{"-porta", "80"}, nil, "", "flag provided but not defined: -porta"},
+		{"ExtraArgs", []string{"serve"}, nil, "", "argumentos inesperados: [serve]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfig(t, tt.file)}, args...)
+			}
+			_, err := Load(args, env(tt.env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	_, err := Load([]string{"-h"}, env(nil))
+	assert.True(t, errors.Is(err, flag.ErrHelp), "Load: %v", err)
+}
+
+func TestValidate(t *testing.T) {
+	config := Default()
+	config.HTTP.Addr = ""
+	config.Storage.Driver = "postgres"
+	config.Authorizer.URL = "util.devi.tools"
+	config.Authorizer.MaxAttempts = 0
+	config.Notifier.SMTP = SMTPConfig{Addr: "smtp:25"}
+	config.Auth.Secret = "curto"
+	config.Limits.TransferQueueSize = 0
+	config.Limits.LowBalanceThreshold = decimal.NewFromInt(-1)
+	config.Seed = []SeedUser{{ID: 1}, {ID: 1, Balance: decimal.NewFromInt(-5)}}
+
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Equal(t, `http.addr é obrigatório
+storage.url é obrigatório para o armazenamento postgres
+authorizer.url "util.devi.tools" não é uma URL http(s) válida
+authorizer.max_attempts deve ser pelo menos 1
+notifier.smtp.from é obrigatório com notifier.smtp.addr
+auth.secret deve ter pelo menos 32 bytes
+limits.transfer_queue_size deve ser pelo menos 1
+limits.low_balance_threshold não pode ser negativo
+seed[1].id 1 repetido
+seed[1].balance não pode ser negativo`, err.Error())
+
+	config = Default()
+	config.Storage.Driver = "mysql"
+	assert.EqualError(t, config.Validate(), `storage.driver "mysql" não suportado: use memory, sqlite ou postgres`)
+	assert.NoError(t, Default().Validate())
+}
+
+// O config.yaml vai para a imagem: um papel de equipe com senha conhecida
+// daria acesso a qualquer instalação nova.
+func TestShippedConfigSeedsNoStaff(t *testing.T) {
+	config, err := Load([]string{"-config", filepath.Join("..", "..", "config.yaml")}, env(nil))
+	require.NoError(t, err)
+	for _, seed := range config.Seed {
+		assert.NotContains(t, []string{"support", "admin"}, seed.Role, "seed %d", seed.ID)
+	}
+
+	_, err = Load([]string{"-config", filepath.Join("..", "..", "config.dev.yaml")}, env(nil))
+	assert.NoError(t, err)
+}
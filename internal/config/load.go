@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv aponta o arquivo de configuração quando a flag -config não é
+// informada.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// binding liga uma opção à sua variável de ambiente e à sua flag, que tem o
+// mesmo nome da chave no arquivo.
+type binding struct {
+	key   string
+	env   string
+	usage string
+	set   func(config *Config, value string) error
+}
+
+func stringOption(key, env, usage string, field func(*Config) *string) binding {
+	return binding{key, env, usage, func(config *Config, value string) error {
+		*field(config) = value
+		return nil
+	}}
+}
+
+func intOption(key, env, usage string, field func(*Config) *int) binding {
+	return binding{key, env, usage, func(config *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = parsed
+		return nil
+	}}
+}
+
+func durationOption(key, env, usage string, field func(*Config) *time.Duration) binding {
+	return binding{key, env, usage, func(config *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = parsed
+		return nil
+	}}
+}
+
+func decimalOption(key, env, usage string, field func(*Config) *decimal.Decimal) binding {
+	return binding{key, env, usage, func(config *Config, value string) error {
+		parsed, err := decimal.NewFromString(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = parsed
+		return nil
+	}}
+}
+
+func boolOption(key, env, usage string, field func(*Config) *bool) binding {
+	return binding{key, env, usage, func(config *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = parsed
+		return nil
+	}}
+}
+
+var bindings = []binding{
+	stringOption("http.addr", "HTTP_ADDR", "endereço do servidor HTTP", func(c *Config) *string { return &c.HTTP.Addr }),
+	durationOption("http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT", "espera pelas requisições em andamento no encerramento", func(c *Config) *time.Duration { return &c.HTTP.ShutdownTimeout }),
+
+	stringOption("storage.driver", "STORAGE_DRIVER", "armazenamento: memory, sqlite ou postgres", func(c *Config) *string { return &c.Storage.Driver }),
+	stringOption("storage.url", "DATABASE_URL", "DSN do banco de dados", func(c *Config) *string { return &c.Storage.URL }),
+
+	stringOption("authorizer.url", "AUTHORIZER_URL", "URL base do autorizador externo", func(c *Config) *string { return &c.Authorizer.URL }),
+	durationOption("authorizer.timeout", "AUTHORIZER_TIMEOUT", "tempo máximo de cada tentativa de autorização", func(c *Config) *time.Duration { return &c.Authorizer.Timeout }),
+	intOption("authorizer.max_attempts", "AUTHORIZER_MAX_ATTEMPTS", "tentativas para falhas transitórias do autorizador", func(c *Config) *int { return &c.Authorizer.MaxAttempts }),
+	intOption("authorizer.breaker_threshold", "AUTHORIZER_BREAKER_THRESHOLD", "falhas seguidas que abrem o circuit breaker", func(c *Config) *int { return &c.Authorizer.BreakerThreshold }),
+	durationOption("authorizer.breaker_timeout", "AUTHORIZER_BREAKER_TIMEOUT", "tempo com o circuito aberto", func(c *Config) *time.Duration { return &c.Authorizer.BreakerTimeout }),
+	stringOption("authorizer.rules_file", "AUTHORIZATION_RULES_FILE", "arquivo de regras do motor de autorização local", func(c *Config) *string { return &c.Authorizer.RulesFile }),
+	durationOption("authorizer.rules_reload", "AUTHORIZATION_RULES_RELOAD", "intervalo de releitura do arquivo de regras", func(c *Config) *time.Duration { return &c.Authorizer.RulesReload }),
+
+	stringOption("notifier.sms_url", "NOTIFY_SMS_URL", "URL do gateway de SMS", func(c *Config) *string { return &c.Notifier.SMSURL }),
+	stringOption("notifier.smtp.addr", "NOTIFY_SMTP_ADDR", "servidor SMTP (host:porta)", func(c *Config) *string { return &c.Notifier.SMTP.Addr }),
+	stringOption("notifier.smtp.from", "NOTIFY_SMTP_FROM", "remetente dos e-mails", func(c *Config) *string { return &c.Notifier.SMTP.From }),
+	stringOption("notifier.smtp.username", "NOTIFY_SMTP_USERNAME", "usuário do servidor SMTP", func(c *Config) *string { return &c.Notifier.SMTP.Username }),
+	stringOption("notifier.smtp.password", "NOTIFY_SMTP_PASSWORD", "senha do servidor SMTP", func(c *Config) *string { return &c.Notifier.SMTP.Password }),
+	durationOption("notifier.dispatch_interval", "OUTBOX_INTERVAL", "intervalo entre as entregas do outbox", func(c *Config) *time.Duration { return &c.Notifier.DispatchInterval }),
+
+	stringOption("auth.secret", "AUTH_SECRET", "chave que assina os access tokens", func(c *Config) *string { return &c.Auth.Secret }),
+	durationOption("auth.access_ttl", "AUTH_ACCESS_TTL", "validade dos access tokens", func(c *Config) *time.Duration { return &c.Auth.AccessTTL }),
+	durationOption("auth.refresh_ttl", "AUTH_REFRESH_TTL", "validade dos refresh tokens", func(c *Config) *time.Duration { return &c.Auth.RefreshTTL }),
+
+	intOption("limits.transfer_workers", "TRANSFER_WORKERS", "workers das transferências assíncronas; 0 desativa o modo assíncrono", func(c *Config) *int { return &c.Limits.TransferWorkers }),
+	intOption("limits.transfer_queue_size", "TRANSFER_QUEUE_SIZE", "tamanho da fila de transferências assíncronas", func(c *Config) *int { return &c.Limits.TransferQueueSize }),
+	decimalOption("limits.low_balance_threshold", "LOW_BALANCE_THRESHOLD", "saldo que dispara o aviso de saldo baixo; 0 desativa", func(c *Config) *decimal.Decimal { return &c.Limits.LowBalanceThreshold }),
+	durationOption("limits.idempotency_ttl", "IDEMPOTENCY_TTL", "validade das chaves de idempotência", func(c *Config) *time.Duration { return &c.Limits.IdempotencyTTL }),
+
+	stringOption("logging.output", "LOG_OUTPUT", "destino do log: stderr, stdout ou um arquivo", func(c *Config) *string { return &c.Logging.Output }),
+	boolOption("logging.requests", "LOG_REQUESTS", "registra cada requisição HTTP", func(c *Config) *bool { return &c.Logging.Requests }),
+}
+
+// Load monta a configuração a partir de args (sem o nome do programa) e do
+// ambiente lido por getenv. Com -h, devolve flag.ErrHelp.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	flags := flag.NewFlagSet("api", flag.ContinueOnError)
+	path := flags.String("config", getenv(ConfigFileEnv), "arquivo de configuração YAML (env "+ConfigFileEnv+")")
+
+	// As flags só são aplicadas depois do arquivo e do ambiente.
+	values := map[string]string{}
+	for _, b := range bindings {
+		b := b
+		flags.Func(b.key, fmt.Sprintf("%s (env %s)", b.usage, b.env), func(value string) error {
+			values[b.key] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("argumentos inesperados: %v", flags.Args())
+	}
+
+	config := Default()
+	if *path != "" {
+		if err := config.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, b := range bindings {
+		value := getenv(b.env)
+		if value == "" {
+			continue
+		}
+		if err := b.set(config, value); err != nil {
+			return nil, fmt.Errorf("%s inválido: %q", b.env, value)
+		}
+	}
+
+	for _, b := range bindings {
+		value, ok := values[b.key]
+		if !ok {
+			continue
+		}
+		if err := b.set(config, value); err != nil {
+			return nil, fmt.Errorf("-%s inválido: %q", b.key, value)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("configuração inválida:\n%w", err)
+	}
+	return config, nil
+}
+
+// loadFile sobrepõe aos valores atuais só as chaves presentes no arquivo.
+// Chaves desconhecidas são rejeitadas para que erros de digitação não passem
+// despercebidos.
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir configuração %s: %v", path, err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("erro ao ler configuração %s: %v", path, err)
+	}
+	return nil
+}
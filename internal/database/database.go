@@ -0,0 +1,102 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type RowScanner interface {
+	Scan(dest ...any) error
+}
+
+type Executor interface {
+	Querier
+	Dialect() Dialect
+}
+
+type DB struct {
+	*sql.DB
+	dialect Dialect
+}
+
+func Open(driver string, dsn string) (*DB, error) {
+	dialect, err := DialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(dialect.DriverName(), dialect.ConfigureDSN(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir conexão com o banco %s: %v", driver, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao conectar ao banco %s: %v", driver, err)
+	}
+
+	log.Printf("Conectado ao banco de dados %s", driver)
+
+	return &DB{DB: db, dialect: dialect}, nil
+}
+
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	return &Tx{Tx: tx, dialect: db.dialect}, nil
+}
+
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (tx *Tx) Dialect() Dialect {
+	return tx.dialect
+}
+
+func RunInTx(executor Executor, fn func(tx Executor) error) error {
+	if tx, ok := executor.(*Tx); ok {
+		return fn(tx)
+	}
+
+	db, ok := executor.(*DB)
+	if !ok {
+		return fmt.Errorf("executor de banco de dados não suportado: %T", executor)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package databasetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pag-simples/internal/database"
+)
+
+const PostgresDSNEnv = "PAG_SIMPLES_TEST_POSTGRES_DSN"
+
+var Drivers = []string{"sqlite", "postgres"}
+
+func Open(t *testing.T, driver string) *database.DB {
+	t.Helper()
+
+	switch driver {
+	case "sqlite":
+		return OpenSQLite(t)
+	case "postgres":
+		return OpenPostgres(t)
+	default:
+		t.Fatalf("driver de testes desconhecido: %s", driver)
+		return nil
+	}
+}
+
+func OpenSQLite(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := database.Open("sqlite", filepath.Join(t.TempDir(), "pag-simples.db"))
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de testes: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("erro ao migrar banco de testes: %v", err)
+	}
+
+	return db
+}
+
+func OpenPostgres(t *testing.T) *database.DB {
+	t.Helper()
+
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("defina %s para executar os testes com PostgreSQL", PostgresDSNEnv)
+	}
+
+	db, err := database.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de testes: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public"); err != nil {
+		t.Fatalf("erro ao limpar banco de testes: %v", err)
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("erro ao migrar banco de testes: %v", err)
+	}
+
+	return db
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type Dialect interface {
+	Name() string
+	DriverName() string
+	ForUpdate() string
+	ResetSequence(table string, column string) string
+	ConfigureDSN(dsn string) string
+	// IsUniqueViolation indica se err veio de uma restrição UNIQUE ou de chave
+	// primária, o que acontece quando duas escritas concorrentes passam pela
+	// mesma verificação prévia.
+	IsUniqueViolation(err error) bool
+}
+
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "postgres":
+		return postgresDialect{}, nil
+	case "sqlite":
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("driver de banco de dados não suportado: %s", driver)
+	}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (postgresDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+func (postgresDialect) ResetSequence(table string, column string) string {
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), GREATEST((SELECT MAX(%s) FROM %s), 1))", table, column, column, table)
+}
+
+func (postgresDialect) ConfigureDSN(dsn string) string {
+	return dsn
+}
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) DriverName() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) ForUpdate() string {
+	return ""
+}
+
+func (sqliteDialect) ResetSequence(table string, column string) string {
+	return ""
+}
+
+// ConfigureDSN habilita WAL e transações IMMEDIATE: o SQLite bloqueia o banco
+// inteiro na escrita, então o lock é obtido já no BEGIN em vez de FOR UPDATE.
+func (sqliteDialect) ConfigureDSN(dsn string) string {
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate"
+}
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
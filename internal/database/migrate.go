@@ -0,0 +1,68 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+func Migrate(db *DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela de migrações: %v", err)
+	}
+
+	dir := path.Join("migrations", db.Dialect().Name())
+	files, err := fs.Glob(migrations, path.Join(dir, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("erro ao listar migrações: %v", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(path.Base(file), ".sql")
+
+		var applied int
+		if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&applied); err != nil {
+			return fmt.Errorf("erro ao consultar migração %s: %v", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := migrations.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("erro ao ler migração %s: %v", version, err)
+		}
+
+		err = RunInTx(db, func(tx Executor) error {
+			if precheck, ok := prechecks[version]; ok {
+				if err := precheck(tx); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.Exec(string(script)); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("erro ao aplicar migração %s: %v", version, err)
+		}
+
+		log.Printf("Migração %s aplicada", version)
+	}
+
+	return nil
+}
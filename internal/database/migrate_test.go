@@ -0,0 +1,48 @@
+package database_test
+
+import (
+	"strings"
+	"testing"
+
+	"pag-simples/internal/database"
+	"pag-simples/internal/database/databasetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDocumentsRejectsDuplicates(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			db := databasetest.Open(t, driver)
+
+			// Volta ao estado anterior à 0015, com documentos gravados como
+			// vieram do cadastro.
+			_, err := db.Exec("DELETE FROM schema_migrations WHERE version = '0015_normalize_documents'")
+			require.NoError(t, err)
+			for id, document := range map[int]string{1: "529.982.247-25", 2: "52998224725", 3: "111.444.777-35"} {
+				_, err := db.Exec(
+					"INSERT INTO users (id, full_name, document_number, email, password, user_type) VALUES ($1, $2, $3, $4, '', 'common_user')",
+					id, "usuario", document, strings.Repeat("u", id)+"@email.com",
+				)
+				require.NoError(t, err)
+			}
+
+			err = database.Migrate(db)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "52998224725 (usuários 1, 2)")
+			assert.NotContains(t, err.Error(), "11144477735")
+
+			var document string
+			require.NoError(t, db.QueryRow("SELECT document_number FROM users WHERE id = 3").Scan(&document))
+			assert.Equal(t, "111.444.777-35", document, "a migração recusada não pode alterar os dados")
+
+			_, err = db.Exec("UPDATE users SET document_number = '39053344705' WHERE id = 2")
+			require.NoError(t, err)
+			require.NoError(t, database.Migrate(db))
+
+			require.NoError(t, db.QueryRow("SELECT document_number FROM users WHERE id = 3").Scan(&document))
+			assert.Equal(t, "11144477735", document)
+		})
+	}
+}
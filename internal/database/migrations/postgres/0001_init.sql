@@ -0,0 +1,49 @@
+CREATE TABLE users (
+	id BIGSERIAL PRIMARY KEY,
+	full_name TEXT NOT NULL,
+	document_number TEXT NOT NULL UNIQUE,
+	email TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	user_type TEXT NOT NULL
+);
+
+CREATE TABLE ledger_accounts (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	allow_negative BOOLEAN NOT NULL DEFAULT FALSE,
+	balance NUMERIC NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE ledger_entries (
+	id TEXT PRIMARY KEY,
+	seq BIGSERIAL NOT NULL UNIQUE,
+	description TEXT NOT NULL,
+	reference TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE ledger_postings (
+	entry_id TEXT NOT NULL REFERENCES ledger_entries (id),
+	position INTEGER NOT NULL,
+	account_id TEXT NOT NULL REFERENCES ledger_accounts (id),
+	amount NUMERIC NOT NULL,
+	PRIMARY KEY (entry_id, position)
+);
+
+CREATE INDEX ledger_postings_account_idx ON ledger_postings (account_id);
+
+CREATE TABLE transfers (
+	id TEXT PRIMARY KEY,
+	value NUMERIC NOT NULL,
+	payer BIGINT NOT NULL,
+	payee BIGINT NOT NULL
+);
+
+CREATE TABLE transactions (
+	id TEXT PRIMARY KEY,
+	transfer_id TEXT NOT NULL REFERENCES transfers (id),
+	amount NUMERIC NOT NULL,
+	status TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
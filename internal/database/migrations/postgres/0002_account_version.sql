@@ -0,0 +1 @@
+ALTER TABLE ledger_accounts ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
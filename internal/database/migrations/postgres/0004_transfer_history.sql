@@ -0,0 +1,7 @@
+ALTER TABLE transfers ADD COLUMN seq BIGSERIAL;
+ALTER TABLE transfers ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX transfers_seq_idx ON transfers (seq);
+CREATE INDEX transfers_payer_idx ON transfers (payer, seq);
+CREATE INDEX transfers_payee_idx ON transfers (payee, seq);
+CREATE INDEX transactions_transfer_idx ON transactions (transfer_id);
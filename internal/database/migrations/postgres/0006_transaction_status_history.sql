@@ -0,0 +1,16 @@
+CREATE TABLE transaction_status_history (
+	seq BIGSERIAL PRIMARY KEY,
+	transaction_id TEXT NOT NULL REFERENCES transactions (id),
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	created_at BIGINT NOT NULL
+);
+
+CREATE INDEX transaction_status_history_transaction_idx ON transaction_status_history (transaction_id, seq);
+
+-- Transações anteriores ao ciclo de vida só registravam sucesso.
+UPDATE transactions SET status = 'settled' WHERE status = 'sucesso';
+
+INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, created_at)
+SELECT id, '', status, 'migração', 0 FROM transactions;
@@ -0,0 +1,5 @@
+ALTER TABLE transfers ADD COLUMN callback_url TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX transactions_status_idx ON transactions (status);
+
+ALTER TABLE idempotency_keys ADD COLUMN location TEXT NOT NULL DEFAULT '';
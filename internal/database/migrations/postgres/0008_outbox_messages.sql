@@ -0,0 +1,14 @@
+CREATE TABLE outbox_messages (
+	seq BIGSERIAL PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE,
+	topic TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at BIGINT NOT NULL,
+	created_at BIGINT NOT NULL,
+	updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX outbox_messages_due_idx ON outbox_messages (status, next_attempt_at);
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN phone TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users ADD COLUMN webhook_url TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users ADD COLUMN notification_channel TEXT NOT NULL DEFAULT 'email';
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'pt-BR';
@@ -0,0 +1 @@
+ALTER TABLE transactions ADD COLUMN authorization_decision TEXT NOT NULL DEFAULT '';
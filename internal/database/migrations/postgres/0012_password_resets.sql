@@ -0,0 +1,7 @@
+CREATE TABLE password_resets (
+	token_hash TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users (id),
+	expires_at BIGINT NOT NULL,
+	used_at BIGINT NOT NULL DEFAULT 0,
+	created_at BIGINT NOT NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE refresh_tokens (
+	token_hash TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users (id),
+	family_id TEXT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	revoked_at BIGINT NOT NULL DEFAULT 0,
+	created_at BIGINT NOT NULL
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
@@ -0,0 +1,2 @@
+-- Documentos que só diferem pela pontuação são recusados antes, em prechecks.go.
+UPDATE users SET document_number = UPPER(REPLACE(REPLACE(REPLACE(REPLACE(document_number, '.', ''), '-', ''), '/', ''), ' ', ''));
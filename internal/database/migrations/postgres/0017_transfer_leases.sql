@@ -0,0 +1,2 @@
+ALTER TABLE transfers ADD COLUMN lease_owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE transfers ADD COLUMN lease_until BIGINT NOT NULL DEFAULT 0;
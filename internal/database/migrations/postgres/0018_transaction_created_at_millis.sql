@@ -0,0 +1,2 @@
+-- created_at passa a ser UnixMilli, como nas demais tabelas.
+ALTER TABLE transactions ALTER COLUMN created_at TYPE BIGINT USING (floor(EXTRACT(EPOCH FROM created_at) * 1000))::BIGINT;
@@ -0,0 +1 @@
+ALTER TABLE ledger_accounts ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
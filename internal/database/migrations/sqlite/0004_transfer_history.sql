@@ -0,0 +1,21 @@
+-- SQLite não adiciona colunas AUTOINCREMENT com ALTER TABLE; a tabela é
+-- recriada e a checagem das chaves estrangeiras fica para o commit.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE transfers_new (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	id TEXT NOT NULL UNIQUE,
+	value TEXT NOT NULL,
+	payer INTEGER NOT NULL,
+	payee INTEGER NOT NULL,
+	created_at INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO transfers_new (id, value, payer, payee) SELECT id, value, payer, payee FROM transfers;
+
+DROP TABLE transfers;
+ALTER TABLE transfers_new RENAME TO transfers;
+
+CREATE INDEX transfers_payer_idx ON transfers (payer, seq);
+CREATE INDEX transfers_payee_idx ON transfers (payee, seq);
+CREATE INDEX transactions_transfer_idx ON transactions (transfer_id);
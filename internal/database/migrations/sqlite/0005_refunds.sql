@@ -0,0 +1,4 @@
+ALTER TABLE transfers ADD COLUMN refund_of TEXT REFERENCES transfers (id);
+ALTER TABLE transfers ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX transfers_refund_of_idx ON transfers (refund_of);
@@ -0,0 +1,7 @@
+CREATE TABLE password_resets (
+	token_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	expires_at INTEGER NOT NULL,
+	used_at INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);
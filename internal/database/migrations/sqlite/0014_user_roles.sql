@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'customer';
+UPDATE users SET role = 'merchant' WHERE user_type = 'merchant';
@@ -0,0 +1 @@
+CREATE INDEX refresh_tokens_user_idx ON refresh_tokens (user_id);
@@ -0,0 +1,39 @@
+-- created_at passa a ser UnixMilli, como nas demais tabelas. SQLite não muda o
+-- tipo de uma coluna; a tabela é recriada e a checagem das chaves
+-- estrangeiras fica para o commit.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE transactions_new (
+	id TEXT PRIMARY KEY,
+	transfer_id TEXT NOT NULL REFERENCES transfers (id),
+	amount TEXT NOT NULL,
+	status TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	authorization_decision TEXT NOT NULL DEFAULT ''
+);
+
+-- O driver gravava as datas no formato de time.Time.String, como
+-- "2024-05-15 10:00:00.123456789 -0300 -03", que as funções de data do SQLite
+-- não reconhecem: data, fração de segundo e fuso são separados à mão.
+INSERT INTO transactions_new (id, transfer_id, amount, status, created_at, authorization_decision)
+SELECT id, transfer_id, amount, status,
+	(CAST(strftime('%s', substr(created_at, 1, 19)) AS INTEGER) - offset_seconds) * 1000 + millis,
+	authorization_decision
+FROM (
+	SELECT *,
+		CAST(substr(fraction || '000', 1, 3) AS INTEGER) AS millis,
+		(CAST(substr(zone, 2, 2) AS INTEGER) * 3600 + CAST(substr(zone, 4, 2) AS INTEGER) * 60)
+			* (CASE substr(zone, 1, 1) WHEN '-' THEN -1 ELSE 1 END) AS offset_seconds
+	FROM (
+		SELECT *,
+			CASE WHEN substr(rest, 1, 1) = '.' THEN substr(rest, 2, instr(rest, ' ') - 2) ELSE '' END AS fraction,
+			substr(rest, instr(rest, ' ') + 1, 5) AS zone
+		FROM (SELECT *, substr(created_at, 20) AS rest FROM transactions)
+	)
+);
+
+DROP TABLE transactions;
+ALTER TABLE transactions_new RENAME TO transactions;
+
+CREATE INDEX transactions_transfer_idx ON transactions (transfer_id);
+CREATE INDEX transactions_status_idx ON transactions (status);
@@ -0,0 +1,3 @@
+-- Momento da última troca de senha, em UnixMilli; access tokens emitidos antes
+-- dele são recusados.
+ALTER TABLE users ADD COLUMN password_changed_at INTEGER NOT NULL DEFAULT 0;
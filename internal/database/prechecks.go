@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// prechecks rodam antes do script da migração de mesmo nome, na mesma
+// transação, e recusam dados que fariam o script falhar com um erro do banco
+// que não diz quais linhas corrigir.
+var prechecks = map[string]func(tx Executor) error{
+	"0015_normalize_documents": duplicateDocuments,
+}
+
+// normalizedDocument é a mesma expressão usada no UPDATE da migração 0015.
+const normalizedDocument = "UPPER(REPLACE(REPLACE(REPLACE(REPLACE(document_number, '.', ''), '-', ''), '/', ''), ' ', ''))"
+
+// duplicateDocuments lista os usuários cujos documentos só diferem pela
+// pontuação e, por isso, violariam o UNIQUE de document_number depois de
+// normalizados.
+func duplicateDocuments(tx Executor) error {
+	rows, err := tx.Query("SELECT id, " + normalizedDocument + " FROM users ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("erro ao buscar documentos dos usuários: %v", err)
+	}
+	defer rows.Close()
+
+	var documents []string
+	ids := map[string][]string{}
+	for rows.Next() {
+		var id int
+		var document string
+		if err := rows.Scan(&id, &document); err != nil {
+			return fmt.Errorf("erro ao ler documento de usuário: %v", err)
+		}
+		if _, ok := ids[document]; !ok {
+			documents = append(documents, document)
+		}
+		ids[document] = append(ids[document], strconv.Itoa(id))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao buscar documentos dos usuários: %v", err)
+	}
+
+	var duplicates []string
+	for _, document := range documents {
+		if len(ids[document]) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s (usuários %s)", document, strings.Join(ids[document], ", ")))
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("documentos repetidos depois de remover a pontuação, corrija-os antes de migrar: %s", strings.Join(duplicates, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package authn autentica as requisições HTTP pelo access token enviado em
+// Authorization: Bearer.
+package authn
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"pag-simples/internal/auth"
+	"pag-simples/internal/http/httperror"
+	"pag-simples/internal/user"
+)
+
+type Authenticator interface {
+	Authenticate(accessToken string) (*user.User, error)
+}
+
+type contextKey struct{}
+
+// WithUser devolve uma cópia de ctx com o usuário autenticado.
+func WithUser(ctx context.Context, authenticated *user.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, authenticated)
+}
+
+// User devolve o usuário autenticado pela Middleware.
+func User(ctx context.Context) (*user.User, bool) {
+	authenticated, ok := ctx.Value(contextKey{}).(*user.User)
+	return authenticated, ok
+}
+
+// Middleware rejeita com 401 as requisições sem um access token válido e
+// coloca o dono do token no contexto das demais.
+func Middleware(authenticator Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				unauthorized(w, auth.ErrUnauthorized)
+				return
+			}
+
+			authenticated, err := authenticator.Authenticate(token)
+			if err != nil {
+				unauthorized(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), authenticated)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="pag-simples"`)
+	httperror.Write(w, err)
+}
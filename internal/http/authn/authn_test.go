@@ -0,0 +1,60 @@
+package authn
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pag-simples/internal/auth"
+	"pag-simples/internal/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenAuthenticator map[string]*user.User
+
+func (a tokenAuthenticator) Authenticate(accessToken string) (*user.User, error) {
+	if authenticated, ok := a[accessToken]; ok {
+		return authenticated, nil
+	}
+	return nil, auth.ErrUnauthorized
+}
+
+func TestMiddleware(t *testing.T) {
+	authenticator := tokenAuthenticator{"valido": {ID: 7}}
+	handler := Middleware(authenticator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated, ok := User(r.Context())
+		if !ok {
+			t.Error(errors.New("usuário ausente no contexto"))
+			return
+		}
+		assert.Equal(t, 7, authenticated.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := map[string]struct {
+		header string
+		status int
+	}{
+		"sem cabeçalho":     {"", http.StatusUnauthorized},
+		"outro esquema":     {"Basic valido", http.StatusUnauthorized},
+		"token inválido":    {"Bearer invalido", http.StatusUnauthorized},
+		"token válido":      {"Bearer valido", http.StatusNoContent},
+		"esquema minúsculo": {"bearer valido", http.StatusNoContent},
+	}
+	for name, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/transfer", nil)
+		if c.header != "" {
+			r.Header.Set("Authorization", c.header)
+		}
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, c.status, w.Code, name)
+		if c.status == http.StatusUnauthorized {
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer", name)
+		}
+	}
+}
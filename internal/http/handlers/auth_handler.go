@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"pag-simples/internal/auth"
+	"pag-simples/internal/http/httperror"
+)
+
+type AuthHandler struct {
+	authService auth.AuthUsecase
+}
+
+func NewAuthHandler(authService auth.AuthUsecase) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var request loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	pair, err := h.authService.Login(request.Email, request.Password)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	writeTokenPair(w, pair)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	pair, err := h.authService.Refresh(request.RefreshToken)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	writeTokenPair(w, pair)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var request refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	if err := h.authService.Logout(request.RefreshToken); err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTokenPair(w http.ResponseWriter, pair *auth.TokenPair) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(pair)
+}
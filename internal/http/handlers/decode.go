@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pag-simples/internal/validation"
+)
+
+// decodeStrict lê o corpo em v recusando campos que v não tem. Campos
+// desconhecidos e valores do tipo errado voltam como erros de campo; o resto
+// vira errInvalidBody.
+func decodeStrict(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	var errs validation.Errors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		errs.Add(typeErr.Field, validation.CodeInvalidType, fmt.Sprintf("esperado %s, recebido %s", typeErr.Type, typeErr.Value))
+	// encoding/json não tem um tipo para este erro, só a mensagem.
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		errs.Add(field, validation.CodeUnknownField, "campo não aceito")
+	default:
+		return errInvalidBody
+	}
+	return errs.Err()
+}
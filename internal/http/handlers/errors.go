@@ -0,0 +1,5 @@
+package handlers
+
+import "pag-simples/internal/apperrors"
+
+var errInvalidBody = apperrors.New(apperrors.CodeInvalidRequest, "erro ao ler o corpo da requisição")
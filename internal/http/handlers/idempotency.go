@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/http/authn"
+	"pag-simples/internal/http/httperror"
+	"pag-simples/internal/idempotency"
+)
+
+const (
+	IdempotencyKeyHeader    = "Idempotency-Key"
+	IdempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+)
+
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// withIdempotency executa next uma única vez por Idempotency-Key e repete a
+// resposta armazenada nas requisições seguintes com o mesmo conteúdo.
+func withIdempotency(service idempotency.IdempotencyUseCase, w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" || service == nil {
+		next(w, r)
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		httperror.Write(w, apperrors.New(apperrors.CodeInvalidRequest, "Idempotency-Key inválida").WithDetails(map[string]interface{}{
+			"header":     IdempotencyKeyHeader,
+			"max_length": maxIdempotencyKeyLength,
+		}))
+		return
+	}
+
+	// Cada usuário tem as suas chaves: a mesma chave enviada por outro usuário
+	// não repete a resposta dada ao primeiro.
+	if authenticated, ok := authn.User(r.Context()); ok {
+		key = fmt.Sprintf("user:%d:%s", authenticated.ID, key)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	record, err := service.Begin(key, idempotency.HashRequest(r.Method, r.URL.Path, body))
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	if record != nil {
+		if record.ContentType != "" {
+			w.Header().Set("Content-Type", record.ContentType)
+		}
+		if record.Location != "" {
+			w.Header().Set("Location", record.Location)
+		}
+		w.Header().Set(IdempotentReplayHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: w}
+	next(recorder, r)
+
+	// Falhas internas liberam a chave para que o cliente possa tentar de novo.
+	if recorder.statusCode == 0 || recorder.statusCode >= http.StatusInternalServerError {
+		if err := service.Release(key); err != nil {
+			log.Printf("Erro ao liberar Idempotency-Key %s: %v", key, err)
+		}
+		return
+	}
+
+	err = service.Complete(key, idempotency.Response{
+		StatusCode:  recorder.statusCode,
+		ContentType: recorder.Header().Get("Content-Type"),
+		Location:    recorder.Header().Get("Location"),
+		Body:        recorder.body.Bytes(),
+	})
+	if err != nil {
+		log.Printf("Erro ao salvar resposta da Idempotency-Key %s: %v", key, err)
+	}
+}
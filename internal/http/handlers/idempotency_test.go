@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/http/authn"
+	"pag-simples/internal/idempotency"
+	"pag-simples/internal/transfer"
+	"pag-simples/internal/user"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTransferService struct {
+	calls   int
+	err     error
+	request transfer.TransferRequest
+	// transfer, quando definida, é a única transferência existente.
+	transfer *transfer.TransferDetails
+}
+
+func (s *countingTransferService) Transfer(value decimal.Decimal, payerID int, payeeID int) error {
+	s.calls++
+	return s.err
+}
+
+func (s *countingTransferService) Execute(ctx context.Context, request transfer.TransferRequest) error {
+	s.request = request
+	return s.Transfer(request.Value, request.Payer, request.Payee)
+}
+
+func (s *countingTransferService) GetTransfer(transferID string) (*transfer.TransferDetails, error) {
+	if s.transfer != nil && s.transfer.ID == transferID {
+		return s.transfer, nil
+	}
+	return nil, transfer.ErrTransferNotFound
+}
+
+func (s *countingTransferService) Submit(request transfer.TransferRequest) (*transfer.TransferDetails, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &transfer.TransferDetails{Transfer: transfer.Transfer{ID: "t1"}}, nil
+}
+
+func (s *countingTransferService) Resume() (int, error) {
+	return 0, nil
+}
+
+func (s *countingTransferService) GetStatusHistory(transferID string) ([]transfer.StatusChange, error) {
+	if s.transfer != nil && s.transfer.ID == transferID {
+		return []transfer.StatusChange{}, nil
+	}
+	return nil, transfer.ErrTransferNotFound
+}
+
+func (s *countingTransferService) ListTransfers(filter transfer.TransferFilter) (*transfer.TransferPage, error) {
+	return &transfer.TransferPage{}, nil
+}
+
+func (s *countingTransferService) Refund(transferID string, request transfer.RefundRequest) (*transfer.Transfer, error) {
+	if s.transfer != nil && s.transfer.ID == transferID {
+		s.calls++
+		return &transfer.Transfer{ID: "estorno", Payer: s.transfer.Payee, Payee: s.transfer.Payer}, nil
+	}
+	return nil, transfer.ErrTransferNotFound
+}
+
+// newTransferRequest monta um POST /transfer já autenticado como userID.
+func newTransferRequest(userID int, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/transfer", strings.NewReader(body))
+	return req.WithContext(authn.WithUser(req.Context(), &user.User{ID: userID}))
+}
+
+func postTransfer(handler *TransferHandler, key string, body string) *httptest.ResponseRecorder {
+	return postTransferAs(handler, 1, key, body)
+}
+
+func postTransferAs(handler *TransferHandler, userID int, key string, body string) *httptest.ResponseRecorder {
+	req := newTransferRequest(userID, body)
+	req.Header.Set(IdempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	handler.Transfer(rec, req)
+	return rec
+}
+
+func TestTransferIdempotencyKey(t *testing.T) {
+	transferService := &countingTransferService{}
+	idempotencyService := idempotency.NewIdempotencyService(idempotency.NewMemoryIdempotencyRepository(), time.Hour)
+	handler := NewTransferHandler(transferService, idempotencyService)
+
+	first := postTransfer(handler, "k1", `{"value": 10, "payer": 1, "payee": 2}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, 1, transferService.calls)
+
+	replay := postTransfer(handler, "k1", `{"value":10,"payer":1,"payee":2}`)
+	assert.Equal(t, http.StatusOK, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayHeader))
+	assert.Equal(t, 1, transferService.calls)
+
+	mismatch := postTransfer(handler, "k1", `{"value":20,"payer":1,"payee":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	assert.Equal(t, 1, transferService.calls)
+
+	other := postTransfer(handler, "k2", `{"value":10,"payer":1,"payee":2}`)
+	assert.Equal(t, http.StatusOK, other.Code)
+	assert.Equal(t, 2, transferService.calls)
+}
+
+func TestTransferAsyncReplaysLocation(t *testing.T) {
+	transferService := &countingTransferService{}
+	idempotencyService := idempotency.NewIdempotencyService(idempotency.NewMemoryIdempotencyRepository(), time.Hour)
+	handler := NewTransferHandler(transferService, idempotencyService)
+
+	post := func() *httptest.ResponseRecorder {
+		req := newTransferRequest(1, `{"value":10,"payer":1,"payee":2}`)
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		req.Header.Set(PreferHeader, "respond-async")
+		rec := httptest.NewRecorder()
+		handler.Transfer(rec, req)
+		return rec
+	}
+
+	first := post()
+	assert.Equal(t, http.StatusAccepted, first.Code)
+	assert.Equal(t, "/transfers/t1", first.Header().Get("Location"))
+
+	replay := post()
+	assert.Equal(t, http.StatusAccepted, replay.Code)
+	assert.Equal(t, "/transfers/t1", replay.Header().Get("Location"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 1, transferService.calls)
+}
+
+func TestTransferReplaysDomainErrors(t *testing.T) {
+	transferService := &countingTransferService{err: apperrors.ErrMerchantCannotPay}
+	idempotencyService := idempotency.NewIdempotencyService(idempotency.NewMemoryIdempotencyRepository(), time.Hour)
+	handler := NewTransferHandler(transferService, idempotencyService)
+
+	first := postTransferAs(handler, 3, "k1", `{"value":10,"payer":3,"payee":1}`)
+	assert.Equal(t, http.StatusForbidden, first.Code)
+	assert.JSONEq(t, `{"code":"merchant_cannot_pay","message":"um lojista não pode realizar transferências"}`, first.Body.String())
+
+	replay := postTransferAs(handler, 3, "k1", `{"value":10,"payer":3,"payee":1}`)
+	assert.Equal(t, http.StatusForbidden, replay.Code)
+	assert.Equal(t, "application/json", replay.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 1, transferService.calls)
+}
+
+func TestTransferInternalErrorsReleaseKey(t *testing.T) {
+	transferService := &countingTransferService{err: errors.New("banco indisponível")}
+	idempotencyService := idempotency.NewIdempotencyService(idempotency.NewMemoryIdempotencyRepository(), time.Hour)
+	handler := NewTransferHandler(transferService, idempotencyService)
+
+	first := postTransfer(handler, "k1", `{"value":10,"payer":1,"payee":2}`)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.JSONEq(t, `{"code":"internal_error","message":"erro interno"}`, first.Body.String())
+
+	transferService.err = nil
+	retry := postTransfer(handler, "k1", `{"value":10,"payer":1,"payee":2}`)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, 2, transferService.calls)
+}
+
+func TestTransferIdempotencyKeyIsPerUser(t *testing.T) {
+	transferService := &countingTransferService{}
+	idempotencyService := idempotency.NewIdempotencyService(idempotency.NewMemoryIdempotencyRepository(), time.Hour)
+	handler := NewTransferHandler(transferService, idempotencyService)
+
+	first := postTransferAs(handler, 1, "k1", `{"value":10,"payee":3}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	other := postTransferAs(handler, 2, "k1", `{"value":10,"payee":3}`)
+	assert.Equal(t, http.StatusOK, other.Code)
+	assert.Empty(t, other.Header().Get(IdempotentReplayHeader))
+	assert.Equal(t, 2, transferService.calls)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+
+	"pag-simples/pkg/authorization"
+)
+
+const DeviceIDHeader = "X-Device-ID"
+
+// requestMetadata coleta os dados da origem da requisição enviados ao
+// autorizador. O IP vem da conexão: X-Forwarded-For é controlado pelo cliente.
+func requestMetadata(r *http.Request) authorization.Metadata {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return authorization.Metadata{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+		DeviceID:  r.Header.Get(DeviceIDHeader),
+	}
+}
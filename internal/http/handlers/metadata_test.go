@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pag-simples/pkg/authorization"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMetadata(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/transfer", nil)
+	req.RemoteAddr = "203.0.113.7:52311"
+	req.Header.Set("User-Agent", "app/1.0")
+	req.Header.Set(DeviceIDHeader, "device-1")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	assert.Equal(t, authorization.Metadata{IP: "203.0.113.7", UserAgent: "app/1.0", DeviceID: "device-1"}, requestMetadata(req))
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+)
+
+// Metrics publica só as variáveis expvar indicadas, no formato de
+// expvar.Handler. O handler padrão também expõe cmdline e memstats, e a linha
+// de comando pode trazer segredos passados por flag.
+func Metrics(names ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, "{")
+		first := true
+		for _, name := range names {
+			value := expvar.Get(name)
+			if value == nil {
+				continue
+			}
+			if !first {
+				fmt.Fprint(w, ",")
+			}
+			first = false
+			fmt.Fprintf(w, "\n%q: %s", name, value.String())
+		}
+		fmt.Fprint(w, "\n}\n")
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsPublishesOnlyNamedVars(t *testing.T) {
+	expvar.NewInt("metrics_test_requests").Set(3)
+
+	rec := httptest.NewRecorder()
+	Metrics("metrics_test_requests", "inexistente")(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+
+	var vars map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &vars), rec.Body.String())
+	assert.Equal(t, map[string]json.RawMessage{"metrics_test_requests": json.RawMessage("3")}, vars)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"pag-simples/internal/http/httperror"
+	"pag-simples/internal/outbox"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type OutboxHandler struct {
+	outboxService outbox.OutboxUseCase
+}
+
+func NewOutboxHandler(outboxService outbox.OutboxUseCase) *OutboxHandler {
+	return &OutboxHandler{outboxService: outboxService}
+}
+
+// ListMessages aceita status, cursor e limit; sem status lista todas.
+func (h *OutboxHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, err := parseLimit(values.Get("limit"))
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	page, err := h.outboxService.ListMessages(outbox.MessageFilter{
+		Status: outbox.Status(values.Get("status")),
+		Cursor: values.Get("cursor"),
+		Limit:  limit,
+	})
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *OutboxHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	message, err := h.outboxService.Replay(chi.URLParam(r, "id"))
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
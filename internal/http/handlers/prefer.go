@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	PreferHeader            = "Prefer"
+	PreferenceAppliedHeader = "Preference-Applied"
+	respondAsync            = "respond-async"
+)
+
+// prefersAsync indica se o cliente pediu processamento assíncrono com
+// "Prefer: respond-async" (RFC 7240), entre outras preferências.
+func prefersAsync(r *http.Request) bool {
+	for _, header := range r.Header.Values(PreferHeader) {
+		for _, preference := range strings.Split(header, ",") {
+			token := strings.TrimSpace(strings.SplitN(preference, ";", 2)[0])
+			if strings.EqualFold(token, respondAsync) {
+				return true
+			}
+		}
+	}
+	return false
+}
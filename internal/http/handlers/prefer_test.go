@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/transfer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefersAsync(t *testing.T) {
+	tests := []struct {
+		headers []string
+		async   bool
+	}{
+		{nil, false},
+		{[]string{"respond-async"}, true},
+		{[]string{"Respond-Async"}, true},
+		{[]string{"return=minimal, respond-async; wait=10"}, true},
+		{[]string{"return=minimal", "respond-async"}, true},
+		{[]string{"return=representation"}, false},
+		{[]string{"respond-asynchronously"}, false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/transfer", nil)
+		for _, header := range tt.headers {
+			req.Header.Add(PreferHeader, header)
+		}
+		assert.Equal(t, tt.async, prefersAsync(req), "%q", tt.headers)
+	}
+}
+
+func TestTransferAsync(t *testing.T) {
+	transferService := &countingTransferService{}
+	handler := NewTransferHandler(transferService, nil)
+
+	req := newTransferRequest(1, `{"value":10,"payer":1,"payee":2,"callback_url":"https://loja.example/webhook"}`)
+	req.Header.Set(PreferHeader, "respond-async")
+	rec := httptest.NewRecorder()
+	handler.Transfer(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "/transfers/t1", rec.Header().Get("Location"))
+	assert.Equal(t, "respond-async", rec.Header().Get(PreferenceAppliedHeader))
+
+	var details transfer.TransferDetails
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&details))
+	assert.Equal(t, "t1", details.ID)
+
+	transferService.err = apperrors.ErrAsyncUnavailable
+	rec = httptest.NewRecorder()
+	req = newTransferRequest(1, `{"value":10,"payer":1,"payee":2}`)
+	req.Header.Set(PreferHeader, "respond-async")
+	handler.Transfer(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
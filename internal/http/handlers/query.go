@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/wallet"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const dateLayout = "2006-01-02"
+
+type listQuery struct {
+	Direction wallet.Direction
+	From      time.Time
+	To        time.Time
+	Cursor    string
+	Limit     int
+}
+
+func invalidParam(name string, value string) error {
+	return apperrors.New(apperrors.CodeInvalidRequest, "parâmetro inválido: "+name).WithDetails(map[string]interface{}{
+		"param": name,
+		"value": value,
+	})
+}
+
+func parseIDParam(r *http.Request) (int, error) {
+	value := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, apperrors.New(apperrors.CodeInvalidRequest, "ID inválido").WithDetails(map[string]interface{}{
+			"id": value,
+		})
+	}
+	return id, nil
+}
+
+// parseLimit devolve 0 quando value está vazio, deixando o padrão para a
+// paginação.
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, invalidParam("limit", value)
+	}
+	return limit, nil
+}
+
+// parseListQuery lê direction, from, to, cursor e limit. Datas aceitam
+// RFC 3339 ou AAAA-MM-DD; neste caso "to" inclui o dia inteiro.
+func parseListQuery(r *http.Request) (*listQuery, error) {
+	values := r.URL.Query()
+	query := &listQuery{
+		Direction: wallet.Direction(values.Get("direction")),
+		Cursor:    values.Get("cursor"),
+	}
+
+	if !query.Direction.Valid() {
+		return nil, invalidParam("direction", values.Get("direction"))
+	}
+
+	limit, err := parseLimit(values.Get("limit"))
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = limit
+
+	if value := values.Get("from"); value != "" {
+		from, _, err := parseDate(value)
+		if err != nil {
+			return nil, invalidParam("from", value)
+		}
+		query.From = from
+	}
+
+	if value := values.Get("to"); value != "" {
+		to, dateOnly, err := parseDate(value)
+		if err != nil {
+			return nil, invalidParam("to", value)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		query.To = to
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, invalidParam("to", values.Get("to"))
+	}
+
+	return query, nil
+}
+
+func parseDate(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, true, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	return date, false, err
+}
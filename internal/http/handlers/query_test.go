@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/wallet"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users/1/transfers?direction=sent&from=2024-03-01&to=2024-03-31&limit=10&cursor=abc", nil)
+
+	query, err := parseListQuery(req)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Sent, query.Direction)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), query.From)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), query.To)
+	assert.Equal(t, 10, query.Limit)
+	assert.Equal(t, "abc", query.Cursor)
+}
+
+func TestParseListQueryAcceptsRFC3339(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users/1/statement?to=2024-03-31T10:00:00Z", nil)
+
+	query, err := parseListQuery(req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC), query.To)
+}
+
+func TestParseListQueryRejectsInvalidValues(t *testing.T) {
+	tests := []string{
+		"direction=both",
+		"limit=-1",
+		"limit=abc",
+		"from=ontem",
+		"to=31/03/2024",
+		"from=2024-03-10&to=2024-03-01",
+	}
+
+	for _, rawQuery := range tests {
+		t.Run(rawQuery, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/users/1/transfers?"+rawQuery, nil)
+
+			_, err := parseListQuery(req)
+			assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "%s: %v", rawQuery, err)
+		})
+	}
+}
@@ -4,46 +4,191 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/auth"
+	"pag-simples/internal/http/authn"
+	"pag-simples/internal/http/httperror"
+	"pag-simples/internal/http/policy"
+	"pag-simples/internal/idempotency"
 	"pag-simples/internal/transfer"
+	"pag-simples/internal/user"
 
-	"github.com/shopspring/decimal"
+	"github.com/go-chi/chi/v5"
 )
 
 type TransferHandler struct {
-	transferService transfer.TransferUsecase
+	transferService    transfer.TransferUsecase
+	idempotencyService idempotency.IdempotencyUseCase
 }
 
-func NewTransferHandler(transferService transfer.TransferUsecase) *TransferHandler {
+func NewTransferHandler(transferService transfer.TransferUsecase, idempotencyService idempotency.IdempotencyUseCase) *TransferHandler {
 	return &TransferHandler{
-		transferService: transferService,
+		transferService:    transferService,
+		idempotencyService: idempotencyService,
 	}
 }
 
 func (h *TransferHandler) Transfer(w http.ResponseWriter, r *http.Request) {
-	var transferRequest struct {
-		Value decimal.Decimal `json:"value"`
-		Payer int             `json:"payer"`
-		Payee int             `json:"payee"`
-	}
+	withIdempotency(h.idempotencyService, w, r, h.transfer)
+}
+
+func (h *TransferHandler) transfer(w http.ResponseWriter, r *http.Request) {
+	var transferRequest transfer.TransferRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&transferRequest); err != nil {
-		http.Error(w, "Erro ao ler o corpo da requisição", http.StatusBadRequest)
+		httperror.Write(w, errInvalidBody)
 		return
 	}
 
-	err := h.transferService.Transfer(transferRequest.Value, transferRequest.Payer, transferRequest.Payee)
-	if err != nil {
-		if strings.Contains(err.Error(), "falha na autorização") {
-			http.Error(w, "Você não tem permissão para realizar essa ação.", http.StatusForbidden)
-			return
-		}
+	// O pagador é sempre o usuário autenticado; payer no corpo é opcional e,
+	// se informado, precisa ser o mesmo.
+	payer, ok := authn.User(r.Context())
+	if !ok {
+		httperror.Write(w, auth.ErrUnauthorized)
+		return
+	}
+	if transferRequest.Payer != 0 && transferRequest.Payer != payer.ID {
+		httperror.Write(w, apperrors.ErrForbidden.WithDetails(map[string]interface{}{
+			"payer": transferRequest.Payer,
+		}))
+		return
+	}
+	transferRequest.Payer = payer.ID
+	transferRequest.Metadata = requestMetadata(r)
+
+	if prefersAsync(r) {
+		h.submit(w, transferRequest)
+		return
+	}
 
-		http.Error(w, fmt.Sprintf("Erro ao realizar a transferência: %v", err), http.StatusInternalServerError)
+	if err := h.transferService.Execute(r.Context(), transferRequest); err != nil {
+		httperror.Write(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Transferência realizada com sucesso"))
 }
+
+// submit responde 202 assim que a transferência é gravada; o cliente
+// acompanha o processamento pelo Location ou pelo callback_url.
+func (h *TransferHandler) submit(w http.ResponseWriter, transferRequest transfer.TransferRequest) {
+	details, err := h.transferService.Submit(transferRequest)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/transfers/"+details.ID)
+	w.Header().Set(PreferenceAppliedHeader, respondAsync)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(details)
+}
+
+// accessibleTransfer esconde a transferência de quem não é pagador,
+// recebedor ou da equipe: para os demais, ela não existe.
+func (h *TransferHandler) accessibleTransfer(r *http.Request, transferID string) (*transfer.TransferDetails, error) {
+	details, err := h.transferService.GetTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy.CanAccess(r.Context(), details.Payer, details.Payee) {
+		return nil, fmt.Errorf("%w: %s", transfer.ErrTransferNotFound, transferID)
+	}
+	return details, nil
+}
+
+func (h *TransferHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	details, err := h.accessibleTransfer(r, chi.URLParam(r, "id"))
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+func (h *TransferHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	transferID := chi.URLParam(r, "id")
+	if _, err := h.accessibleTransfer(r, transferID); err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	history, err := h.transferService.GetStatusHistory(transferID)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": history})
+}
+
+func (h *TransferHandler) ListUserTransfers(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	query, err := parseListQuery(r)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	page, err := h.transferService.ListTransfers(transfer.TransferFilter{
+		UserID:    userID,
+		Direction: query.Direction,
+		From:      query.From,
+		To:        query.To,
+		Cursor:    query.Cursor,
+		Limit:     query.Limit,
+	})
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *TransferHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	withIdempotency(h.idempotencyService, w, r, h.refund)
+}
+
+func (h *TransferHandler) refund(w http.ResponseWriter, r *http.Request) {
+	var refundRequest transfer.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&refundRequest); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	// Só o recebedor, que devolve o dinheiro, ou um administrador estornam.
+	transferID := chi.URLParam(r, "id")
+	original, err := h.accessibleTransfer(r, transferID)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+	if refunder, _ := authn.User(r.Context()); refunder.ID != original.Payee && refunder.Role != user.RoleAdmin {
+		httperror.Write(w, apperrors.ErrForbidden)
+		return
+	}
+
+	refund, err := h.transferService.Refund(transferID, refundRequest)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
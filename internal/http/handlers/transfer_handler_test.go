@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pag-simples/internal/http/authn"
+	"pag-simples/internal/transfer"
+	"pag-simples/internal/user"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferPayerFromToken(t *testing.T) {
+	transferService := &countingTransferService{}
+	handler := NewTransferHandler(transferService, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Transfer(rec, newTransferRequest(4, `{"value":10,"payee":2}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 4, transferService.request.Payer)
+
+	rec = httptest.NewRecorder()
+	handler.Transfer(rec, newTransferRequest(4, `{"value":10,"payer":1,"payee":2}`))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"forbidden"`)
+
+	rec = httptest.NewRecorder()
+	handler.Transfer(rec, httptest.NewRequest(http.MethodPost, "/transfer", strings.NewReader(`{"value":10,"payee":2}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, 1, transferService.calls)
+}
+
+func transferRequestAs(u *user.User, method string, body string) *http.Request {
+	r := httptest.NewRequest(method, "/transfers/t1", strings.NewReader(body))
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "t1")
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeContext)
+	return r.WithContext(authn.WithUser(ctx, u))
+}
+
+func TestTransferOwnership(t *testing.T) {
+	transferService := &countingTransferService{
+		transfer: &transfer.TransferDetails{Transfer: transfer.Transfer{ID: "t1", Payer: 1, Payee: 2}},
+	}
+	handler := NewTransferHandler(transferService, nil)
+
+	payer := &user.User{ID: 1, Role: user.RoleCustomer}
+	payee := &user.User{ID: 2, Role: user.RoleMerchant}
+	stranger := &user.User{ID: 5, Role: user.RoleCustomer}
+	support := &user.User{ID: 6, Role: user.RoleSupport}
+	admin := &user.User{ID: 7, Role: user.RoleAdmin}
+
+	for u, status := range map[*user.User]int{payer: http.StatusOK, payee: http.StatusOK, support: http.StatusOK, stranger: http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		handler.GetTransfer(rec, transferRequestAs(u, http.MethodGet, ""))
+		assert.Equal(t, status, rec.Code, "GetTransfer como %d", u.ID)
+
+		rec = httptest.NewRecorder()
+		handler.GetStatusHistory(rec, transferRequestAs(u, http.MethodGet, ""))
+		assert.Equal(t, status, rec.Code, "GetStatusHistory como %d", u.ID)
+	}
+
+	for u, status := range map[*user.User]int{payer: http.StatusForbidden, support: http.StatusForbidden, stranger: http.StatusNotFound, payee: http.StatusCreated, admin: http.StatusCreated} {
+		rec := httptest.NewRecorder()
+		handler.Refund(rec, transferRequestAs(u, http.MethodPost, `{"value":1}`))
+		assert.Equal(t, status, rec.Code, "Refund como %d", u.ID)
+	}
+	assert.Equal(t, 2, transferService.calls)
+}
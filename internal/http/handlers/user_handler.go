@@ -9,14 +9,18 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/shopspring/decimal"
 
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/http/httperror"
 	"pag-simples/internal/user"
 	"pag-simples/internal/wallet"
+	"pag-simples/pkg/notification"
 )
 
 type UserHandler struct {
 	userService   user.UserUsecase
 	walletService wallet.WalletUseCase
 }
+
 func NewUserHandler(userService user.UserUsecase, walletService wallet.WalletUseCase) *UserHandler {
 	return &UserHandler{
 		userService:   userService,
@@ -28,13 +32,15 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "id")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		httperror.Write(w, apperrors.New(apperrors.CodeInvalidRequest, "ID inválido").WithDetails(map[string]interface{}{
+			"id": userIDStr,
+		}))
 		return
 	}
 
 	user, err := h.userService.GetUser(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		httperror.Write(w, err)
 		return
 	}
 
@@ -45,43 +51,163 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := h.userService.GetAllUsers()
 	if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		httperror.Write(w, err)
+		return
 	}
 
 	for i := range users {
-			balance, err := h.walletService.GetBalance(users[i].ID)
-			if err != nil {
-					log.Printf("Erro ao obter saldo da carteira para o usuário %d: %v", users[i].ID, err)
-					users[i].Wallet = wallet.Wallet{
-							ID:      users[i].ID,
-							Balance: decimal.Zero,
-					}
-			} else {
-					users[i].Wallet = wallet.Wallet{
-							ID:      users[i].ID,
-							Balance: balance,
-					}
+		balance, err := h.walletService.GetBalance(users[i].ID)
+		if err != nil {
+			log.Printf("Erro ao obter saldo da carteira para o usuário %d: %v", users[i].ID, err)
+			users[i].Wallet = wallet.Wallet{
+				ID:      users[i].ID,
+				Balance: decimal.Zero,
 			}
+		} else {
+			users[i].Wallet = wallet.Wallet{
+				ID:      users[i].ID,
+				Balance: balance,
+			}
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(users)
 }
 
+// createUserRequest traz só o que o cadastro público pode informar: ID,
+// papel e carteira são definidos pelo servidor.
+type createUserRequest struct {
+	FullName            string
+	DocumentNumber      string
+	Email               string
+	Password            string
+	UserType            user.UserType
+	Phone               string
+	WebhookURL          string
+	NotificationChannel notification.Channel
+	Locale              notification.Locale
+}
 
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	var newUser user.User
-	if err := json.NewDecoder(r.Body).Decode(&newUser); err != nil {
-		http.Error(w, "Erro ao ler o corpo da requisição", http.StatusBadRequest)
+	var request createUserRequest
+	if err := decodeStrict(r, &request); err != nil {
+		httperror.Write(w, err)
 		return
 	}
 
+	newUser := user.User{
+		FullName:            request.FullName,
+		DocumentNumber:      request.DocumentNumber,
+		Email:               request.Email,
+		Password:            request.Password,
+		UserType:            request.UserType,
+		Phone:               request.Phone,
+		WebhookURL:          request.WebhookURL,
+		NotificationChannel: request.NotificationChannel,
+		Locale:              request.Locale,
+	}
+
 	if err := h.userService.SaveUser(&newUser); err != nil {
-		http.Error(w, err.Error(), http.StatusConflict)
+		httperror.Write(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(newUser)
 }
+
+func (h *UserHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	query, err := parseListQuery(r)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	statement, err := h.walletService.GetStatement(userID, wallet.StatementFilter{
+		Direction: query.Direction,
+		From:      query.From,
+		To:        query.To,
+		Cursor:    query.Cursor,
+		Limit:     query.Limit,
+	})
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statement)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r)
+	if err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	var request changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	if err := h.userService.ChangePassword(userID, request.CurrentPassword, request.NewPassword); err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type passwordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// RequestPasswordReset responde 202 mesmo quando o envio falha ou o e-mail
+// não existe, para não revelar quais e-mails estão cadastrados.
+func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request passwordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(request.Email); err != nil {
+		log.Printf("Erro ao solicitar redefinição de senha: %v", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type confirmPasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *UserHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request confirmPasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httperror.Write(w, errInvalidBody)
+		return
+	}
+
+	if err := h.userService.ResetPassword(request.Token, request.Password); err != nil {
+		httperror.Write(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
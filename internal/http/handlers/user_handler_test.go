@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pag-simples/internal/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fieldErrorsResponse struct {
+	Code    string `json:"code"`
+	Details struct {
+		Fields []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"fields"`
+	} `json:"details"`
+}
+
+func createUser(t *testing.T, handler *UserHandler, body string) (*httptest.ResponseRecorder, map[string]string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.CreateUser(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+	if rec.Code == http.StatusCreated {
+		return rec, nil
+	}
+
+	var response fieldErrorsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+	codes := map[string]string{}
+	for _, field := range response.Details.Fields {
+		codes[field.Field] = field.Code
+	}
+	return rec, codes
+}
+
+func TestCreateUserValidation(t *testing.T) {
+	repo := user.NewMemoryUserRepository()
+	handler := NewUserHandler(user.NewUserService(repo), nil)
+
+	cases := []struct {
+		name   string
+		body   string
+		fields map[string]string
+	}{
+		{
+			"campo desconhecido",
+			`{"ID":99,"FullName":"João Silva","DocumentNumber":"52998224725","Email":"joao@email.com","Password":"senha123","UserType":"common_user"}`,
+			map[string]string{"ID": "unknown_field"},
+		},
+		{
+			"papel",
+			`{"FullName":"João Silva","DocumentNumber":"52998224725","Email":"joao@email.com","Password":"senha123","UserType":"common_user","Role":"admin"}`,
+			map[string]string{"Role": "unknown_field"},
+		},
+		{
+			"tipo errado",
+			`{"FullName":"João Silva","DocumentNumber":52998224725}`,
+			map[string]string{"DocumentNumber": "invalid_type"},
+		},
+		{
+			"vários campos",
+			`{"FullName":"","DocumentNumber":"52998224725","Email":"joao@","Password":"senha","UserType":"root"}`,
+			map[string]string{"FullName": "required", "Email": "invalid_format", "Password": "too_short", "UserType": "not_allowed"},
+		},
+	}
+
+	for _, c := range cases {
+		rec, fields := createUser(t, handler, c.body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, c.name)
+		assert.Equal(t, c.fields, fields, c.name)
+	}
+
+	users, err := repo.GetAllUsers()
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	rec, _ := createUser(t, handler, `{"FullName":"João Silva","DocumentNumber":"529.982.247-25","Email":"joao@email.com","Password":"senha123","UserType":"common_user"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "senha123")
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "52998224725", body["DocumentNumber"])
+	assert.Equal(t, "529.982.247-25", body["FormattedDocumentNumber"])
+	assert.NotContains(t, body, "Password")
+
+	saved, err := repo.GetUser(1)
+	require.NoError(t, err)
+	assert.Equal(t, user.RoleCustomer, saved.Role)
+	assert.Equal(t, "52998224725", saved.DocumentNumber)
+}
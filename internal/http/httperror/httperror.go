@@ -0,0 +1,89 @@
+package httperror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"pag-simples/internal/apperrors"
+)
+
+type Response struct {
+	Code    apperrors.Code         `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+var statusByCode = map[apperrors.Code]int{
+	apperrors.CodeInvalidRequest:               http.StatusBadRequest,
+	apperrors.CodeInvalidAmount:                http.StatusUnprocessableEntity,
+	apperrors.CodeUserNotFound:                 http.StatusNotFound,
+	apperrors.CodeUserAlreadyExists:            http.StatusConflict,
+	apperrors.CodePayerNotFound:                http.StatusNotFound,
+	apperrors.CodePayeeNotFound:                http.StatusNotFound,
+	apperrors.CodeWalletNotFound:               http.StatusNotFound,
+	apperrors.CodeTransferNotFound:             http.StatusNotFound,
+	apperrors.CodeOutboxMessageNotFound:        http.StatusNotFound,
+	apperrors.CodeWalletAlreadyExists:          http.StatusConflict,
+	apperrors.CodeInsufficientFunds:            http.StatusUnprocessableEntity,
+	apperrors.CodeMerchantCannotPay:            http.StatusForbidden,
+	apperrors.CodeAuthorizationDenied:          http.StatusForbidden,
+	apperrors.CodeAuthorizerUnavailable:        http.StatusServiceUnavailable,
+	apperrors.CodeConcurrentUpdate:             http.StatusConflict,
+	apperrors.CodeTransferNotRefundable:        http.StatusUnprocessableEntity,
+	apperrors.CodeRefundExceedsAmount:          http.StatusUnprocessableEntity,
+	apperrors.CodeInvalidStatusTransition:      http.StatusConflict,
+	apperrors.CodeAsyncUnavailable:             http.StatusServiceUnavailable,
+	apperrors.CodeIdempotencyKeyReused:         http.StatusUnprocessableEntity,
+	apperrors.CodeIdempotencyRequestInProgress: http.StatusConflict,
+	apperrors.CodeInvalidCredentials:           http.StatusUnauthorized,
+	apperrors.CodeInvalidResetToken:            http.StatusBadRequest,
+	apperrors.CodeUnauthorized:                 http.StatusUnauthorized,
+	apperrors.CodeInvalidRefreshToken:          http.StatusUnauthorized,
+	apperrors.CodeForbidden:                    http.StatusForbidden,
+	apperrors.CodeInternal:                     http.StatusInternalServerError,
+}
+
+func Status(err error) int {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+
+	if status, ok := statusByCode[appErr.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Write responde com o status e o corpo JSON correspondentes ao erro. Só a
+// mensagem e os detalhes do erro de domínio chegam ao cliente, nunca o
+// contexto acrescentado ao embrulhá-lo, como erros do autorizador ou IDs
+// internos. Erros sem código de domínio viram internal_error.
+func Write(w http.ResponseWriter, err error) {
+	response := Response{
+		Code:    apperrors.CodeInternal,
+		Message: apperrors.ErrInternal.Message,
+	}
+
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		response.Code = appErr.Code
+		response.Message = appErr.Message
+		response.Details = appErr.Details
+	}
+
+	status := Status(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Erro interno: %v", err)
+		response.Code = apperrors.CodeInternal
+		response.Message = apperrors.ErrInternal.Message
+		response.Details = nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
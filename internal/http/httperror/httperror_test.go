@@ -0,0 +1,102 @@
+package httperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pag-simples/internal/apperrors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    apperrors.Code
+		message string
+	}{
+		{
+			name:    "saldo insuficiente",
+			err:     apperrors.ErrInsufficientFunds,
+			status:  http.StatusUnprocessableEntity,
+			code:    apperrors.CodeInsufficientFunds,
+			message: "saldo insuficiente para a transferência",
+		},
+		{
+			name:    "pagador não encontrado",
+			err:     fmt.Errorf("%w: usuário não encontrado: ID 9", apperrors.ErrPayerNotFound),
+			status:  http.StatusNotFound,
+			code:    apperrors.CodePayerNotFound,
+			message: "pagador não encontrado",
+		},
+		{
+			name:    "lojista",
+			err:     apperrors.ErrMerchantCannotPay,
+			status:  http.StatusForbidden,
+			code:    apperrors.CodeMerchantCannotPay,
+			message: "um lojista não pode realizar transferências",
+		},
+		{
+			name:    "autorizador indisponível",
+			err:     fmt.Errorf("%w: timeout", apperrors.ErrAuthorizerUnavailable),
+			status:  http.StatusServiceUnavailable,
+			code:    apperrors.CodeAuthorizerUnavailable,
+			message: "falha na autorização",
+		},
+		{
+			name:    "erro desconhecido",
+			err:     errors.New("conexão recusada em 10.0.0.1"),
+			status:  http.StatusInternalServerError,
+			code:    apperrors.CodeInternal,
+			message: "erro interno",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Write(rec, tt.err)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var body Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.code, body.Code)
+			assert.Equal(t, tt.message, body.Message)
+		})
+	}
+}
+
+func TestWriteIncludesDetails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, apperrors.ErrInsufficientFunds.WithDetails(map[string]interface{}{"payer": 1}))
+
+	var body Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, map[string]interface{}{"payer": float64(1)}, body.Details)
+}
+
+func TestWriteDoesNotExposeWrappedErrors(t *testing.T) {
+	secret := `Get "http://autorizador.interno:8080/authorize": dial tcp 10.0.0.7:8080: connection refused`
+	err := fmt.Errorf("%w: %v", apperrors.ErrAuthorizerUnavailable.WithDetails(map[string]interface{}{"transfer": "t-1"}), errors.New(secret))
+
+	rec := httptest.NewRecorder()
+	Write(rec, err)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "10.0.0.7")
+	assert.NotContains(t, rec.Body.String(), "autorizador.interno")
+
+	var body Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "falha na autorização", body.Message)
+	assert.Equal(t, map[string]interface{}{"transfer": "t-1"}, body.Details)
+}
@@ -0,0 +1,102 @@
+// Package policy declara quem pode acessar cada rota. Deve vir depois de
+// authn.Middleware, que coloca o usuário autenticado no contexto.
+package policy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/auth"
+	"pag-simples/internal/http/authn"
+	"pag-simples/internal/http/httperror"
+	"pag-simples/internal/user"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Requirement decide se o usuário autenticado pode seguir com a requisição.
+type Requirement func(r *http.Request, authenticated *user.User) bool
+
+// Require responde 401 sem usuário autenticado e 403 quando algum dos
+// requisitos não é atendido.
+func Require(requirements ...Requirement) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated, ok := authn.User(r.Context())
+			if !ok {
+				httperror.Write(w, auth.ErrUnauthorized)
+				return
+			}
+
+			for _, requirement := range requirements {
+				if !requirement(r, authenticated) {
+					httperror.Write(w, apperrors.ErrForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Role exige um dos papéis informados.
+func Role(roles ...user.Role) Requirement {
+	return func(r *http.Request, authenticated *user.User) bool {
+		for _, role := range roles {
+			if authenticated.Role == role {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Staff exige suporte ou administrador.
+func Staff() Requirement {
+	return func(r *http.Request, authenticated *user.User) bool {
+		return authenticated.Role.Staff()
+	}
+}
+
+// Self exige que o parâmetro de rota param seja o ID do usuário autenticado.
+func Self(param string) Requirement {
+	return func(r *http.Request, authenticated *user.User) bool {
+		id, err := strconv.Atoi(chi.URLParam(r, param))
+		return err == nil && id == authenticated.ID
+	}
+}
+
+// AnyOf é atendido quando ao menos um dos requisitos é.
+func AnyOf(requirements ...Requirement) Requirement {
+	return func(r *http.Request, authenticated *user.User) bool {
+		for _, requirement := range requirements {
+			if requirement(r, authenticated) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// CanAccess diz se o usuário autenticado em ctx pode ler um recurso dos
+// usuários ownerIDs. Serve às checagens que dependem do recurso já carregado,
+// como a de uma transferência pelo pagador e pelo recebedor.
+func CanAccess(ctx context.Context, ownerIDs ...int) bool {
+	authenticated, ok := authn.User(ctx)
+	if !ok {
+		return false
+	}
+	if authenticated.Role.Staff() {
+		return true
+	}
+
+	for _, id := range ownerIDs {
+		if id == authenticated.ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pag-simples/internal/http/authn"
+	"pag-simples/internal/user"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequire(t *testing.T) {
+	router := chi.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	router.With(Require(Staff())).Get("/users", ok)
+	router.With(Require(AnyOf(Self("id"), Staff()))).Get("/users/{id}", ok)
+	router.With(Require(Role(user.RoleAdmin))).Post("/admin/outbox/{id}/replay", ok)
+
+	customer := &user.User{ID: 1, Role: user.RoleCustomer}
+	support := &user.User{ID: 2, Role: user.RoleSupport}
+	admin := &user.User{ID: 3, Role: user.RoleAdmin}
+
+	cases := []struct {
+		name   string
+		as     *user.User
+		method string
+		path   string
+		status int
+	}{
+		{"anônimo", nil, http.MethodGet, "/users/1", http.StatusUnauthorized},
+		{"cliente lista usuários", customer, http.MethodGet, "/users", http.StatusForbidden},
+		{"suporte lista usuários", support, http.MethodGet, "/users", http.StatusNoContent},
+		{"cliente lê o próprio perfil", customer, http.MethodGet, "/users/1", http.StatusNoContent},
+		{"cliente lê outro perfil", customer, http.MethodGet, "/users/2", http.StatusForbidden},
+		{"suporte lê outro perfil", support, http.MethodGet, "/users/1", http.StatusNoContent},
+		{"suporte reenvia mensagem", support, http.MethodPost, "/admin/outbox/m1/replay", http.StatusForbidden},
+		{"administrador reenvia mensagem", admin, http.MethodPost, "/admin/outbox/m1/replay", http.StatusNoContent},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		if c.as != nil {
+			r = r.WithContext(authn.WithUser(r.Context(), c.as))
+		}
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, c.status, w.Code, c.name)
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	as := func(u *user.User) *http.Request { return r.WithContext(authn.WithUser(r.Context(), u)) }
+
+	assert.False(t, CanAccess(r.Context(), 1), "anônimo")
+	assert.True(t, CanAccess(as(&user.User{ID: 1, Role: user.RoleCustomer}).Context(), 2, 1), "dono")
+	assert.False(t, CanAccess(as(&user.User{ID: 3, Role: user.RoleMerchant}).Context(), 2, 1), "outro usuário")
+	assert.True(t, CanAccess(as(&user.User{ID: 3, Role: user.RoleSupport}).Context(), 2, 1), "suporte")
+}
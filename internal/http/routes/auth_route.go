@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"pag-simples/internal/http/handlers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func ConfigureAuthRoutes(r chi.Router, authHandler *handlers.AuthHandler) {
+	r.Post("/auth/login", authHandler.Login)
+	r.Post("/auth/refresh", authHandler.Refresh)
+	r.Post("/auth/logout", authHandler.Logout)
+}
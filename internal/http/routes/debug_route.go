@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"net/http"
+
+	"pag-simples/internal/http/handlers"
+	"pag-simples/internal/http/policy"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ConfigureDebugRoutes expõe as métricas do autorizador só para a equipe.
+func ConfigureDebugRoutes(r chi.Router, authenticate func(http.Handler) http.Handler) {
+	r.With(authenticate, policy.Require(policy.Staff())).Get("/debug/vars", handlers.Metrics("authorizer"))
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"net/http"
+
+	"pag-simples/internal/http/handlers"
+	"pag-simples/internal/http/policy"
+	"pag-simples/internal/user"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ConfigureOutboxRoutes libera a consulta ao suporte, mas só administradores
+// reenviam mensagens.
+func ConfigureOutboxRoutes(r chi.Router, outboxHandler *handlers.OutboxHandler, authenticate func(http.Handler) http.Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(authenticate)
+
+		r.With(policy.Require(policy.Staff())).Get("/admin/outbox", outboxHandler.ListMessages)
+		r.With(policy.Require(policy.Role(user.RoleAdmin))).Post("/admin/outbox/{id}/replay", outboxHandler.Replay)
+	})
+}
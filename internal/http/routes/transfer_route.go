@@ -1,11 +1,27 @@
 package routes
 
 import (
+	"net/http"
+
 	"pag-simples/internal/http/handlers"
+	"pag-simples/internal/http/policy"
+	"pag-simples/internal/user"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func ConfigureTransferRoutes(r chi.Router, transferHandler *handlers.TransferHandler) {
-	r.Post("/transfer", transferHandler.Transfer)
+// ConfigureTransferRoutes exige autenticação em todas as rotas. Só clientes e
+// lojistas transferem, e o pagador vem do access token; o acesso a uma
+// transferência específica é conferido pelo handler, que conhece pagador e
+// recebedor.
+func ConfigureTransferRoutes(r chi.Router, transferHandler *handlers.TransferHandler, authenticate func(http.Handler) http.Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(authenticate)
+
+		r.With(policy.Require(policy.Role(user.RoleCustomer, user.RoleMerchant))).Post("/transfer", transferHandler.Transfer)
+		r.Get("/transfers/{id}", transferHandler.GetTransfer)
+		r.Get("/transfers/{id}/history", transferHandler.GetStatusHistory)
+		r.Post("/transfers/{id}/refund", transferHandler.Refund)
+		r.With(policy.Require(policy.AnyOf(policy.Self("id"), policy.Staff()))).Get("/users/{id}/transfers", transferHandler.ListUserTransfers)
+	})
 }
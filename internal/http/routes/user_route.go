@@ -1,13 +1,27 @@
 package routes
 
 import (
+	"net/http"
+
 	"pag-simples/internal/http/handlers"
+	"pag-simples/internal/http/policy"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func ConfigureUserRoutes(r chi.Router, userHandler *handlers.UserHandler) {
-	r.Get("/users/{id}", userHandler.GetUser)
-	r.Get("/users", userHandler.GetAllUsers)
+// ConfigureUserRoutes deixa públicos só o cadastro e a redefinição de senha.
+// Cada usuário lê os próprios dados; suporte e administradores leem todos.
+func ConfigureUserRoutes(r chi.Router, userHandler *handlers.UserHandler, authenticate func(http.Handler) http.Handler) {
 	r.Post("/users", userHandler.CreateUser)
+	r.Post("/password-reset", userHandler.RequestPasswordReset)
+	r.Post("/password-reset/confirm", userHandler.ConfirmPasswordReset)
+
+	r.Group(func(r chi.Router) {
+		r.Use(authenticate)
+
+		r.With(policy.Require(policy.Staff())).Get("/users", userHandler.GetAllUsers)
+		r.With(policy.Require(policy.AnyOf(policy.Self("id"), policy.Staff()))).Get("/users/{id}", userHandler.GetUser)
+		r.With(policy.Require(policy.AnyOf(policy.Self("id"), policy.Staff()))).Get("/users/{id}/statement", userHandler.GetStatement)
+		r.With(policy.Require(policy.Self("id"))).Put("/users/{id}/password", userHandler.ChangePassword)
+	})
 }
@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type Record struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	Completed   bool      `json:"completed"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Location    string    `json:"location"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Location    string
+	Body        []byte
+}
+
+func (r *Record) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// HashRequest identifica o conteúdo da requisição; JSON é compactado antes do
+// hash para que diferenças de espaçamento não sejam tratadas como outro payload.
+func HashRequest(method string, path string, body []byte) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		body = compacted.Bytes()
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,133 @@
+package idempotencytest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/idempotency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func RunIdempotencyRepositoryTests(t *testing.T, newRepo func(t *testing.T) idempotency.IdempotencyRepository) {
+	t.Run("ReserveAndComplete", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now().UTC()
+
+		existing, err := repo.Reserve(newRecord("k1", "hash", now, time.Hour))
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+
+		existing, err = repo.Reserve(newRecord("k1", "outro", now, time.Hour))
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.Equal(t, "hash", existing.RequestHash)
+		assert.False(t, existing.Completed)
+
+		require.NoError(t, repo.Complete("k1", idempotency.Response{
+			StatusCode:  201,
+			ContentType: "application/json",
+			Location:    "/transfers/t1",
+			Body:        []byte(`{"id":"t1"}`),
+		}))
+
+		existing, err = repo.Reserve(newRecord("k1", "hash", now, time.Hour))
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.True(t, existing.Completed)
+		assert.Equal(t, 201, existing.StatusCode)
+		assert.Equal(t, "application/json", existing.ContentType)
+		assert.Equal(t, "/transfers/t1", existing.Location)
+		assert.Equal(t, `{"id":"t1"}`, string(existing.Body))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.Complete("inexistente", idempotency.Response{StatusCode: 200})
+		assert.True(t, errors.Is(err, idempotency.ErrRecordNotFound), "Complete: %v", err)
+		assert.NoError(t, repo.Delete("inexistente"))
+	})
+
+	t.Run("DeleteReleasesKey", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now().UTC()
+
+		_, err := repo.Reserve(newRecord("k1", "hash", now, time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete("k1"))
+
+		existing, err := repo.Reserve(newRecord("k1", "outro", now, time.Hour))
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("ExpiredKeysAreReplaced", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now().UTC()
+
+		_, err := repo.Reserve(newRecord("k1", "hash", now.Add(-2*time.Hour), time.Hour))
+		require.NoError(t, err)
+
+		existing, err := repo.Reserve(newRecord("k1", "outro", now, time.Hour))
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("PurgeExpired", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now().UTC()
+
+		_, err := repo.Reserve(newRecord("antiga", "hash", now.Add(-2*time.Hour), time.Hour))
+		require.NoError(t, err)
+		_, err = repo.Reserve(newRecord("recente", "hash", now, time.Hour))
+		require.NoError(t, err)
+
+		purged, err := repo.PurgeExpired(now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		existing, err := repo.Reserve(newRecord("recente", "hash", now, time.Hour))
+		require.NoError(t, err)
+		assert.NotNil(t, existing)
+	})
+
+	t.Run("ConcurrentReservations", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now().UTC()
+
+		var mu sync.Mutex
+		reserved := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				existing, err := repo.Reserve(newRecord("k1", "hash", now, time.Hour))
+				if !assert.NoError(t, err) {
+					return
+				}
+				if existing == nil {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, reserved)
+	})
+}
+
+func newRecord(key string, hash string, createdAt time.Time, ttl time.Duration) *idempotency.Record {
+	return &idempotency.Record{
+		Key:         key,
+		RequestHash: hash,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(ttl),
+	}
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrRecordNotFound = errors.New("chave de idempotência não encontrada")
+
+type IdempotencyRepository interface {
+	Reserve(record *Record) (*Record, error)
+	Complete(key string, response Response) error
+	Delete(key string) error
+	PurgeExpired(now time.Time) (int, error)
+}
+
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]Record),
+	}
+}
+
+func (r *MemoryIdempotencyRepository) Reserve(record *Record) (*Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.records[record.Key]; exists && !existing.Expired(record.CreatedAt) {
+		return &existing, nil
+	}
+
+	r.records[record.Key] = *record
+	return nil, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(key string, response Response) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, exists := r.records[key]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrRecordNotFound, key)
+	}
+
+	record.Completed = true
+	record.StatusCode = response.StatusCode
+	record.ContentType = response.ContentType
+	record.Location = response.Location
+	record.Body = append([]byte(nil), response.Body...)
+	r.records[key] = record
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) PurgeExpired(now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for key, record := range r.records {
+		if record.Expired(now) {
+			delete(r.records, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
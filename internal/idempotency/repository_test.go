@@ -0,0 +1,25 @@
+package idempotency_test
+
+import (
+	"testing"
+
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/idempotency"
+	"pag-simples/internal/idempotency/idempotencytest"
+)
+
+func TestMemoryIdempotencyRepository(t *testing.T) {
+	idempotencytest.RunIdempotencyRepositoryTests(t, func(t *testing.T) idempotency.IdempotencyRepository {
+		return idempotency.NewMemoryIdempotencyRepository()
+	})
+}
+
+func TestSQLIdempotencyRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			idempotencytest.RunIdempotencyRepositoryTests(t, func(t *testing.T) idempotency.IdempotencyRepository {
+				return idempotency.NewSQLIdempotencyRepository(databasetest.Open(t, driver))
+			})
+		})
+	}
+}
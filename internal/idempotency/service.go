@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"pag-simples/internal/apperrors"
+)
+
+const DefaultTTL = 24 * time.Hour
+
+var (
+	ErrKeyReused         = apperrors.New(apperrors.CodeIdempotencyKeyReused, "chave de idempotência já utilizada com outro conteúdo")
+	ErrRequestInProgress = apperrors.New(apperrors.CodeIdempotencyRequestInProgress, "requisição com esta chave de idempotência ainda em processamento")
+)
+
+type IdempotencyService struct {
+	repo IdempotencyRepository
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewIdempotencyService(repo IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &IdempotencyService{
+		repo: repo,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Begin reserva a chave para a requisição atual. Retorna nil quando a
+// requisição deve ser processada ou o registro concluído que deve ser repetido.
+func (s *IdempotencyService) Begin(key string, requestHash string) (*Record, error) {
+	now := s.now().UTC()
+	existing, err := s.repo.Reserve(&Record{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	})
+	if err != nil {
+		log.Printf("Erro ao reservar chave de idempotência %s: %v", key, err)
+		return nil, err
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, fmt.Errorf("%w: %s", ErrKeyReused, key)
+	}
+
+	if !existing.Completed {
+		return nil, fmt.Errorf("%w: %s", ErrRequestInProgress, key)
+	}
+
+	return existing, nil
+}
+
+func (s *IdempotencyService) Complete(key string, response Response) error {
+	if err := s.repo.Complete(key, response); err != nil {
+		log.Printf("Erro ao salvar resposta da chave de idempotência %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+func (s *IdempotencyService) Release(key string) error {
+	if err := s.repo.Delete(key); err != nil {
+		log.Printf("Erro ao liberar chave de idempotência %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+func (s *IdempotencyService) PurgeExpired() (int, error) {
+	return s.repo.PurgeExpired(s.now().UTC())
+}
@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeginReplaysCompletedResponse(t *testing.T) {
+	service := NewIdempotencyService(NewMemoryIdempotencyRepository(), time.Hour)
+
+	record, err := service.Begin("k1", "hash")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	_, err = service.Begin("k1", "hash")
+	assert.True(t, errors.Is(err, ErrRequestInProgress), "Begin: %v", err)
+
+	require.NoError(t, service.Complete("k1", Response{StatusCode: 200, Body: []byte("ok")}))
+
+	record, err = service.Begin("k1", "hash")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, 200, record.StatusCode)
+	assert.Equal(t, "ok", string(record.Body))
+}
+
+func TestBeginRejectsDifferentPayload(t *testing.T) {
+	service := NewIdempotencyService(NewMemoryIdempotencyRepository(), time.Hour)
+
+	_, err := service.Begin("k1", "hash")
+	require.NoError(t, err)
+	require.NoError(t, service.Complete("k1", Response{StatusCode: 200}))
+
+	_, err = service.Begin("k1", "outro")
+	assert.True(t, errors.Is(err, ErrKeyReused), "Begin: %v", err)
+}
+
+func TestKeysExpireAfterTTL(t *testing.T) {
+	now := time.Now()
+	service := NewIdempotencyService(NewMemoryIdempotencyRepository(), time.Minute)
+	service.now = func() time.Time { return now }
+
+	_, err := service.Begin("k1", "hash")
+	require.NoError(t, err)
+	require.NoError(t, service.Complete("k1", Response{StatusCode: 200}))
+
+	now = now.Add(2 * time.Minute)
+
+	record, err := service.Begin("k1", "outro")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestReleaseAllowsRetry(t *testing.T) {
+	service := NewIdempotencyService(NewMemoryIdempotencyRepository(), time.Hour)
+
+	_, err := service.Begin("k1", "hash")
+	require.NoError(t, err)
+	require.NoError(t, service.Release("k1"))
+
+	record, err := service.Begin("k1", "hash")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestHashRequestIgnoresJSONWhitespace(t *testing.T) {
+	compact := HashRequest("POST", "/transfer", []byte(`{"value":10,"payer":1,"payee":2}`))
+	spaced := HashRequest("POST", "/transfer", []byte("{\n  \"value\": 10,\n  \"payer\": 1,\n  \"payee\": 2\n}"))
+	other := HashRequest("POST", "/transfer", []byte(`{"value":11,"payer":1,"payee":2}`))
+
+	assert.Equal(t, compact, spaced)
+	assert.NotEqual(t, compact, other)
+}
@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pag-simples/internal/database"
+)
+
+type SQLIdempotencyRepository struct {
+	db database.Executor
+}
+
+func NewSQLIdempotencyRepository(db database.Executor) *SQLIdempotencyRepository {
+	return &SQLIdempotencyRepository{
+		db: db,
+	}
+}
+
+func (r *SQLIdempotencyRepository) Reserve(record *Record) (*Record, error) {
+	var existing *Record
+	err := database.RunInTx(r.db, func(tx database.Executor) error {
+		if _, err := tx.Exec("DELETE FROM idempotency_keys WHERE key = $1 AND expires_at <= $2", record.Key, record.CreatedAt.UnixMilli()); err != nil {
+			return fmt.Errorf("erro ao remover chave de idempotência expirada %s: %v", record.Key, err)
+		}
+
+		result, err := tx.Exec(
+			`INSERT INTO idempotency_keys (key, request_hash, completed, status_code, content_type, body, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (key) DO NOTHING`,
+			record.Key, record.RequestHash, false, 0, "", []byte{}, record.CreatedAt, record.ExpiresAt.UnixMilli(),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao reservar chave de idempotência %s: %v", record.Key, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("erro ao reservar chave de idempotência %s: %v", record.Key, err)
+		}
+		if affected > 0 {
+			return nil
+		}
+
+		existing, err = scanRecord(tx.QueryRow(
+			"SELECT key, request_hash, completed, status_code, content_type, location, body, created_at, expires_at FROM idempotency_keys WHERE key = $1",
+			record.Key,
+		))
+		if err != nil {
+			return fmt.Errorf("erro ao buscar chave de idempotência %s: %v", record.Key, err)
+		}
+		return nil
+	})
+	return existing, err
+}
+
+func (r *SQLIdempotencyRepository) Complete(key string, response Response) error {
+	result, err := r.db.Exec(
+		"UPDATE idempotency_keys SET completed = $1, status_code = $2, content_type = $3, location = $4, body = $5 WHERE key = $6",
+		true, response.StatusCode, response.ContentType, response.Location, response.Body, key,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar resposta da chave de idempotência %s: %v", key, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("erro ao salvar resposta da chave de idempotência %s: %v", key, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrRecordNotFound, key)
+	}
+	return nil
+}
+
+func (r *SQLIdempotencyRepository) Delete(key string) error {
+	if _, err := r.db.Exec("DELETE FROM idempotency_keys WHERE key = $1", key); err != nil {
+		return fmt.Errorf("erro ao remover chave de idempotência %s: %v", key, err)
+	}
+	return nil
+}
+
+func (r *SQLIdempotencyRepository) PurgeExpired(now time.Time) (int, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= $1", now.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover chaves de idempotência expiradas: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover chaves de idempotência expiradas: %v", err)
+	}
+	return int(affected), nil
+}
+
+func scanRecord(row database.RowScanner) (*Record, error) {
+	var record Record
+	var expiresAt int64
+	err := row.Scan(&record.Key, &record.RequestHash, &record.Completed, &record.StatusCode, &record.ContentType, &record.Location, &record.Body, &record.CreatedAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, record.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	record.ExpiresAt = time.UnixMilli(expiresAt)
+	return &record, nil
+}
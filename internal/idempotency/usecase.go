@@ -0,0 +1,8 @@
+package idempotency
+
+type IdempotencyUseCase interface {
+	Begin(key string, requestHash string) (*Record, error)
+	Complete(key string, response Response) error
+	Release(key string) error
+	PurgeExpired() (int, error)
+}
@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type AccountType string
+
+const (
+	WalletAccount AccountType = "wallet"
+	SystemAccount AccountType = "system"
+)
+
+const FundingAccountID = "system:funding"
+
+type Account struct {
+	ID            string      `json:"id"`
+	Type          AccountType `json:"type"`
+	AllowNegative bool        `json:"allow_negative"`
+	Version       int64       `json:"version"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type Entry struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	ExpectedVersions map[string]int64 `json:"-"`
+}
+
+type Posting struct {
+	AccountID string          `json:"account_id"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+func WalletAccountID(userID int) string {
+	return fmt.Sprintf("wallet:%d", userID)
+}
+
+func (e *Entry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("lançamento %s deve possuir ao menos duas partidas", e.ID)
+	}
+
+	sum := decimal.Zero
+	for _, posting := range e.Postings {
+		if posting.Amount.IsZero() {
+			return fmt.Errorf("lançamento %s possui partida com valor zero para a conta %s", e.ID, posting.AccountID)
+		}
+		sum = sum.Add(posting.Amount)
+	}
+
+	if !sum.IsZero() {
+		return fmt.Errorf("lançamento %s não está balanceado: soma das partidas é %s", e.ID, sum.String())
+	}
+
+	for accountID := range e.ExpectedVersions {
+		if !e.touches(accountID) {
+			return fmt.Errorf("lançamento %s informa versão esperada para a conta %s, que não participa do lançamento", e.ID, accountID)
+		}
+	}
+
+	return nil
+}
+
+func (e *Entry) touches(accountID string) bool {
+	for _, posting := range e.Postings {
+		if posting.AccountID == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Entry) Deltas() (map[string]decimal.Decimal, []string) {
+	deltas := make(map[string]decimal.Decimal)
+	for _, posting := range e.Postings {
+		deltas[posting.AccountID] = deltas[posting.AccountID].Add(posting.Amount)
+	}
+
+	accountIDs := make([]string, 0, len(deltas))
+	for accountID := range deltas {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	return deltas, accountIDs
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLedger(t *testing.T) *MemoryLedgerRepository {
+	repo := NewMemoryLedgerRepository()
+	assert.NoError(t, repo.CreateAccount(&Account{ID: FundingAccountID, Type: SystemAccount, AllowNegative: true}))
+	assert.NoError(t, repo.CreateAccount(&Account{ID: WalletAccountID(1), Type: WalletAccount}))
+	assert.NoError(t, repo.CreateAccount(&Account{ID: WalletAccountID(2), Type: WalletAccount}))
+	return repo
+}
+
+func TestEntryValidateRejectsUnbalancedEntry(t *testing.T) {
+	entry := &Entry{
+		ID: "e1",
+		Postings: []Posting{
+			{AccountID: WalletAccountID(1), Amount: decimal.NewFromInt(-100)},
+			{AccountID: WalletAccountID(2), Amount: decimal.NewFromInt(90)},
+		},
+	}
+
+	err := entry.Validate()
+
+	assert.Error(t, err)
+	assert.Equal(t, "lançamento e1 não está balanceado: soma das partidas é -10", err.Error())
+}
+
+func TestEntryValidateRejectsSinglePosting(t *testing.T) {
+	entry := &Entry{
+		ID:       "e1",
+		Postings: []Posting{{AccountID: WalletAccountID(1), Amount: decimal.NewFromInt(100)}},
+	}
+
+	assert.Error(t, entry.Validate())
+}
+
+func TestPostEntryDerivesBalancesFromPostings(t *testing.T) {
+	repo := newTestLedger(t)
+
+	assert.NoError(t, repo.PostEntry(&Entry{
+		ID: "e1",
+		Postings: []Posting{
+			{AccountID: FundingAccountID, Amount: decimal.NewFromInt(-500)},
+			{AccountID: WalletAccountID(1), Amount: decimal.NewFromInt(500)},
+		},
+	}))
+	assert.NoError(t, repo.PostEntry(&Entry{
+		ID: "e2",
+		Postings: []Posting{
+			{AccountID: WalletAccountID(1), Amount: decimal.NewFromFloat(-120.50)},
+			{AccountID: WalletAccountID(2), Amount: decimal.NewFromFloat(120.50)},
+		},
+	}))
+
+	payerBalance, _ := repo.GetBalance(WalletAccountID(1))
+	payeeBalance, _ := repo.GetBalance(WalletAccountID(2))
+	fundingBalance, _ := repo.GetBalance(FundingAccountID)
+
+	assert.True(t, payerBalance.Equal(decimal.NewFromFloat(379.50)))
+	assert.True(t, payeeBalance.Equal(decimal.NewFromFloat(120.50)))
+	assert.True(t, fundingBalance.Equal(decimal.NewFromInt(-500)))
+	assert.True(t, payerBalance.Add(payeeBalance).Add(fundingBalance).IsZero())
+
+	entries, err := repo.GetEntries(WalletAccountID(1))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestPostEntryRejectsOverdraftAtomically(t *testing.T) {
+	repo := newTestLedger(t)
+
+	err := repo.PostEntry(&Entry{
+		ID: "e1",
+		Postings: []Posting{
+			{AccountID: WalletAccountID(1), Amount: decimal.NewFromInt(-10)},
+			{AccountID: WalletAccountID(2), Amount: decimal.NewFromInt(10)},
+		},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "saldo insuficiente na conta wallet:1", err.Error())
+
+	payeeBalance, _ := repo.GetBalance(WalletAccountID(2))
+	assert.True(t, payeeBalance.IsZero())
+}
+
+func TestPostEntryRejectsUnknownAccount(t *testing.T) {
+	repo := newTestLedger(t)
+
+	err := repo.PostEntry(&Entry{
+		ID: "e1",
+		Postings: []Posting{
+			{AccountID: FundingAccountID, Amount: decimal.NewFromInt(-10)},
+			{AccountID: WalletAccountID(99), Amount: decimal.NewFromInt(10)},
+		},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "conta não encontrada: wallet:99", err.Error())
+}
@@ -0,0 +1,231 @@
+package ledgertest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/ledger"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func RunLedgerRepositoryTests(t *testing.T, newRepo func(t *testing.T) ledger.LedgerRepository) {
+	t.Run("CreateAndGetAccount", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateAccount(walletAccount(1)))
+
+		account, err := repo.GetAccount(ledger.WalletAccountID(1))
+		require.NoError(t, err)
+		assert.Equal(t, ledger.WalletAccount, account.Type)
+		assert.False(t, account.AllowNegative)
+
+		balance, err := repo.GetBalance(ledger.WalletAccountID(1))
+		require.NoError(t, err)
+		assert.True(t, balance.IsZero())
+
+		err = repo.CreateAccount(walletAccount(1))
+		assert.True(t, errors.Is(err, ledger.ErrAccountAlreadyExists), "CreateAccount: %v", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		account, err := repo.GetAccount("wallet:404")
+		assert.Nil(t, account)
+		assert.True(t, errors.Is(err, ledger.ErrAccountNotFound), "GetAccount: %v", err)
+
+		_, err = repo.GetBalance("wallet:404")
+		assert.True(t, errors.Is(err, ledger.ErrAccountNotFound), "GetBalance: %v", err)
+
+		_, err = repo.GetEntries("wallet:404")
+		assert.True(t, errors.Is(err, ledger.ErrAccountNotFound), "GetEntries: %v", err)
+	})
+
+	t.Run("BalancesFollowPostings", func(t *testing.T) {
+		repo := newFundedRepo(t, newRepo, 500)
+
+		require.NoError(t, repo.PostEntry(transferEntry("t1", 1, 2, "120.50")))
+
+		assertBalance(t, repo, ledger.WalletAccountID(1), "379.50")
+		assertBalance(t, repo, ledger.WalletAccountID(2), "120.50")
+		assertBalance(t, repo, ledger.FundingAccountID, "-500")
+	})
+
+	t.Run("BalanceIsSumOfPostings", func(t *testing.T) {
+		repo := newFundedRepo(t, newRepo, 300)
+
+		require.NoError(t, repo.PostEntry(transferEntry("t1", 1, 2, "75.25")))
+		require.NoError(t, repo.PostEntry(transferEntry("t2", 2, 1, "0.25")))
+		require.NoError(t, repo.PostEntry(transferEntry("t3", 1, 2, "100")))
+
+		for _, accountID := range []string{ledger.FundingAccountID, ledger.WalletAccountID(1), ledger.WalletAccountID(2)} {
+			entries, err := repo.GetEntries(accountID)
+			require.NoError(t, err)
+
+			sum := decimal.Zero
+			for _, entry := range entries {
+				for _, posting := range entry.Postings {
+					if posting.AccountID == accountID {
+						sum = sum.Add(posting.Amount)
+					}
+				}
+			}
+			assertBalance(t, repo, accountID, sum.String())
+		}
+		assertBalance(t, repo, ledger.WalletAccountID(1), "125")
+	})
+
+	t.Run("RejectsInvalidEntriesWithoutSideEffects", func(t *testing.T) {
+		repo := newFundedRepo(t, newRepo, 100)
+
+		unbalanced := transferEntry("unbalanced", 1, 2, "10")
+		unbalanced.Postings[1].Amount = decimal.NewFromInt(9)
+		assert.Error(t, repo.PostEntry(unbalanced))
+
+		err := repo.PostEntry(transferEntry("overdraft", 1, 2, "100.01"))
+		assert.True(t, errors.Is(err, ledger.ErrInsufficientBalance), "overdraft: %v", err)
+
+		unknown := transferEntry("unknown", 1, 2, "10")
+		unknown.Postings[1].AccountID = "wallet:404"
+		err = repo.PostEntry(unknown)
+		assert.True(t, errors.Is(err, ledger.ErrAccountNotFound), "unknown: %v", err)
+
+		assertBalance(t, repo, ledger.WalletAccountID(1), "100")
+		assertBalance(t, repo, ledger.WalletAccountID(2), "0")
+
+		entries, err := repo.GetEntries(ledger.WalletAccountID(1))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("EntriesInPostingOrder", func(t *testing.T) {
+		repo := newFundedRepo(t, newRepo, 100)
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, repo.PostEntry(transferEntry(fmt.Sprintf("t%d", i), 1, 2, "10")))
+		}
+
+		entries, err := repo.GetEntries(ledger.WalletAccountID(2))
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		for i, entry := range entries {
+			assert.Equal(t, fmt.Sprintf("t%d", i+1), entry.ID)
+			assert.Equal(t, fmt.Sprintf("t%d", i+1), entry.Reference)
+			require.Len(t, entry.Postings, 2)
+			assert.Equal(t, ledger.WalletAccountID(1), entry.Postings[0].AccountID)
+			assert.True(t, entry.Postings[0].Amount.Equal(decimal.NewFromInt(-10)))
+			assert.Equal(t, ledger.WalletAccountID(2), entry.Postings[1].AccountID)
+		}
+	})
+
+	t.Run("OptimisticVersioning", func(t *testing.T) {
+		repo := newFundedRepo(t, newRepo, 100)
+
+		account, err := repo.GetAccount(ledger.WalletAccountID(1))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), account.Version)
+
+		entry := transferEntry("t1", 1, 2, "10")
+		entry.ExpectedVersions = map[string]int64{ledger.WalletAccountID(1): account.Version}
+		require.NoError(t, repo.PostEntry(entry))
+
+		stale := transferEntry("t2", 1, 2, "10")
+		stale.ExpectedVersions = map[string]int64{ledger.WalletAccountID(1): account.Version}
+		err = repo.PostEntry(stale)
+
+		var conflict *ledger.VersionConflictError
+		require.True(t, errors.As(err, &conflict), "PostEntry: %v", err)
+		assert.Equal(t, ledger.WalletAccountID(1), conflict.AccountID)
+		assert.Equal(t, int64(1), conflict.ExpectedVersion)
+		assert.Equal(t, int64(2), conflict.ActualVersion)
+		assertBalance(t, repo, ledger.WalletAccountID(1), "90")
+
+		payee, err := repo.GetAccount(ledger.WalletAccountID(2))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), payee.Version)
+	})
+
+	t.Run("ConcurrentDebitsNeverOverdraw", func(t *testing.T) {
+		repo := newFundedRepo(t, newRepo, 200)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := repo.PostEntry(transferEntry(fmt.Sprintf("c%d", i), 1, 2, "10"))
+				if err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+					return
+				}
+				assert.True(t, errors.Is(err, ledger.ErrInsufficientBalance), "PostEntry: %v", err)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 20, succeeded)
+		assertBalance(t, repo, ledger.WalletAccountID(1), "0")
+		assertBalance(t, repo, ledger.WalletAccountID(2), "200")
+	})
+}
+
+func newFundedRepo(t *testing.T, newRepo func(t *testing.T) ledger.LedgerRepository, initial int64) ledger.LedgerRepository {
+	repo := newRepo(t)
+	require.NoError(t, repo.CreateAccount(&ledger.Account{
+		ID:            ledger.FundingAccountID,
+		Type:          ledger.SystemAccount,
+		AllowNegative: true,
+		CreatedAt:     time.Now(),
+	}))
+	require.NoError(t, repo.CreateAccount(walletAccount(1)))
+	require.NoError(t, repo.CreateAccount(walletAccount(2)))
+	require.NoError(t, repo.PostEntry(&ledger.Entry{
+		ID:          "funding",
+		Description: "Saldo inicial",
+		Postings: []ledger.Posting{
+			{AccountID: ledger.FundingAccountID, Amount: decimal.NewFromInt(-initial)},
+			{AccountID: ledger.WalletAccountID(1), Amount: decimal.NewFromInt(initial)},
+		},
+		CreatedAt: time.Now(),
+	}))
+	return repo
+}
+
+func walletAccount(userID int) *ledger.Account {
+	return &ledger.Account{
+		ID:        ledger.WalletAccountID(userID),
+		Type:      ledger.WalletAccount,
+		CreatedAt: time.Now(),
+	}
+}
+
+func transferEntry(id string, payerID int, payeeID int, amount string) *ledger.Entry {
+	value := decimal.RequireFromString(amount)
+	return &ledger.Entry{
+		ID:          id,
+		Description: "Transferência",
+		Reference:   id,
+		Postings: []ledger.Posting{
+			{AccountID: ledger.WalletAccountID(payerID), Amount: value.Neg()},
+			{AccountID: ledger.WalletAccountID(payeeID), Amount: value},
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+func assertBalance(t *testing.T, repo ledger.LedgerRepository, accountID string, expected string) {
+	t.Helper()
+
+	balance, err := repo.GetBalance(accountID)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.RequireFromString(expected)), "saldo de %s: esperado %s, obtido %s", accountID, expected, balance)
+}
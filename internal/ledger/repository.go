@@ -0,0 +1,256 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"pag-simples/internal/apperrors"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAccountNotFound      = errors.New("conta não encontrada")
+	ErrAccountAlreadyExists = errors.New("conta já existe")
+	ErrInsufficientBalance  = apperrors.New(apperrors.CodeInsufficientFunds, "saldo insuficiente na conta")
+)
+
+type VersionConflictError struct {
+	AccountID       string
+	ExpectedVersion int64
+	ActualVersion   int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("conflito de versão na conta %s: esperada %d, atual %d", e.AccountID, e.ExpectedVersion, e.ActualVersion)
+}
+
+type LedgerRepository interface {
+	CreateAccount(account *Account) error
+	GetAccount(accountID string) (*Account, error)
+	PostEntry(entry *Entry) error
+	GetBalance(accountID string) (decimal.Decimal, error)
+	GetEntries(accountID string) ([]Entry, error)
+}
+
+type MemoryLedgerRepository struct {
+	mu       sync.RWMutex
+	accounts map[string]Account
+	entries  []Entry
+	postings map[string][]int
+}
+
+func NewMemoryLedgerRepository() *MemoryLedgerRepository {
+	return &MemoryLedgerRepository{
+		accounts: make(map[string]Account),
+		entries:  []Entry{},
+		postings: make(map[string][]int),
+	}
+}
+
+func (r *MemoryLedgerRepository) CreateAccount(account *Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createAccount(account)
+}
+
+func (r *MemoryLedgerRepository) GetAccount(accountID string) (*Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getAccount(accountID)
+}
+
+func (r *MemoryLedgerRepository) PostEntry(entry *Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.postEntry(entry)
+}
+
+func (r *MemoryLedgerRepository) GetBalance(accountID string) (decimal.Decimal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getBalance(accountID)
+}
+
+func (r *MemoryLedgerRepository) GetEntries(accountID string) ([]Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getEntries(accountID)
+}
+
+// Begin bloqueia o repositório até Commit ou Rollback. Dentro da transação,
+// use apenas o MemoryLedgerTx retornado para evitar deadlock.
+func (r *MemoryLedgerRepository) Begin() *MemoryLedgerTx {
+	r.mu.Lock()
+	return &MemoryLedgerTx{repo: r}
+}
+
+func (r *MemoryLedgerRepository) createAccount(account *Account) error {
+	if _, exists := r.accounts[account.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrAccountAlreadyExists, account.ID)
+	}
+	r.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *MemoryLedgerRepository) getAccount(accountID string) (*Account, error) {
+	account, exists := r.accounts[accountID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	return &account, nil
+}
+
+func (r *MemoryLedgerRepository) postEntry(entry *Entry) error {
+	deltas, accountIDs := entry.Deltas()
+	for _, accountID := range accountIDs {
+		account, exists := r.accounts[accountID]
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+		}
+		if expected, ok := entry.ExpectedVersions[accountID]; ok && expected != account.Version {
+			return &VersionConflictError{AccountID: accountID, ExpectedVersion: expected, ActualVersion: account.Version}
+		}
+		if !account.AllowNegative && r.balance(accountID).Add(deltas[accountID]).IsNegative() {
+			return fmt.Errorf("%w %s", ErrInsufficientBalance, accountID)
+		}
+	}
+
+	r.entries = append(r.entries, copyEntry(entry))
+	for _, posting := range entry.Postings {
+		r.postings[posting.AccountID] = append(r.postings[posting.AccountID], len(r.entries)-1)
+	}
+	r.bumpVersions(accountIDs, 1)
+	return nil
+}
+
+func (r *MemoryLedgerRepository) bumpVersions(accountIDs []string, delta int64) {
+	for _, accountID := range accountIDs {
+		account := r.accounts[accountID]
+		account.Version += delta
+		r.accounts[accountID] = account
+	}
+}
+
+func (r *MemoryLedgerRepository) getBalance(accountID string) (decimal.Decimal, error) {
+	if _, exists := r.accounts[accountID]; !exists {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	return r.balance(accountID), nil
+}
+
+func (r *MemoryLedgerRepository) getEntries(accountID string) ([]Entry, error) {
+	if _, exists := r.accounts[accountID]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+
+	entries := []Entry{}
+	last := -1
+	for _, index := range r.postings[accountID] {
+		if index == last {
+			continue
+		}
+		entries = append(entries, copyEntry(&r.entries[index]))
+		last = index
+	}
+	return entries, nil
+}
+
+func (r *MemoryLedgerRepository) balance(accountID string) decimal.Decimal {
+	balance := decimal.Zero
+	last := -1
+	for _, index := range r.postings[accountID] {
+		if index == last {
+			continue
+		}
+		for _, posting := range r.entries[index].Postings {
+			if posting.AccountID == accountID {
+				balance = balance.Add(posting.Amount)
+			}
+		}
+		last = index
+	}
+	return balance
+}
+
+func (r *MemoryLedgerRepository) removeLastEntry() {
+	last := len(r.entries) - 1
+	_, accountIDs := r.entries[last].Deltas()
+	r.bumpVersions(accountIDs, -1)
+	for _, posting := range r.entries[last].Postings {
+		indexes := r.postings[posting.AccountID]
+		for len(indexes) > 0 && indexes[len(indexes)-1] == last {
+			indexes = indexes[:len(indexes)-1]
+		}
+		r.postings[posting.AccountID] = indexes
+	}
+	r.entries = r.entries[:last]
+}
+
+type MemoryLedgerTx struct {
+	repo *MemoryLedgerRepository
+	undo []func()
+	done bool
+}
+
+func (t *MemoryLedgerTx) CreateAccount(account *Account) error {
+	if err := t.repo.createAccount(account); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() { delete(t.repo.accounts, account.ID) })
+	return nil
+}
+
+func (t *MemoryLedgerTx) GetAccount(accountID string) (*Account, error) {
+	return t.repo.getAccount(accountID)
+}
+
+func (t *MemoryLedgerTx) PostEntry(entry *Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	if err := t.repo.postEntry(entry); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, t.repo.removeLastEntry)
+	return nil
+}
+
+func (t *MemoryLedgerTx) GetBalance(accountID string) (decimal.Decimal, error) {
+	return t.repo.getBalance(accountID)
+}
+
+func (t *MemoryLedgerTx) GetEntries(accountID string) ([]Entry, error) {
+	return t.repo.getEntries(accountID)
+}
+
+func (t *MemoryLedgerTx) Commit() {
+	if t.done {
+		return
+	}
+	t.done = true
+	t.repo.mu.Unlock()
+}
+
+func (t *MemoryLedgerTx) Rollback() {
+	if t.done {
+		return
+	}
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	t.done = true
+	t.repo.mu.Unlock()
+}
+
+func copyEntry(entry *Entry) Entry {
+	copied := *entry
+	copied.Postings = append([]Posting(nil), entry.Postings...)
+	copied.ExpectedVersions = nil
+	return copied
+}
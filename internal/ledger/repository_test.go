@@ -0,0 +1,60 @@
+package ledger_test
+
+import (
+	"testing"
+	"time"
+
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/ledger/ledgertest"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLedgerRepository(t *testing.T) {
+	ledgertest.RunLedgerRepositoryTests(t, func(t *testing.T) ledger.LedgerRepository {
+		return ledger.NewMemoryLedgerRepository()
+	})
+}
+
+func TestSQLLedgerRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			ledgertest.RunLedgerRepositoryTests(t, func(t *testing.T) ledger.LedgerRepository {
+				return ledger.NewSQLLedgerRepository(databasetest.Open(t, driver))
+			})
+		})
+	}
+}
+
+// A coluna balance é só cache: um ajuste manual nela não muda o saldo, que é
+// a soma das partidas.
+func TestSQLGetBalanceIgnoresCachedColumn(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			db := databasetest.Open(t, driver)
+			repo := ledger.NewSQLLedgerRepository(db)
+
+			require.NoError(t, repo.CreateAccount(&ledger.Account{ID: ledger.FundingAccountID, Type: ledger.SystemAccount, AllowNegative: true, CreatedAt: time.Now()}))
+			require.NoError(t, repo.CreateAccount(&ledger.Account{ID: ledger.WalletAccountID(1), Type: ledger.WalletAccount, CreatedAt: time.Now()}))
+			require.NoError(t, repo.PostEntry(&ledger.Entry{
+				ID:          "funding",
+				Description: "Saldo inicial",
+				Postings: []ledger.Posting{
+					{AccountID: ledger.FundingAccountID, Amount: decimal.NewFromInt(-40)},
+					{AccountID: ledger.WalletAccountID(1), Amount: decimal.NewFromInt(40)},
+				},
+				CreatedAt: time.Now(),
+			}))
+
+			_, err := db.Exec("UPDATE ledger_accounts SET balance = $1 WHERE id = $2", decimal.NewFromInt(1000), ledger.WalletAccountID(1))
+			require.NoError(t, err)
+
+			balance, err := repo.GetBalance(ledger.WalletAccountID(1))
+			require.NoError(t, err)
+			assert.True(t, balance.Equal(decimal.NewFromInt(40)), "saldo: %s", balance)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"pag-simples/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+type SQLLedgerRepository struct {
+	db database.Executor
+}
+
+func NewSQLLedgerRepository(db database.Executor) *SQLLedgerRepository {
+	return &SQLLedgerRepository{
+		db: db,
+	}
+}
+
+func (r *SQLLedgerRepository) CreateAccount(account *Account) error {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM ledger_accounts WHERE id = $1", account.ID).Scan(&count); err != nil {
+		return fmt.Errorf("erro ao consultar conta %s: %v", account.ID, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrAccountAlreadyExists, account.ID)
+	}
+
+	_, err := r.db.Exec(
+		"INSERT INTO ledger_accounts (id, type, allow_negative, balance, created_at) VALUES ($1, $2, $3, $4, $5)",
+		account.ID, account.Type, account.AllowNegative, decimal.Zero, account.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao criar conta %s: %v", account.ID, err)
+	}
+	return nil
+}
+
+func (r *SQLLedgerRepository) GetAccount(accountID string) (*Account, error) {
+	var account Account
+	err := r.db.QueryRow(
+		"SELECT id, type, allow_negative, version, created_at FROM ledger_accounts WHERE id = $1", accountID,
+	).Scan(&account.ID, &account.Type, &account.AllowNegative, &account.Version, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar conta %s: %v", accountID, err)
+	}
+	return &account, nil
+}
+
+func (r *SQLLedgerRepository) PostEntry(entry *Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	return database.RunInTx(r.db, func(tx database.Executor) error {
+		deltas, accountIDs := entry.Deltas()
+		balances := make(map[string]decimal.Decimal)
+		versions := make(map[string]int64)
+		for _, accountID := range accountIDs {
+			var allowNegative bool
+			var balance decimal.Decimal
+			var version int64
+			err := tx.QueryRow(
+				"SELECT allow_negative, balance, version FROM ledger_accounts WHERE id = $1"+tx.Dialect().ForUpdate(), accountID,
+			).Scan(&allowNegative, &balance, &version)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+			}
+			if err != nil {
+				return fmt.Errorf("erro ao bloquear conta %s: %v", accountID, err)
+			}
+
+			if expected, ok := entry.ExpectedVersions[accountID]; ok && expected != version {
+				return &VersionConflictError{AccountID: accountID, ExpectedVersion: expected, ActualVersion: version}
+			}
+
+			newBalance := balance.Add(deltas[accountID])
+			if !allowNegative && newBalance.IsNegative() {
+				return fmt.Errorf("%w %s", ErrInsufficientBalance, accountID)
+			}
+			balances[accountID] = newBalance
+			versions[accountID] = version
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO ledger_entries (id, description, reference, created_at) VALUES ($1, $2, $3, $4)",
+			entry.ID, entry.Description, entry.Reference, entry.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao registrar lançamento %s: %v", entry.ID, err)
+		}
+
+		for position, posting := range entry.Postings {
+			_, err := tx.Exec(
+				"INSERT INTO ledger_postings (entry_id, position, account_id, amount) VALUES ($1, $2, $3, $4)",
+				entry.ID, position, posting.AccountID, posting.Amount,
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao registrar partida do lançamento %s: %v", entry.ID, err)
+			}
+		}
+
+		for _, accountID := range accountIDs {
+			result, err := tx.Exec(
+				"UPDATE ledger_accounts SET balance = $1, version = version + 1 WHERE id = $2 AND version = $3",
+				balances[accountID], accountID, versions[accountID],
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao atualizar saldo da conta %s: %v", accountID, err)
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("erro ao atualizar saldo da conta %s: %v", accountID, err)
+			}
+			if affected == 0 {
+				conflict := &VersionConflictError{AccountID: accountID, ExpectedVersion: versions[accountID]}
+				tx.QueryRow("SELECT version FROM ledger_accounts WHERE id = $1", accountID).Scan(&conflict.ActualVersion)
+				return conflict
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetBalance soma as partidas da conta, como o repositório em memória. A coluna
+// balance é só um cache usado por PostEntry para recusar saques a descoberto
+// sem reler o histórico; ela é lida na mesma consulta e, se divergir da soma,
+// a divergência é registrada e vale a soma.
+func (r *SQLLedgerRepository) GetBalance(accountID string) (decimal.Decimal, error) {
+	rows, err := r.db.Query(`SELECT a.balance, p.amount
+		FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		WHERE a.id = $1`, accountID)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("erro ao buscar saldo da conta %s: %v", accountID, err)
+	}
+	defer rows.Close()
+
+	found := false
+	balance, cached := decimal.Zero, decimal.Zero
+	for rows.Next() {
+		var amount decimal.NullDecimal
+		if err := rows.Scan(&cached, &amount); err != nil {
+			return decimal.Zero, fmt.Errorf("erro ao ler partida da conta %s: %v", accountID, err)
+		}
+		found = true
+		if amount.Valid {
+			balance = balance.Add(amount.Decimal)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return decimal.Zero, fmt.Errorf("erro ao buscar saldo da conta %s: %v", accountID, err)
+	}
+	if !found {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+	}
+
+	if !balance.Equal(cached) {
+		log.Printf("Saldo em cache da conta %s (%s) diverge da soma das partidas (%s)", accountID, cached.String(), balance.String())
+	}
+	return balance, nil
+}
+
+func (r *SQLLedgerRepository) GetEntries(accountID string) ([]Entry, error) {
+	if _, err := r.GetAccount(accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT e.id, e.description, e.reference, e.created_at, p.account_id, p.amount
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.entry_id = e.id
+		WHERE e.id IN (SELECT entry_id FROM ledger_postings WHERE account_id = $1)
+		ORDER BY e.seq, p.position`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar lançamentos da conta %s: %v", accountID, err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var posting Posting
+		if err := rows.Scan(&entry.ID, &entry.Description, &entry.Reference, &entry.CreatedAt, &posting.AccountID, &posting.Amount); err != nil {
+			return nil, fmt.Errorf("erro ao ler lançamento: %v", err)
+		}
+
+		if len(entries) == 0 || entries[len(entries)-1].ID != entry.ID {
+			entries = append(entries, entry)
+		}
+		last := &entries[len(entries)-1]
+		last.Postings = append(last.Postings, posting)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusDead      Status = "dead"
+)
+
+func (s Status) Valid() bool {
+	return s == StatusPending || s == StatusDelivered || s == StatusDead
+}
+
+// Message é um evento gravado na mesma unidade de trabalho da operação que o
+// originou e entregue depois pelo OutboxService.
+type Message struct {
+	ID            string          `json:"id"`
+	Topic         string          `json:"topic"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        Status          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Seq           int64           `json:"-"`
+}
+
+// MessageFilter com Status vazio lista mensagens de todos os status.
+type MessageFilter struct {
+	Status Status
+	Cursor string
+	Limit  int
+}
+
+type MessagePage struct {
+	Messages   []Message `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+func NewMessage(topic string, payload interface{}) (*Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao codificar mensagem do tópico %s: %v", topic, err)
+	}
+
+	now := time.Now()
+	return &Message{
+		ID:            uuid.New().String(),
+		Topic:         topic,
+		Payload:       data,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package outboxtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/pagination"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func RunOutboxRepositoryTests(t *testing.T, newRepo func(t *testing.T) outbox.OutboxRepository) {
+	t.Run("AddAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		message := newMessage(t, "notification")
+
+		require.NoError(t, repo.Add(message))
+		assert.NotZero(t, message.Seq)
+
+		stored, err := repo.Get(message.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "notification", stored.Topic)
+		assert.JSONEq(t, string(message.Payload), string(stored.Payload))
+		assert.Equal(t, outbox.StatusPending, stored.Status)
+		assert.Zero(t, stored.Attempts)
+		assert.Equal(t, message.NextAttemptAt.UnixMilli(), stored.NextAttemptAt.UnixMilli())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Get("inexistente")
+		assert.True(t, errors.Is(err, outbox.ErrMessageNotFound), "Get: %v", err)
+
+		err = repo.Update(&outbox.Message{ID: "inexistente", Status: outbox.StatusDead})
+		assert.True(t, errors.Is(err, outbox.ErrMessageNotFound), "Update: %v", err)
+	})
+
+	t.Run("ClaimReservesDueMessages", func(t *testing.T) {
+		repo := newRepo(t)
+
+		due := newMessage(t, "notification")
+		require.NoError(t, repo.Add(due))
+
+		now := time.Now()
+		later := newMessage(t, "notification")
+		later.NextAttemptAt = now.Add(time.Hour)
+		require.NoError(t, repo.Add(later))
+
+		claimed, err := repo.Claim(now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, due.ID, claimed[0].ID)
+		assert.Equal(t, now.Add(time.Minute).UnixMilli(), claimed[0].NextAttemptAt.UnixMilli())
+
+		claimed, err = repo.Claim(now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed, "mensagem reservada não pode ser entregue de novo antes do fim da reserva")
+
+		claimed, err = repo.Claim(now.Add(2*time.Minute), now.Add(3*time.Minute), 10)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 1, "a reserva expirada volta para a fila")
+	})
+
+	t.Run("ClaimRespectsLimitAndOrder", func(t *testing.T) {
+		repo := newRepo(t)
+		ids := make([]string, 3)
+		for i := range ids {
+			message := newMessage(t, "notification")
+			require.NoError(t, repo.Add(message))
+			ids[i] = message.ID
+		}
+
+		now := time.Now()
+		claimed, err := repo.Claim(now, now.Add(time.Minute), 2)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, ids[0], claimed[0].ID)
+		assert.Equal(t, ids[1], claimed[1].ID)
+	})
+
+	t.Run("ClaimSkipsFinishedMessages", func(t *testing.T) {
+		repo := newRepo(t)
+		message := newMessage(t, "notification")
+		require.NoError(t, repo.Add(message))
+
+		message.Status = outbox.StatusDelivered
+		message.Attempts = 1
+		require.NoError(t, repo.Update(message))
+
+		now := time.Now()
+		claimed, err := repo.Claim(now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+	})
+
+	t.Run("ConcurrentClaimsNeverOverlap", func(t *testing.T) {
+		repo := newRepo(t)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, repo.Add(newMessage(t, "notification")))
+		}
+
+		now := time.Now()
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		seen := make(map[string]int)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				claimed, err := repo.Claim(now, now.Add(time.Minute), 10)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, message := range claimed {
+					seen[message.ID]++
+				}
+			}()
+		}
+		wg.Wait()
+
+		for id, count := range seen {
+			assert.Equal(t, 1, count, "mensagem %s reservada %d vezes", id, count)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		message := newMessage(t, "notification")
+		require.NoError(t, repo.Add(message))
+
+		next := time.Now().Add(time.Minute)
+		message.Attempts = 2
+		message.LastError = "timeout"
+		message.NextAttemptAt = next
+		require.NoError(t, repo.Update(message))
+
+		stored, err := repo.Get(message.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, stored.Attempts)
+		assert.Equal(t, "timeout", stored.LastError)
+		assert.Equal(t, next.UnixMilli(), stored.NextAttemptAt.UnixMilli())
+	})
+
+	t.Run("ListFiltersAndPaginates", func(t *testing.T) {
+		repo := newRepo(t)
+		ids := make([]string, 5)
+		for i := range ids {
+			message := newMessage(t, "notification")
+			require.NoError(t, repo.Add(message))
+			ids[i] = message.ID
+
+			if i%2 == 0 {
+				message.Status = outbox.StatusDead
+				message.Attempts = 8
+				require.NoError(t, repo.Update(message))
+			}
+		}
+
+		dead, cursor, err := repo.List(outbox.MessageFilter{Status: outbox.StatusDead, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, dead, 2)
+		assert.Equal(t, ids[4], dead[0].ID)
+		assert.Equal(t, ids[2], dead[1].ID)
+		require.NotEmpty(t, cursor)
+
+		dead, cursor, err = repo.List(outbox.MessageFilter{Status: outbox.StatusDead, Cursor: cursor, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, dead, 1)
+		assert.Equal(t, ids[0], dead[0].ID)
+		assert.Empty(t, cursor)
+
+		all, _, err := repo.List(outbox.MessageFilter{})
+		require.NoError(t, err)
+		assert.Len(t, all, 5)
+
+		_, _, err = repo.List(outbox.MessageFilter{Cursor: "invalido"})
+		assert.True(t, errors.Is(err, pagination.ErrInvalidCursor), "List: %v", err)
+	})
+}
+
+func newMessage(t *testing.T, topic string) *outbox.Message {
+	message, err := outbox.NewMessage(topic, map[string]string{"message": fmt.Sprintf("mensagem de %s", topic)})
+	require.NoError(t, err)
+	return message
+}
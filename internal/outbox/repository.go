@@ -0,0 +1,222 @@
+package outbox
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/pagination"
+)
+
+var ErrMessageNotFound = apperrors.ErrOutboxMessageNotFound
+
+type OutboxRepository interface {
+	Add(message *Message) error
+	// Claim reserva até limit mensagens pendentes vencidas em now, adiando a
+	// próxima tentativa para until para que outros dispatchers não as peguem.
+	Claim(now time.Time, until time.Time, limit int) ([]Message, error)
+	Update(message *Message) error
+	Get(id string) (*Message, error)
+	List(filter MessageFilter) ([]Message, string, error)
+}
+
+type MemoryOutboxRepository struct {
+	mu       sync.Mutex
+	messages map[string]Message
+	seq      int64
+}
+
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{
+		messages: make(map[string]Message),
+	}
+}
+
+func (r *MemoryOutboxRepository) Add(message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.add(message)
+}
+
+func (r *MemoryOutboxRepository) Claim(now time.Time, until time.Time, limit int) ([]Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.claim(now, until, limit), nil
+}
+
+func (r *MemoryOutboxRepository) Update(message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(message)
+}
+
+func (r *MemoryOutboxRepository) Get(id string) (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.get(id)
+}
+
+func (r *MemoryOutboxRepository) List(filter MessageFilter) ([]Message, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(filter)
+}
+
+// Begin bloqueia o repositório até Commit ou Rollback. Dentro da transação,
+// use apenas o MemoryOutboxTx retornado para evitar deadlock.
+func (r *MemoryOutboxRepository) Begin() *MemoryOutboxTx {
+	r.mu.Lock()
+	return &MemoryOutboxTx{repo: r}
+}
+
+func (r *MemoryOutboxRepository) add(message *Message) error {
+	if _, exists := r.messages[message.ID]; exists {
+		return fmt.Errorf("mensagem %s já registrada", message.ID)
+	}
+	r.seq++
+	message.Seq = r.seq
+	r.messages[message.ID] = *message
+	return nil
+}
+
+func (r *MemoryOutboxRepository) claim(now time.Time, until time.Time, limit int) []Message {
+	due := []Message{}
+	for _, message := range r.messages {
+		if message.Status == StatusPending && !message.NextAttemptAt.After(now) {
+			due = append(due, message)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Seq < due[j].Seq
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for i := range due {
+		due[i].NextAttemptAt = until
+		r.messages[due[i].ID] = due[i]
+	}
+	return due
+}
+
+func (r *MemoryOutboxRepository) update(message *Message) error {
+	stored, exists := r.messages[message.ID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, message.ID)
+	}
+
+	stored.Status = message.Status
+	stored.Attempts = message.Attempts
+	stored.LastError = message.LastError
+	stored.NextAttemptAt = message.NextAttemptAt
+	stored.UpdatedAt = message.UpdatedAt
+	r.messages[message.ID] = stored
+	return nil
+}
+
+func (r *MemoryOutboxRepository) get(id string) (*Message, error) {
+	message, exists := r.messages[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	return &message, nil
+}
+
+func (r *MemoryOutboxRepository) list(filter MessageFilter) ([]Message, string, error) {
+	before, err := pagination.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	messages := []Message{}
+	for _, message := range r.messages {
+		if before > 0 && message.Seq >= before {
+			continue
+		}
+		if filter.Status != "" && message.Status != filter.Status {
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Seq > messages[j].Seq
+	})
+	messages, next := pagination.Page(messages, filter.Limit, Message.position)
+	return messages, next, nil
+}
+
+type MemoryOutboxTx struct {
+	repo *MemoryOutboxRepository
+	undo []func()
+	done bool
+}
+
+func (t *MemoryOutboxTx) Add(message *Message) error {
+	if err := t.repo.add(message); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() { delete(t.repo.messages, message.ID) })
+	return nil
+}
+
+func (t *MemoryOutboxTx) Claim(now time.Time, until time.Time, limit int) ([]Message, error) {
+	claimed := t.repo.claim(now, until, limit)
+	t.undo = append(t.undo, func() {
+		// Basta que voltem a vencer em now; o horário original já passou.
+		for _, message := range claimed {
+			stored := t.repo.messages[message.ID]
+			stored.NextAttemptAt = now
+			t.repo.messages[message.ID] = stored
+		}
+	})
+	return claimed, nil
+}
+
+func (t *MemoryOutboxTx) Update(message *Message) error {
+	previous, err := t.repo.get(message.ID)
+	if err != nil {
+		return err
+	}
+	if err := t.repo.update(message); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() { t.repo.messages[previous.ID] = *previous })
+	return nil
+}
+
+func (t *MemoryOutboxTx) Get(id string) (*Message, error) {
+	return t.repo.get(id)
+}
+
+func (t *MemoryOutboxTx) List(filter MessageFilter) ([]Message, string, error) {
+	return t.repo.list(filter)
+}
+
+func (t *MemoryOutboxTx) Commit() {
+	if t.done {
+		return
+	}
+	t.done = true
+	t.repo.mu.Unlock()
+}
+
+func (t *MemoryOutboxTx) Rollback() {
+	if t.done {
+		return
+	}
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	t.done = true
+	t.repo.mu.Unlock()
+}
+
+// position é a posição da mensagem no cursor da listagem.
+func (m Message) position() int64 {
+	return m.Seq
+}
@@ -0,0 +1,25 @@
+package outbox_test
+
+import (
+	"testing"
+
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/outbox/outboxtest"
+)
+
+func TestMemoryOutboxRepository(t *testing.T) {
+	outboxtest.RunOutboxRepositoryTests(t, func(t *testing.T) outbox.OutboxRepository {
+		return outbox.NewMemoryOutboxRepository()
+	})
+}
+
+func TestSQLOutboxRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			outboxtest.RunOutboxRepositoryTests(t, func(t *testing.T) outbox.OutboxRepository {
+				return outbox.NewSQLOutboxRepository(databasetest.Open(t, driver))
+			})
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+)
+
+const (
+	DefaultBatchSize = 50
+	// DefaultLease é o tempo que uma mensagem reservada fica fora do alcance
+	// de outros dispatchers; deve cobrir com folga a entrega mais lenta.
+	DefaultLease = time.Minute
+)
+
+// BackoffPolicy dobra o intervalo a cada falha, até Max. A mensagem vai para
+// dead depois de MaxAttempts tentativas.
+type BackoffPolicy struct {
+	Initial     time.Duration
+	Max         time.Duration
+	MaxAttempts int
+}
+
+var DefaultBackoffPolicy = BackoffPolicy{
+	Initial:     5 * time.Second,
+	Max:         10 * time.Minute,
+	MaxAttempts: 8,
+}
+
+func (p BackoffPolicy) delay(attempts int) time.Duration {
+	delay := p.Initial
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.Max {
+			return p.Max
+		}
+	}
+	return delay
+}
+
+type Handler func(message Message) error
+
+type OutboxService struct {
+	repo      OutboxRepository
+	policy    BackoffPolicy
+	batchSize int
+	lease     time.Duration
+	now       func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewOutboxService(repo OutboxRepository, policy BackoffPolicy) *OutboxService {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	return &OutboxService{
+		repo:      repo,
+		policy:    policy,
+		batchSize: DefaultBatchSize,
+		lease:     DefaultLease,
+		now:       time.Now,
+		handlers:  make(map[string]Handler),
+	}
+}
+
+// Handle registra quem entrega as mensagens de topic.
+func (s *OutboxService) Handle(topic string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[topic] = handler
+}
+
+// Dispatch entrega um lote de mensagens vencidas e devolve quantas foram
+// processadas, com sucesso ou não.
+func (s *OutboxService) Dispatch() (int, error) {
+	now := s.now()
+	messages, err := s.repo.Claim(now, now.Add(s.lease), s.batchSize)
+	if err != nil {
+		log.Printf("Erro ao reservar mensagens do outbox: %v", err)
+		return 0, err
+	}
+
+	for i := range messages {
+		s.deliver(&messages[i])
+	}
+	return len(messages), nil
+}
+
+// Run chama Dispatch a cada interval até ctx ser cancelado, esvaziando a fila
+// quando há mais mensagens vencidas do que cabem em um lote.
+func (s *OutboxService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.Dispatch()
+			if err != nil || processed < s.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *OutboxService) deliver(message *Message) {
+	s.mu.RLock()
+	handler, exists := s.handlers[message.Topic]
+	s.mu.RUnlock()
+
+	err := fmt.Errorf("nenhum handler para o tópico %s", message.Topic)
+	if exists {
+		err = handler(*message)
+	}
+
+	now := s.now()
+	message.Attempts++
+	message.UpdatedAt = now
+
+	switch {
+	case err == nil:
+		message.Status = StatusDelivered
+		message.LastError = ""
+	case message.Attempts >= s.policy.MaxAttempts:
+		log.Printf("Mensagem %s do tópico %s descartada após %d tentativas: %v", message.ID, message.Topic, message.Attempts, err)
+		message.Status = StatusDead
+		message.LastError = err.Error()
+	default:
+		log.Printf("Falha ao entregar mensagem %s do tópico %s (tentativa %d): %v", message.ID, message.Topic, message.Attempts, err)
+		message.LastError = err.Error()
+		message.NextAttemptAt = now.Add(s.policy.delay(message.Attempts))
+	}
+
+	if err := s.repo.Update(message); err != nil {
+		log.Printf("Erro ao atualizar mensagem %s do outbox: %v", message.ID, err)
+	}
+}
+
+func (s *OutboxService) ListMessages(filter MessageFilter) (*MessagePage, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "status inválido").WithDetails(map[string]interface{}{
+			"status":  filter.Status,
+			"allowed": []Status{StatusPending, StatusDelivered, StatusDead},
+		})
+	}
+
+	messages, cursor, err := s.repo.List(filter)
+	if err != nil {
+		log.Printf("Erro ao listar mensagens do outbox: %v", err)
+		return nil, err
+	}
+
+	return &MessagePage{
+		Messages:   messages,
+		NextCursor: cursor,
+	}, nil
+}
+
+// Replay devolve uma mensagem dead para a fila, com as tentativas zeradas.
+func (s *OutboxService) Replay(id string) (*Message, error) {
+	message, err := s.repo.Get(id)
+	if err != nil {
+		log.Printf("Erro ao buscar mensagem %s do outbox: %v", id, err)
+		return nil, err
+	}
+
+	if message.Status != StatusDead {
+		return nil, apperrors.ErrInvalidStatusTransition.WithDetails(map[string]interface{}{
+			"id":     id,
+			"status": message.Status,
+		})
+	}
+
+	now := s.now()
+	message.Status = StatusPending
+	message.Attempts = 0
+	message.NextAttemptAt = now
+	message.UpdatedAt = now
+
+	if err := s.repo.Update(message); err != nil {
+		log.Printf("Erro ao reenfileirar mensagem %s do outbox: %v", id, err)
+		return nil, err
+	}
+
+	log.Printf("Mensagem %s do tópico %s reenfileirada", message.ID, message.Topic)
+	return message, nil
+}
@@ -0,0 +1,183 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestService(t *testing.T, policy BackoffPolicy) (*OutboxService, *MemoryOutboxRepository, *clock) {
+	repo := NewMemoryOutboxRepository()
+	// Começa adiantado para que as mensagens criadas no teste já estejam vencidas.
+	c := &clock{now: time.Now().Add(time.Second)}
+	service := NewOutboxService(repo, policy)
+	service.now = c.Now
+	return service, repo, c
+}
+
+func addMessage(t *testing.T, repo OutboxRepository, topic string) *Message {
+	message, err := NewMessage(topic, map[string]string{"message": "olá"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Add(message))
+	return message
+}
+
+func TestBackoffDelay(t *testing.T) {
+	policy := BackoffPolicy{Initial: time.Second, Max: 10 * time.Second, MaxAttempts: 10}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, delay := range expected {
+		assert.Equal(t, delay, policy.delay(i+1), "tentativa %d", i+1)
+	}
+}
+
+func TestDispatchDeliversMessages(t *testing.T) {
+	service, repo, _ := newTestService(t, DefaultBackoffPolicy)
+	message := addMessage(t, repo, "notification")
+
+	var delivered []string
+	service.Handle("notification", func(m Message) error {
+		delivered = append(delivered, m.ID)
+		return nil
+	})
+
+	processed, err := service.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []string{message.ID}, delivered)
+
+	stored, err := repo.Get(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDelivered, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+
+	processed, err = service.Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+}
+
+func TestDispatchRetriesWithBackoffUntilDead(t *testing.T) {
+	policy := BackoffPolicy{Initial: time.Second, Max: time.Minute, MaxAttempts: 3}
+	service, repo, c := newTestService(t, policy)
+	message := addMessage(t, repo, "notification")
+
+	calls := 0
+	service.Handle("notification", func(Message) error {
+		calls++
+		return fmt.Errorf("servidor indisponível")
+	})
+
+	start := c.now
+	_, err := service.Dispatch()
+	require.NoError(t, err)
+
+	stored, err := repo.Get(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, "servidor indisponível", stored.LastError)
+	assert.Equal(t, start.Add(time.Second), stored.NextAttemptAt)
+
+	processed, err := service.Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, processed, "não deve tentar de novo antes do backoff")
+
+	c.now = stored.NextAttemptAt
+	_, err = service.Dispatch()
+	require.NoError(t, err)
+
+	stored, err = repo.Get(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, c.now.Add(2*time.Second), stored.NextAttemptAt)
+
+	c.now = stored.NextAttemptAt
+	_, err = service.Dispatch()
+	require.NoError(t, err)
+
+	stored, err = repo.Get(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDead, stored.Status)
+	assert.Equal(t, 3, stored.Attempts)
+	assert.Equal(t, 3, calls)
+
+	c.now = c.now.Add(time.Hour)
+	processed, err = service.Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+}
+
+func TestDispatchWithoutHandlerRetries(t *testing.T) {
+	service, repo, _ := newTestService(t, DefaultBackoffPolicy)
+	message := addMessage(t, repo, "desconhecido")
+
+	_, err := service.Dispatch()
+	require.NoError(t, err)
+
+	stored, err := repo.Get(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, stored.Status)
+	assert.Equal(t, "nenhum handler para o tópico desconhecido", stored.LastError)
+}
+
+func TestReplay(t *testing.T) {
+	service, repo, c := newTestService(t, BackoffPolicy{Initial: time.Second, Max: time.Second, MaxAttempts: 1})
+	message := addMessage(t, repo, "notification")
+
+	failing := true
+	service.Handle("notification", func(Message) error {
+		if failing {
+			return fmt.Errorf("falha")
+		}
+		return nil
+	})
+
+	_, err := service.Replay(message.ID)
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidStatusTransition), "Replay pendente: %v", err)
+
+	_, err = service.Dispatch()
+	require.NoError(t, err)
+
+	page, err := service.ListMessages(MessageFilter{Status: StatusDead})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 1)
+
+	c.now = c.now.Add(time.Minute)
+	replayed, err := service.Replay(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, replayed.Status)
+	assert.Zero(t, replayed.Attempts)
+
+	failing = false
+	processed, err := service.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	stored, err := repo.Get(message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDelivered, stored.Status)
+
+	_, err = service.Replay("inexistente")
+	assert.True(t, errors.Is(err, ErrMessageNotFound), "Replay inexistente: %v", err)
+}
+
+func TestListMessagesRejectsInvalidStatus(t *testing.T) {
+	service, _, _ := newTestService(t, DefaultBackoffPolicy)
+
+	_, err := service.ListMessages(MessageFilter{Status: "perdida"})
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "ListMessages: %v", err)
+}
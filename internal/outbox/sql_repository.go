@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"pag-simples/internal/database"
+	"pag-simples/internal/pagination"
+)
+
+const messageColumns = "seq, id, topic, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at"
+
+type SQLOutboxRepository struct {
+	db database.Executor
+}
+
+func NewSQLOutboxRepository(db database.Executor) *SQLOutboxRepository {
+	return &SQLOutboxRepository{
+		db: db,
+	}
+}
+
+func (r *SQLOutboxRepository) Add(message *Message) error {
+	err := r.db.QueryRow(
+		`INSERT INTO outbox_messages (id, topic, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING seq`,
+		message.ID, message.Topic, string(message.Payload), string(message.Status), message.Attempts, message.LastError,
+		message.NextAttemptAt.UnixMilli(), message.CreatedAt.UnixMilli(), message.UpdatedAt.UnixMilli(),
+	).Scan(&message.Seq)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar mensagem %s: %v", message.ID, err)
+	}
+	return nil
+}
+
+// Claim só fica com as mensagens cujo next_attempt_at ainda é o lido, o que
+// impede dois dispatchers de reservarem a mesma mensagem.
+func (r *SQLOutboxRepository) Claim(now time.Time, until time.Time, limit int) ([]Message, error) {
+	due, err := r.query(
+		"SELECT "+messageColumns+" FROM outbox_messages WHERE status = $1 AND next_attempt_at <= $2 ORDER BY seq LIMIT $3",
+		string(StatusPending), now.UnixMilli(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagens pendentes: %v", err)
+	}
+
+	claimed := []Message{}
+	for _, message := range due {
+		result, err := r.db.Exec(
+			"UPDATE outbox_messages SET next_attempt_at = $1 WHERE id = $2 AND status = $3 AND next_attempt_at = $4",
+			until.UnixMilli(), message.ID, string(StatusPending), message.NextAttemptAt.UnixMilli(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reservar mensagem %s: %v", message.ID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reservar mensagem %s: %v", message.ID, err)
+		}
+		if affected == 0 {
+			continue
+		}
+
+		message.NextAttemptAt = time.UnixMilli(until.UnixMilli())
+		claimed = append(claimed, message)
+	}
+	return claimed, nil
+}
+
+func (r *SQLOutboxRepository) Update(message *Message) error {
+	result, err := r.db.Exec(
+		"UPDATE outbox_messages SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, updated_at = $5 WHERE id = $6",
+		string(message.Status), message.Attempts, message.LastError, message.NextAttemptAt.UnixMilli(), message.UpdatedAt.UnixMilli(), message.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar mensagem %s: %v", message.ID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar mensagem %s: %v", message.ID, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, message.ID)
+	}
+	return nil
+}
+
+func (r *SQLOutboxRepository) Get(id string) (*Message, error) {
+	message, err := scanMessage(r.db.QueryRow("SELECT "+messageColumns+" FROM outbox_messages WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagem %s: %v", id, err)
+	}
+	return message, nil
+}
+
+func (r *SQLOutboxRepository) List(filter MessageFilter) ([]Message, string, error) {
+	before, err := pagination.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+arg(string(filter.Status)))
+	}
+	if before > 0 {
+		conditions = append(conditions, "seq < "+arg(before))
+	}
+
+	limit := pagination.NormalizeLimit(filter.Limit)
+	query := "SELECT " + messageColumns + " FROM outbox_messages WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY seq DESC LIMIT " + arg(limit+1)
+
+	messages, err := r.query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao listar mensagens: %v", err)
+	}
+
+	messages, next := pagination.Page(messages, limit, Message.position)
+	return messages, next, nil
+}
+
+func (r *SQLOutboxRepository) query(query string, args ...interface{}) ([]Message, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	return messages, rows.Err()
+}
+
+func scanMessage(row database.RowScanner) (*Message, error) {
+	var message Message
+	var payload, status string
+	var nextAttemptAt, createdAt, updatedAt int64
+	err := row.Scan(&message.Seq, &message.ID, &message.Topic, &payload, &status, &message.Attempts, &message.LastError, &nextAttemptAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	message.Payload = []byte(payload)
+	message.Status = Status(status)
+	message.NextAttemptAt = time.UnixMilli(nextAttemptAt)
+	message.CreatedAt = time.UnixMilli(createdAt)
+	message.UpdatedAt = time.UnixMilli(updatedAt)
+	return &message, nil
+}
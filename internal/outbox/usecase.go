@@ -0,0 +1,6 @@
+package outbox
+
+type OutboxUseCase interface {
+	ListMessages(filter MessageFilter) (*MessagePage, error)
+	Replay(id string) (*Message, error)
+}
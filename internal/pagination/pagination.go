@@ -0,0 +1,61 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"pag-simples/internal/apperrors"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = apperrors.New(apperrors.CodeInvalidRequest, "cursor inválido")
+
+// EncodeCursor torna opaca a posição usada para continuar a listagem.
+func EncodeCursor(position int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(position, 10)))
+}
+
+func DecodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+	}
+
+	position, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || position <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+	}
+	return position, nil
+}
+
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// Page corta a listagem, já ordenada da mais recente para a mais antiga, e
+// devolve o cursor da próxima página quando houver mais itens. position
+// informa a posição de cada item usada no cursor.
+func Page[T any](items []T, limit int, position func(T) int64) ([]T, string) {
+	limit = NormalizeLimit(limit)
+	if len(items) <= limit {
+		return items, ""
+	}
+
+	items = items[:limit]
+	return items, EncodeCursor(position(items[limit-1]))
+}
@@ -0,0 +1,44 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	position, err := DecodeCursor(EncodeCursor(42))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), position)
+
+	position, err = DecodeCursor("")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, cursor := range []string{"%%%", EncodeCursor(0), "YWJj"} {
+		_, err := DecodeCursor(cursor)
+		assert.True(t, errors.Is(err, ErrInvalidCursor), "%s: %v", cursor, err)
+	}
+}
+
+func TestNormalizeLimit(t *testing.T) {
+	assert.Equal(t, DefaultLimit, NormalizeLimit(0))
+	assert.Equal(t, 5, NormalizeLimit(5))
+	assert.Equal(t, MaxLimit, NormalizeLimit(1000))
+}
+
+func TestPage(t *testing.T) {
+	position := func(item int64) int64 { return item }
+
+	items, next := Page([]int64{5, 4, 3}, 3, position)
+	assert.Equal(t, []int64{5, 4, 3}, items)
+	assert.Empty(t, next)
+
+	items, next = Page([]int64{5, 4, 3}, 2, position)
+	assert.Equal(t, []int64{5, 4}, items)
+	assert.Equal(t, EncodeCursor(4), next)
+}
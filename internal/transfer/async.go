@@ -0,0 +1,279 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/pkg/authorization"
+	"pag-simples/pkg/webhook"
+
+	"github.com/shopspring/decimal"
+)
+
+var sendWebhook = webhook.Send
+
+type TransferRequest struct {
+	Value       decimal.Decimal `json:"value"`
+	Payer       int             `json:"payer"`
+	Payee       int             `json:"payee"`
+	CallbackURL string          `json:"callback_url,omitempty"`
+	// Metadata é preenchida pelo handler a partir da requisição HTTP.
+	Metadata authorization.Metadata `json:"-"`
+}
+
+// WorkerPool executa as transferências assíncronas com um número fixo de
+// workers e uma fila limitada. Os jobs recebem o contexto do pool, cancelado
+// quando Shutdown desiste de esperar.
+type WorkerPool struct {
+	mu     sync.RWMutex
+	jobs   chan func(ctx context.Context)
+	closed bool
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewWorkerPool(workers int, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := &WorkerPool{jobs: make(chan func(context.Context), queueSize), ctx: ctx, cancel: cancel}
+	for i := 0; i < workers; i++ {
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			for job := range pool.jobs {
+				job(pool.ctx)
+			}
+		}()
+	}
+	return pool
+}
+
+// Enqueue não bloqueia: devolve false quando a fila está cheia ou o pool já
+// foi fechado.
+func (p *WorkerPool) Enqueue(job func(ctx context.Context)) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close para de aceitar novos jobs e espera a fila ser esvaziada.
+func (p *WorkerPool) Close() {
+	p.Shutdown(context.Background())
+}
+
+// Shutdown para de aceitar novos jobs e espera a fila ser esvaziada até ctx
+// terminar. A partir daí o contexto dos jobs é cancelado e Shutdown espera só
+// que os workers o percebam.
+func (p *WorkerPool) Shutdown(ctx context.Context) {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Tempo de encerramento esgotado, cancelando as transferências assíncronas em andamento")
+	}
+	p.cancel()
+	<-done
+}
+
+func WithWorkerPool(pool *WorkerPool) Option {
+	return func(s *TransferService) {
+		s.workers = pool
+	}
+}
+
+// DefaultLease é o tempo em que uma transferência criada ou retomada por uma
+// instância fica fora do alcance do Resume das outras. Precisa cobrir a
+// espera na fila e a autorização, com as novas tentativas.
+const DefaultLease = 5 * time.Minute
+
+func WithLease(lease time.Duration) Option {
+	return func(s *TransferService) {
+		if lease > 0 {
+			s.lease = lease
+		}
+	}
+}
+
+// Submit grava a transferência como created e deixa a autorização e a
+// liquidação para o pool de workers. O resultado é consultado em
+// GET /transfers/{id} ou enviado para request.CallbackURL.
+func (s *TransferService) Submit(request TransferRequest) (*TransferDetails, error) {
+	if s.workers == nil {
+		return nil, apperrors.ErrAsyncUnavailable
+	}
+
+	if request.CallbackURL != "" {
+		if err := webhook.ValidateURL(request.CallbackURL); err != nil {
+			return nil, apperrors.New(apperrors.CodeInvalidRequest, "callback_url inválida").WithDetails(map[string]interface{}{
+				"callback_url": request.CallbackURL,
+				"reason":       err.Error(),
+			})
+		}
+	}
+
+	pending, err := s.prepare(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// A transação passa a ser alterada pelo worker assim que entra na fila.
+	details := &TransferDetails{
+		Transfer:     *pending.transfer,
+		Transactions: []Transaction{*pending.transaction},
+	}
+
+	if !s.workers.Enqueue(func(ctx context.Context) { s.run(ctx, pending) }) {
+		log.Printf("Fila de transferências cheia, transferência %s descartada", pending.transfer.ID)
+		return nil, s.fail(pending.transaction, StatusFailed, fmt.Errorf("%w: fila de transferências cheia", apperrors.ErrAsyncUnavailable))
+	}
+
+	log.Printf("Transferência %s enfileirada para processamento assíncrono", pending.transfer.ID)
+	return details, nil
+}
+
+// Resume trata as transferências que ficaram em created, authorizing ou
+// authorized sem ninguém cuidando delas: as de uma instância que caiu ou as
+// que ela não terminou antes de encerrar. Só são tratadas as que esta
+// instância consegue reservar, ou seja, aquelas cujo lease já venceu; as que
+// outra instância está processando ficam com ela. Com o pool de workers, as
+// reservadas voltam à fila e continuam do status em que pararam; sem ele, são
+// marcadas como failed, para que nenhuma fique sem desfecho. Devolve quantas
+// foram tratadas.
+func (s *TransferService) Resume() (int, error) {
+	now := time.Now()
+	transfers, err := s.transferRepo.ClaimPendingTransfers(s.instance, now, now.Add(s.lease))
+	if err != nil {
+		log.Printf("Erro ao buscar transferências pendentes: %v", err)
+		return 0, err
+	}
+
+	resumed := 0
+	for i := range transfers {
+		pending, err := s.reload(&transfers[i])
+		if err != nil {
+			log.Printf("Erro ao retomar transferência %s: %v", transfers[i].ID, err)
+			continue
+		}
+
+		if s.workers == nil {
+			s.fail(pending.transaction, StatusFailed, errInterrupted)
+			if pending.transfer.CallbackURL != "" {
+				s.notifyCallback(pending.transfer)
+			}
+			resumed++
+			continue
+		}
+
+		if !s.workers.Enqueue(func(ctx context.Context) { s.run(ctx, pending) }) {
+			log.Printf("Fila de transferências cheia, %d transferências pendentes ficaram para a próxima retomada", len(transfers)-i)
+			break
+		}
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+var errInterrupted = errors.New("transferência interrompida por uma parada do servidor")
+
+func (s *TransferService) reload(transfer *Transfer) (*pendingTransfer, error) {
+	transactions, err := s.transferRepo.GetTransactions(transfer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &pendingTransfer{transfer: transfer}
+	for i := range transactions {
+		if transactions[i].Status.pending() {
+			pending.transaction = &transactions[i]
+			break
+		}
+	}
+	if pending.transaction == nil {
+		return nil, fmt.Errorf("%w: transferência %s não está pendente", ErrInvalidTransition, transfer.ID)
+	}
+
+	if pending.payer, err = s.getParty(transfer.Payer, apperrors.ErrPayerNotFound); err != nil {
+		return nil, err
+	}
+	if pending.payee, err = s.getParty(transfer.Payee, apperrors.ErrPayeeNotFound); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func (s *TransferService) run(ctx context.Context, pending *pendingTransfer) {
+	// Com o pool cancelado, a transferência continua em created e é retomada,
+	// por esta ou outra instância, quando o lease vencer.
+	if ctx.Err() != nil {
+		log.Printf("Transferência assíncrona %s não iniciada antes do encerramento", pending.transfer.ID)
+		return
+	}
+
+	// A espera na fila pode ter passado do lease; se outra instância já
+	// retomou a transferência, ela fica com essa instância.
+	extended, err := s.transferRepo.ExtendLease(pending.transfer.ID, s.instance, time.Now().Add(s.lease))
+	if err != nil {
+		log.Printf("Erro ao renovar reserva da transferência assíncrona %s: %v", pending.transfer.ID, err)
+		return
+	}
+	if !extended {
+		log.Printf("Transferência assíncrona %s foi retomada por outra instância", pending.transfer.ID)
+		return
+	}
+
+	if err := s.process(ctx, pending); err != nil {
+		log.Printf("Transferência assíncrona %s terminou em %s: %v", pending.transfer.ID, pending.transaction.Status, err)
+	}
+
+	if pending.transfer.CallbackURL != "" {
+		s.notifyCallback(pending.transfer)
+	}
+}
+
+func (s *TransferService) notifyCallback(transfer *Transfer) {
+	details, err := s.GetTransfer(transfer.ID)
+	if err != nil {
+		log.Printf("Erro ao montar webhook da transferência %s: %v", transfer.ID, err)
+		return
+	}
+
+	if err := sendWebhook(transfer.CallbackURL, details); err != nil {
+		log.Printf("Falha ao enviar webhook da transferência %s: %v", transfer.ID, err)
+	}
+}
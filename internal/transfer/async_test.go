@@ -0,0 +1,399 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
+	"pag-simples/pkg/authorization"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type asyncFixture struct {
+	service       *TransferService
+	transferRepo  *MemoryTransferRepository
+	walletService *wallet.WalletService
+	pool          *WorkerPool
+	// peer cria outra instância do serviço sobre os mesmos repositórios,
+	// como uma segunda réplica da API ligada ao mesmo banco.
+	peer func(opts ...Option) *TransferService
+}
+
+func newAsyncFixture(t *testing.T, pool *WorkerPool) *asyncFixture {
+	userRepo := user.NewMemoryUserRepository()
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 1, FullName: "Cliente", Email: "cliente@email.com", DocumentNumber: "1", UserType: user.CommonUser}))
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 2, FullName: "Loja", Email: "loja@email.com", DocumentNumber: "2", UserType: user.Merchant}))
+
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	walletService := wallet.NewWalletService(ledgerRepo)
+	require.NoError(t, walletService.CreateWallet(1, decimal.NewFromInt(1000)))
+	require.NoError(t, walletService.CreateWallet(2, decimal.NewFromInt(50)))
+
+	authorizationService := new(MockAuthorizationService)
+	authorizationService.On("CheckAuthorization").Return(true, nil)
+
+	var opts []Option
+	if pool != nil {
+		opts = append(opts, WithWorkerPool(pool))
+	}
+
+	transferRepo := NewMemoryTransferRepository()
+	unitOfWork := NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository())
+	newService := func(opts ...Option) *TransferService {
+		return NewTransferService(user.NewUserService(userRepo), walletService, transferRepo, unitOfWork, authorizationService, opts...).(*TransferService)
+	}
+
+	return &asyncFixture{
+		service:       newService(opts...),
+		transferRepo:  transferRepo,
+		walletService: walletService,
+		pool:          pool,
+		peer:          newService,
+	}
+}
+
+func (f *asyncFixture) status(t *testing.T, transferID string) Status {
+	details, err := f.service.GetTransfer(transferID)
+	require.NoError(t, err)
+	require.Len(t, details.Transactions, 1)
+	return details.Transactions[0].Status
+}
+
+func stubWebhook(t *testing.T) <-chan *TransferDetails {
+	delivered := make(chan *TransferDetails, 10)
+	previous := sendWebhook
+	sendWebhook = func(url string, payload interface{}) error {
+		assert.Equal(t, "https://loja.example/webhook", url)
+		delivered <- payload.(*TransferDetails)
+		return nil
+	}
+	t.Cleanup(func() { sendWebhook = previous })
+	return delivered
+}
+
+func TestSubmitProcessesInBackground(t *testing.T) {
+	delivered := stubWebhook(t)
+	f := newAsyncFixture(t, NewWorkerPool(2, 10))
+
+	details, err := f.service.Submit(TransferRequest{
+		Value:       decimal.NewFromInt(200),
+		Payer:       1,
+		Payee:       2,
+		CallbackURL: "https://loja.example/webhook",
+	})
+	require.NoError(t, err)
+	require.Len(t, details.Transactions, 1)
+	assert.Equal(t, StatusCreated, details.Transactions[0].Status)
+	assert.Equal(t, "https://loja.example/webhook", details.CallbackURL)
+
+	f.pool.Close()
+
+	assert.Equal(t, StatusSettled, f.status(t, details.ID))
+	balance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(800)))
+
+	select {
+	case payload := <-delivered:
+		assert.Equal(t, details.ID, payload.ID)
+		assert.Equal(t, StatusSettled, payload.Transactions[0].Status)
+		assert.Len(t, payload.Transactions[0].History, 4)
+	case <-time.After(time.Second):
+		t.Fatal("webhook não enviado")
+	}
+}
+
+func TestSubmitRecordsFailures(t *testing.T) {
+	delivered := stubWebhook(t)
+	f := newAsyncFixture(t, NewWorkerPool(1, 10))
+
+	details, err := f.service.Submit(TransferRequest{
+		Value:       decimal.NewFromInt(5000),
+		Payer:       1,
+		Payee:       2,
+		CallbackURL: "https://loja.example/webhook",
+	})
+	require.NoError(t, err)
+
+	f.pool.Close()
+
+	assert.Equal(t, StatusFailed, f.status(t, details.ID))
+	payload := <-delivered
+	assert.Equal(t, StatusFailed, payload.Transactions[0].Status)
+}
+
+func TestSubmitValidatesBeforeRecording(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(1, 10))
+	defer f.pool.Close()
+
+	for _, callback := range []string{"ftp://loja.example", "http://169.254.169.254/latest/meta-data/", "http://127.0.0.1:8080/"} {
+		_, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(10), Payer: 1, Payee: 2, CallbackURL: callback})
+		assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "callback_url %s: %v", callback, err)
+	}
+
+	_, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(10), Payer: 2, Payee: 1})
+	assert.True(t, errors.Is(err, apperrors.ErrMerchantCannotPay), "lojista: %v", err)
+
+	assert.Empty(t, f.transferRepo.transfers)
+}
+
+func TestSubmitWithoutWorkerPool(t *testing.T) {
+	f := newAsyncFixture(t, nil)
+
+	_, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(10), Payer: 1, Payee: 2})
+	assert.True(t, errors.Is(err, apperrors.ErrAsyncUnavailable), "Submit: %v", err)
+
+	resumed, err := f.service.Resume()
+	require.NoError(t, err)
+	assert.Zero(t, resumed)
+}
+
+func TestSubmitFailsWhenQueueIsFull(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(1, 0))
+
+	release := make(chan struct{})
+	require.Eventually(t, func() bool {
+		return f.pool.Enqueue(func(context.Context) { <-release })
+	}, time.Second, time.Millisecond)
+
+	_, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(10), Payer: 1, Payee: 2})
+	assert.True(t, errors.Is(err, apperrors.ErrAsyncUnavailable), "Submit: %v", err)
+
+	close(release)
+	f.pool.Close()
+
+	require.Len(t, f.transferRepo.transactions, 1)
+	for _, transaction := range f.transferRepo.transactions {
+		assert.Equal(t, StatusFailed, transaction.Status)
+	}
+}
+
+func TestResumePendingTransfers(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(2, 10))
+
+	pending := &Transfer{ID: "t1", Value: decimal.NewFromInt(100), Payer: 1, Payee: 2, CreatedAt: time.Now()}
+	require.NoError(t, f.transferRepo.CreateTransfer(pending))
+	require.NoError(t, f.transferRepo.CreateTransaction(&Transaction{ID: "tx1", TransferID: "t1", Amount: pending.Value, Status: StatusCreated, CreatedAt: pending.CreatedAt}))
+
+	settled := &Transfer{ID: "t2", Value: decimal.NewFromInt(100), Payer: 1, Payee: 2, CreatedAt: time.Now()}
+	require.NoError(t, f.transferRepo.CreateTransfer(settled))
+	require.NoError(t, f.transferRepo.CreateTransaction(&Transaction{ID: "tx2", TransferID: "t2", Amount: settled.Value, Status: StatusSettled, CreatedAt: settled.CreatedAt}))
+
+	resumed, err := f.service.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, 1, resumed)
+
+	f.pool.Close()
+
+	assert.Equal(t, StatusSettled, f.status(t, "t1"))
+	balance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(900)))
+}
+
+// createInterrupted grava uma transferência de 100 do cliente para a loja
+// parada em status, como depois de uma queda do servidor.
+func (f *asyncFixture) createInterrupted(t *testing.T, id string, status Status) {
+	pending := &Transfer{ID: id, Value: decimal.NewFromInt(100), Payer: 1, Payee: 2, CreatedAt: time.Now()}
+	require.NoError(t, f.transferRepo.CreateTransfer(pending))
+	transaction := &Transaction{ID: "tx-" + id, TransferID: id, Amount: pending.Value, Status: StatusCreated, CreatedAt: pending.CreatedAt}
+	require.NoError(t, f.transferRepo.CreateTransaction(transaction))
+
+	from := StatusCreated
+	for _, to := range []Status{StatusAuthorizing, StatusAuthorized} {
+		if from == status {
+			return
+		}
+		require.NoError(t, f.transferRepo.UpdateTransactionStatus(&StatusChange{TransactionID: transaction.ID, From: from, To: to, CreatedAt: time.Now()}))
+		from = to
+	}
+}
+
+func TestResumeInterruptedTransfers(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(2, 10))
+	f.createInterrupted(t, "t1", StatusAuthorizing)
+	f.createInterrupted(t, "t2", StatusAuthorized)
+
+	resumed, err := f.service.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, 2, resumed)
+
+	f.pool.Close()
+
+	assert.Equal(t, StatusSettled, f.status(t, "t1"))
+	assert.Equal(t, StatusSettled, f.status(t, "t2"))
+	balance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(800)), "saldo: %s", balance)
+}
+
+func TestResumeWithoutWorkerPoolFailsInterrupted(t *testing.T) {
+	f := newAsyncFixture(t, nil)
+	f.createInterrupted(t, "t1", StatusCreated)
+	f.createInterrupted(t, "t2", StatusAuthorizing)
+	f.createInterrupted(t, "t3", StatusAuthorized)
+
+	resumed, err := f.service.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, 3, resumed)
+
+	for _, id := range []string{"t1", "t2", "t3"} {
+		details, err := f.service.GetTransfer(id)
+		require.NoError(t, err)
+		transaction := details.Transactions[0]
+		assert.Equal(t, StatusFailed, transaction.Status, id)
+		assert.Equal(t, errInterrupted.Error(), transaction.History[len(transaction.History)-1].Reason, id)
+	}
+
+	balance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(1000)), "saldo: %s", balance)
+
+	pending, err := f.transferRepo.ClaimPendingTransfers("outra", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+// gatedAuthorizer aprova cada transferência só depois de release ser fechado
+// e avisa em started quando uma autorização começa.
+type gatedAuthorizer struct {
+	started chan string
+	release chan struct{}
+}
+
+func (a gatedAuthorizer) CheckAuthorization(ctx context.Context, request authorization.Request) (*authorization.Decision, error) {
+	a.started <- request.TransferID
+	<-a.release
+	return &authorization.Decision{Authorized: true, ReasonCode: authorization.ReasonApproved}, nil
+}
+
+func TestResumeLeavesTransfersOfOtherInstances(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(1, 10))
+	gate := gatedAuthorizer{started: make(chan string, 1), release: make(chan struct{})}
+	f.service.authorizationService = gate
+
+	submitted, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(100), Payer: 1, Payee: 2})
+	require.NoError(t, err)
+	<-gate.started
+
+	// Uma réplica que sobe enquanto a primeira autoriza não mexe na
+	// transferência, com ou sem pool de workers.
+	otherPool := NewWorkerPool(1, 10)
+	for _, other := range []*TransferService{f.peer(), f.peer(WithWorkerPool(otherPool))} {
+		resumed, err := other.Resume()
+		require.NoError(t, err)
+		assert.Equal(t, 0, resumed)
+		assert.Equal(t, StatusAuthorizing, f.status(t, submitted.ID))
+	}
+	otherPool.Close()
+
+	close(gate.release)
+	f.pool.Close()
+
+	assert.Equal(t, StatusSettled, f.status(t, submitted.ID))
+	balance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(900)), "saldo: %s", balance)
+}
+
+func TestResumeTakesOverExpiredLeases(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(1, 10))
+	blocked := make(chan struct{})
+	require.True(t, f.pool.Enqueue(func(context.Context) { <-blocked }))
+
+	// A transferência fica na fila de a além do lease, e b, sem workers, a
+	// dá como interrompida.
+	a := f.peer(WithWorkerPool(f.pool), WithLease(20*time.Millisecond))
+	submitted, err := a.Submit(TransferRequest{Value: decimal.NewFromInt(100), Payer: 1, Payee: 2})
+	require.NoError(t, err)
+
+	b := f.peer()
+	resumed, err := b.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, 0, resumed, "lease de a ainda vale")
+
+	time.Sleep(30 * time.Millisecond)
+	resumed, err = b.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, 1, resumed)
+
+	// Quando o worker de a chega à transferência, ela já é de b.
+	close(blocked)
+	f.pool.Close()
+
+	assert.Equal(t, StatusFailed, f.status(t, submitted.ID))
+	balance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(1000)), "saldo: %s", balance)
+}
+
+func TestWorkerPoolRejectsAfterClose(t *testing.T) {
+	pool := NewWorkerPool(3, 10)
+
+	var mu sync.Mutex
+	done := 0
+	for i := 0; i < 10; i++ {
+		require.True(t, pool.Enqueue(func(context.Context) {
+			mu.Lock()
+			done++
+			mu.Unlock()
+		}))
+	}
+
+	pool.Close()
+	assert.Equal(t, 10, done)
+	assert.False(t, pool.Enqueue(func(context.Context) {}))
+	pool.Close()
+}
+
+// waitingAuthorizer só responde quando o contexto é cancelado.
+type waitingAuthorizer struct{}
+
+func (waitingAuthorizer) CheckAuthorization(ctx context.Context, request authorization.Request) (*authorization.Decision, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestExecuteStopsWhenContextIsCancelled(t *testing.T) {
+	f := newAsyncFixture(t, nil)
+	f.service.authorizationService = waitingAuthorizer{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := f.service.Execute(ctx, TransferRequest{Value: decimal.NewFromInt(10), Payer: 1, Payee: 2})
+	assert.True(t, errors.Is(err, apperrors.ErrAuthorizerUnavailable), "Execute: %v", err)
+
+	page, err := f.service.ListTransfers(TransferFilter{UserID: 1, Direction: Sent})
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 1)
+	assert.Equal(t, StatusFailed, f.status(t, page.Transfers[0].ID))
+}
+
+func TestWorkerPoolShutdownCancelsInFlightJobs(t *testing.T) {
+	f := newAsyncFixture(t, NewWorkerPool(1, 10))
+	f.service.authorizationService = waitingAuthorizer{}
+
+	first, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(10), Payer: 1, Payee: 2})
+	require.NoError(t, err)
+	second, err := f.service.Submit(TransferRequest{Value: decimal.NewFromInt(10), Payer: 1, Payee: 2})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	f.pool.Shutdown(ctx)
+
+	// A primeira esperava o autorizador e falhou; a segunda nem começou e fica
+	// para a próxima retomada.
+	assert.Equal(t, StatusFailed, f.status(t, first.ID))
+	assert.Equal(t, StatusCreated, f.status(t, second.ID))
+}
@@ -0,0 +1,125 @@
+package transfer
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"pag-simples/internal/apperrors"
+
+	"pag-simples/internal/wallet"
+	"pag-simples/pkg/authorization"
+
+	"github.com/shopspring/decimal"
+)
+
+type authorizationHistory struct {
+	transferRepo  TransferRepository
+	walletService wallet.WalletUseCase
+}
+
+// NewAuthorizationHistory expõe ao motor de regras apenas o que já foi
+// liquidado: transferências em created ou recusadas não contam.
+func NewAuthorizationHistory(transferRepo TransferRepository, walletService wallet.WalletUseCase) authorization.History {
+	return &authorizationHistory{
+		transferRepo:  transferRepo,
+		walletService: walletService,
+	}
+}
+
+// LimitChecker reaplica os limites de volume no momento da liquidação.
+type LimitChecker interface {
+	CheckLimits(request authorization.Request) (*authorization.Decision, error)
+}
+
+// WithLimitChecker confere os limites de volume de novo em settle, depois de
+// ler a versão da wallet do pagador. A conferência feita na autorização só
+// vale dentro deste processo; a de settle vale entre réplicas, porque uma
+// liquidação concorrente do mesmo pagador muda a versão e força outra volta.
+func WithLimitChecker(checker LimitChecker) Option {
+	return func(s *TransferService) {
+		s.limits = checker
+	}
+}
+
+func (s *TransferService) checkLimits(pending *pendingTransfer) error {
+	if s.limits == nil {
+		return nil
+	}
+
+	decision, err := s.limits.CheckLimits(pending.authorizationRequest())
+	if err != nil {
+		log.Printf("Falha ao conferir os limites da transferência %s: %v", pending.transfer.ID, err)
+		return fmt.Errorf("falha ao conferir os limites de volume: %v", err)
+	}
+
+	if !decision.Authorized {
+		log.Printf("Transferência %s excede os limites na liquidação: %s (%s)", pending.transfer.ID, decision.ReasonCode, decision.ReferenceID)
+		return apperrors.ErrAuthorizationDenied.WithDetails(map[string]interface{}{
+			"reason_code":  decision.ReasonCode,
+			"reference_id": decision.ReferenceID,
+			"explanation":  decision.Explanation,
+		})
+	}
+
+	return nil
+}
+
+// Spent soma os pagamentos do extrato, que só recebe lançamentos liquidados.
+// Estornos feitos pelo pagador e ajustes de saldo, que não têm transferência,
+// não contam.
+func (h *authorizationHistory) Spent(payerID int, since time.Time) (decimal.Decimal, error) {
+	spent := decimal.Zero
+	filter := wallet.StatementFilter{Direction: wallet.Sent, From: since}
+	for {
+		statement, err := h.walletService.GetStatement(payerID, filter)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		for _, line := range statement.Lines {
+			if line.Reference == "" {
+				continue
+			}
+			transfer, err := h.transferRepo.GetTransfer(line.Reference)
+			if err != nil {
+				return decimal.Zero, err
+			}
+			if transfer.RefundOf != "" {
+				continue
+			}
+			spent = spent.Sub(line.Amount)
+		}
+		if statement.NextCursor == "" {
+			return spent, nil
+		}
+		filter.Cursor = statement.NextCursor
+	}
+}
+
+func (h *authorizationHistory) HasPaid(payerID int, payeeID int) (bool, error) {
+	filter := TransferFilter{UserID: payerID, Direction: Sent}
+	for {
+		transfers, next, err := h.transferRepo.ListTransfers(filter)
+		if err != nil {
+			return false, err
+		}
+		for _, transfer := range transfers {
+			if transfer.Payee != payeeID || transfer.RefundOf != "" {
+				continue
+			}
+			transactions, err := h.transferRepo.GetTransactions(transfer.ID)
+			if err != nil {
+				return false, err
+			}
+			for _, transaction := range transactions {
+				if transaction.Status.settled() {
+					return true, nil
+				}
+			}
+		}
+		if next == "" {
+			return false, nil
+		}
+		filter.Cursor = next
+	}
+}
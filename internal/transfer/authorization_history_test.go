@@ -0,0 +1,167 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
+	"pag-simples/pkg/authorization"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationHistory(t *testing.T) {
+	f := newMemoryRefundFixture(t)
+	history := NewAuthorizationHistory(f.transferRepo, f.walletService)
+	start := time.Now()
+
+	paid, err := history.HasPaid(1, 2)
+	require.NoError(t, err)
+	assert.False(t, paid)
+
+	original := f.purchase(t, 200)
+	f.purchase(t, 50)
+
+	spent, err := history.Spent(1, start)
+	require.NoError(t, err)
+	assert.True(t, spent.Equal(decimal.NewFromInt(250)), "Spent: %s", spent)
+
+	spent, err = history.Spent(1, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, spent.IsZero(), "Spent: %s", spent)
+
+	paid, err = history.HasPaid(1, 2)
+	require.NoError(t, err)
+	assert.True(t, paid)
+
+	_, err = f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(80), Reason: ReasonCustomerReturn})
+	require.NoError(t, err)
+
+	paid, err = history.HasPaid(2, 1)
+	require.NoError(t, err)
+	assert.False(t, paid, "estornos não contam como transferência ao cliente")
+
+	spent, err = history.Spent(2, start)
+	require.NoError(t, err)
+	assert.True(t, spent.IsZero(), "estornos não contam como gasto do lojista: %s", spent)
+
+	require.NoError(t, f.walletService.UpdateBalance(1, decimal.NewFromInt(-30)))
+	spent, err = history.Spent(1, start)
+	require.NoError(t, err)
+	assert.True(t, spent.Equal(decimal.NewFromInt(250)), "ajustes de saldo não contam: %s", spent)
+}
+
+// slowApproval aprova depois de uma pausa, alargando a janela entre a leitura
+// do histórico e a liquidação.
+type slowApproval struct{}
+
+func (slowApproval) CheckAuthorization(ctx context.Context, request authorization.Request) (*authorization.Decision, error) {
+	time.Sleep(10 * time.Millisecond)
+	return &authorization.Decision{Authorized: true, ReasonCode: authorization.ReasonApproved}, nil
+}
+
+func TestConcurrentTransfersRespectDailyLimit(t *testing.T) {
+	userRepo := user.NewMemoryUserRepository()
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	transferRepo := NewMemoryTransferRepository()
+	unitOfWork := NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository())
+
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 1, FullName: "Cliente", Email: "cliente@email.com", DocumentNumber: "1", UserType: user.CommonUser}))
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 2, FullName: "Loja", Email: "loja@email.com", DocumentNumber: "2", UserType: user.Merchant}))
+	walletService := wallet.NewWalletService(ledgerRepo)
+	require.NoError(t, walletService.CreateWallet(1, decimal.NewFromInt(1000)))
+	require.NoError(t, walletService.CreateWallet(2, decimal.Zero))
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"daily_limit": "100"}`), 0o600))
+	rules, err := authorization.NewRulesEngine(path, NewAuthorizationHistory(transferRepo, walletService))
+	require.NoError(t, err)
+
+	service := NewTransferService(user.NewUserService(userRepo), walletService, transferRepo, unitOfWork, authorization.Chain(rules, slowApproval{}))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	settled := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := service.Transfer(decimal.NewFromInt(30), 1, 2)
+			if err == nil {
+				mu.Lock()
+				settled++
+				mu.Unlock()
+				return
+			}
+			assert.True(t, errors.Is(err, apperrors.ErrAuthorizationDenied), "Transfer: %v", err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, settled)
+	balance, err := walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(910)), "saldo: %s", balance)
+}
+
+// Duas instâncias com o mesmo banco não compartilham o bloqueio por pagador;
+// o limite é garantido pela conferência em settle.
+func TestConcurrentInstancesRespectDailyLimit(t *testing.T) {
+	userRepo := user.NewMemoryUserRepository()
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	transferRepo := NewMemoryTransferRepository()
+	unitOfWork := NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository())
+
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 1, FullName: "Cliente", Email: "cliente@email.com", DocumentNumber: "1", UserType: user.CommonUser}))
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 2, FullName: "Loja", Email: "loja@email.com", DocumentNumber: "2", UserType: user.Merchant}))
+	walletService := wallet.NewWalletService(ledgerRepo)
+	require.NoError(t, walletService.CreateWallet(1, decimal.NewFromInt(1000)))
+	require.NoError(t, walletService.CreateWallet(2, decimal.Zero))
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"daily_limit": "100"}`), 0o600))
+	rules, err := authorization.NewRulesEngine(path, NewAuthorizationHistory(transferRepo, walletService))
+	require.NoError(t, err)
+
+	newInstance := func() TransferUsecase {
+		return NewTransferService(user.NewUserService(userRepo), walletService, transferRepo, unitOfWork, authorization.Chain(rules, slowApproval{}),
+			WithLimitChecker(rules), WithRetryPolicy(RetryPolicy{MaxAttempts: 10, Backoff: time.Millisecond}))
+	}
+	instances := []TransferUsecase{newInstance(), newInstance()}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	settled := 0
+	for i := 0; i < 8; i++ {
+		service := instances[i%len(instances)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := service.Transfer(decimal.NewFromInt(30), 1, 2)
+			if err == nil {
+				mu.Lock()
+				settled++
+				mu.Unlock()
+				return
+			}
+			assert.True(t, errors.Is(err, apperrors.ErrAuthorizationDenied), "Transfer: %v", err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, settled)
+	balance, err := walletService.GetBalance(1)
+	require.NoError(t, err)
+	assert.True(t, balance.Equal(decimal.NewFromInt(910)), "saldo: %s", balance)
+}
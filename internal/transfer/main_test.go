@@ -0,0 +1,11 @@
+package transfer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	sendWebhook = func(string, interface{}) error { return nil }
+	os.Exit(m.Run())
+}
@@ -0,0 +1,99 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/pkg/notification"
+
+	"github.com/shopspring/decimal"
+)
+
+// NotificationTopic é o tópico do outbox com as notificações aos usuários.
+const NotificationTopic = "notification"
+
+type NotificationSender interface {
+	Send(recipient notification.Recipient, event notification.Event, data notification.Data) error
+}
+
+func newNotification(event notification.Event, recipient *user.User, counterparty *user.User, amount decimal.Decimal) notification.Notification {
+	return notification.Notification{
+		Event:  event,
+		UserID: recipient.ID,
+		Data: notification.Data{
+			Counterparty: counterparty.FullName,
+			Amount:       amount,
+		},
+	}
+}
+
+// WithLowBalanceThreshold avisa o usuário quando uma saída leva o saldo da
+// wallet para baixo de threshold. Zero desativa o aviso.
+func WithLowBalanceThreshold(threshold decimal.Decimal) Option {
+	return func(s *TransferService) {
+		s.lowBalanceThreshold = threshold
+	}
+}
+
+// lowBalanceNotifications só avisa quando o saldo cruza o limite, e não a
+// cada saída de quem já está abaixo dele.
+func (s *TransferService) lowBalanceNotifications(owner *user.User, balance decimal.Decimal, debit decimal.Decimal) []notification.Notification {
+	if !s.lowBalanceThreshold.IsPositive() {
+		return nil
+	}
+
+	remaining := balance.Sub(debit)
+	if balance.LessThan(s.lowBalanceThreshold) || !remaining.LessThan(s.lowBalanceThreshold) {
+		return nil
+	}
+
+	return []notification.Notification{{
+		Event:  notification.EventLowBalance,
+		UserID: owner.ID,
+		Data:   notification.Data{Amount: remaining},
+	}}
+}
+
+// enqueueNotifications grava as notificações no outbox da mesma unidade de
+// trabalho da movimentação: elas só existem se a movimentação for confirmada.
+func enqueueNotifications(tx Tx, notifications ...notification.Notification) error {
+	for _, n := range notifications {
+		message, err := outbox.NewMessage(NotificationTopic, n)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Outbox().Add(message); err != nil {
+			log.Printf("Falha ao registrar notificação para o usuário %d: %v", n.UserID, err)
+			return fmt.Errorf("falha ao registrar a notificação: %v", err)
+		}
+	}
+	return nil
+}
+
+// NotificationHandler entrega as mensagens de NotificationTopic pelo canal
+// que o usuário preferir no momento da entrega.
+func NotificationHandler(userUsecase user.UserUsecase, sender NotificationSender) outbox.Handler {
+	return func(message outbox.Message) error {
+		var n notification.Notification
+		if err := json.Unmarshal(message.Payload, &n); err != nil {
+			return fmt.Errorf("notificação %s inválida: %v", message.ID, err)
+		}
+
+		recipient, err := userUsecase.GetUser(n.UserID)
+		if err != nil {
+			log.Printf("Erro ao buscar usuário %d da notificação %s: %v", n.UserID, message.ID, err)
+			return err
+		}
+
+		if err := sender.Send(recipient.Recipient(), n.Event, n.Data); err != nil {
+			return fmt.Errorf("falha ao enviar a notificação: %v", err)
+		}
+
+		log.Printf("Notificação %s (%s) enviada para o usuário %d", message.ID, n.Event, n.UserID)
+		return nil
+	}
+}
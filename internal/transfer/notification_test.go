@@ -0,0 +1,141 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/pkg/notification"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentNotification struct {
+	recipient notification.Recipient
+	event     notification.Event
+	data      notification.Data
+}
+
+type fakeSender struct {
+	sent []sentNotification
+	err  error
+}
+
+func (s *fakeSender) Send(recipient notification.Recipient, event notification.Event, data notification.Data) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, sentNotification{recipient, event, data})
+	return nil
+}
+
+func pendingNotifications(t *testing.T, repo outbox.OutboxRepository) []notification.Notification {
+	messages, _, err := repo.List(outbox.MessageFilter{Status: outbox.StatusPending})
+	require.NoError(t, err)
+
+	notifications := make([]notification.Notification, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		assert.Equal(t, NotificationTopic, messages[i].Topic)
+
+		var n notification.Notification
+		require.NoError(t, json.Unmarshal(messages[i].Payload, &n))
+		notifications = append(notifications, n)
+	}
+	return notifications
+}
+
+func assertNotification(t *testing.T, n notification.Notification, event notification.Event, userID int, counterparty string, amount int64) {
+	assert.Equal(t, event, n.Event)
+	assert.Equal(t, userID, n.UserID)
+	assert.Equal(t, counterparty, n.Data.Counterparty)
+	assert.True(t, n.Data.Amount.Equal(decimal.NewFromInt(amount)), "valor: %s", n.Data.Amount)
+}
+
+func TestTransferAndRefundWriteNotificationsToOutbox(t *testing.T) {
+	userRepo := user.NewMemoryUserRepository()
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	transferRepo := NewMemoryTransferRepository()
+	outboxRepo := outbox.NewMemoryOutboxRepository()
+	f := newRefundFixture(t, userRepo, ledgerRepo, transferRepo, NewMemoryUnitOfWork(transferRepo, ledgerRepo, outboxRepo))
+
+	original := f.purchase(t, 200)
+	notifications := pendingNotifications(t, outboxRepo)
+	require.Len(t, notifications, 2)
+	assertNotification(t, notifications[0], notification.EventTransferSent, 1, "Loja", 200)
+	assertNotification(t, notifications[1], notification.EventTransferReceived, 2, "Cliente", 200)
+
+	err := f.service.Transfer(decimal.NewFromInt(5000), 1, 2)
+	require.Error(t, err)
+	assert.Len(t, pendingNotifications(t, outboxRepo), 2, "transferência recusada não gera notificação")
+
+	_, err = f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(50), Reason: ReasonOther})
+	require.NoError(t, err)
+
+	notifications = pendingNotifications(t, outboxRepo)
+	require.Len(t, notifications, 3)
+	assertNotification(t, notifications[2], notification.EventRefundIssued, 1, "Loja", 50)
+}
+
+func TestLowBalanceNotification(t *testing.T) {
+	userRepo := user.NewMemoryUserRepository()
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	transferRepo := NewMemoryTransferRepository()
+	outboxRepo := outbox.NewMemoryOutboxRepository()
+	f := newRefundFixture(t, userRepo, ledgerRepo, transferRepo, NewMemoryUnitOfWork(transferRepo, ledgerRepo, outboxRepo))
+	f.service.(*TransferService).lowBalanceThreshold = decimal.NewFromInt(300)
+
+	f.purchase(t, 600)
+	assert.Len(t, pendingNotifications(t, outboxRepo), 2, "saldo de 400 continua acima do limite")
+
+	f.purchase(t, 200)
+	notifications := pendingNotifications(t, outboxRepo)
+	require.Len(t, notifications, 5)
+	assert.Equal(t, notification.EventLowBalance, notifications[4].Event)
+	assert.Equal(t, 1, notifications[4].UserID)
+	assert.True(t, notifications[4].Data.Amount.Equal(decimal.NewFromInt(200)), "saldo: %s", notifications[4].Data.Amount)
+
+	f.purchase(t, 50)
+	assert.Len(t, pendingNotifications(t, outboxRepo), 7, "quem já está abaixo do limite não é avisado de novo")
+}
+
+func TestNotificationHandler(t *testing.T) {
+	userRepo := user.NewMemoryUserRepository()
+	require.NoError(t, userRepo.SaveUser(&user.User{
+		ID:                  1,
+		FullName:            "João",
+		Email:               "joao@email.com",
+		Phone:               "+5511999990000",
+		NotificationChannel: notification.ChannelSMS,
+	}))
+
+	sender := &fakeSender{}
+	handler := NotificationHandler(user.NewUserService(userRepo), sender)
+
+	message, err := outbox.NewMessage(NotificationTopic, notification.Notification{
+		Event:  notification.EventTransferReceived,
+		UserID: 1,
+		Data:   notification.Data{Counterparty: "Maria", Amount: decimal.NewFromInt(10)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler(*message))
+
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, notification.ChannelSMS, sender.sent[0].recipient.Channel)
+	assert.Equal(t, "+5511999990000", sender.sent[0].recipient.Phone)
+	assert.Equal(t, notification.EventTransferReceived, sender.sent[0].event)
+	assert.Equal(t, "Maria", sender.sent[0].data.Counterparty)
+
+	sender.err = errors.New("serviço fora do ar")
+	assert.EqualError(t, handler(*message), "falha ao enviar a notificação: serviço fora do ar")
+
+	missing, err := outbox.NewMessage(NotificationTopic, notification.Notification{Event: notification.EventTransferSent, UserID: 42})
+	require.NoError(t, err)
+	assert.True(t, errors.Is(handler(*missing), user.ErrUserNotFound))
+
+	assert.Error(t, handler(outbox.Message{ID: "m1", Topic: NotificationTopic, Payload: []byte("{")}))
+}
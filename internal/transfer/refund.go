@@ -0,0 +1,204 @@
+package transfer
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/user"
+	"pag-simples/pkg/notification"
+
+	"github.com/shopspring/decimal"
+)
+
+type RefundReason string
+
+const (
+	ReasonCustomerReturn     RefundReason = "customer_return"
+	ReasonDuplicate          RefundReason = "duplicate"
+	ReasonFraud              RefundReason = "fraud"
+	ReasonServiceNotRendered RefundReason = "service_not_rendered"
+	ReasonOther              RefundReason = "other"
+)
+
+var refundReasons = []RefundReason{ReasonCustomerReturn, ReasonDuplicate, ReasonFraud, ReasonServiceNotRendered, ReasonOther}
+
+func (r RefundReason) Valid() bool {
+	for _, reason := range refundReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// RefundRequest com Value zerado estorna todo o valor ainda não estornado.
+type RefundRequest struct {
+	Value  decimal.Decimal `json:"value"`
+	Reason RefundReason    `json:"reason"`
+}
+
+func (s *TransferService) Refund(transferID string, request RefundRequest) (*Transfer, error) {
+	log.Printf("Iniciando estorno de %s da transferência %s", request.Value.String(), transferID)
+
+	if !request.Reason.Valid() {
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "motivo de estorno inválido").WithDetails(map[string]interface{}{
+			"reason":  request.Reason,
+			"allowed": refundReasons,
+		})
+	}
+
+	if request.Value.IsNegative() {
+		return nil, apperrors.ErrInvalidAmount.WithDetails(map[string]interface{}{"reason": "valor do estorno não pode ser negativo"})
+	}
+
+	original, err := s.transferRepo.GetTransfer(transferID)
+	if err != nil {
+		log.Printf("Erro ao buscar transferência %s para estorno: %v", transferID, err)
+		return nil, err
+	}
+
+	if original.RefundOf != "" {
+		log.Printf("Erro: transferência %s já é um estorno de %s", transferID, original.RefundOf)
+		return nil, apperrors.ErrTransferNotRefundable.WithDetails(map[string]interface{}{"reason": "a transferência já é um estorno", "refund_of": original.RefundOf})
+	}
+
+	payer, err := s.getParty(original.Payee, apperrors.ErrPayerNotFound)
+	if err != nil {
+		log.Printf("Erro ao buscar usuário %d para o estorno: %v", original.Payee, err)
+		return nil, err
+	}
+
+	payee, err := s.getParty(original.Payer, apperrors.ErrPayeeNotFound)
+	if err != nil {
+		log.Printf("Erro ao buscar usuário %d para o estorno: %v", original.Payer, err)
+		return nil, err
+	}
+
+	refund := &Transfer{
+		ID:        generateID(),
+		Value:     request.Value,
+		Payer:     original.Payee,
+		Payee:     original.Payer,
+		CreatedAt: time.Now(),
+		RefundOf:  original.ID,
+		Reason:    request.Reason,
+	}
+
+	err = s.settle(refund, func(refund *Transfer) error {
+		return s.tryRefund(original, refund, request.Value, payer, payee)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Estorno %s de %.2f da transferência %s realizado com sucesso", refund.ID, refund.Value.InexactFloat64(), original.ID)
+	return refund, nil
+}
+
+// tryRefund lê a wallet antes dos estornos já feitos: um estorno concorrente
+// que passe entre as leituras altera a versão da wallet e força nova tentativa.
+func (s *TransferService) tryRefund(original *Transfer, refund *Transfer, requested decimal.Decimal, sender *user.User, recipient *user.User) error {
+	payer, err := s.walletService.GetWallet(refund.Payer)
+	if err != nil {
+		log.Printf("Falha ao obter wallet do recebedor %d: %v", refund.Payer, err)
+		return fmt.Errorf("falha ao obter o saldo do recebedor: %w", err)
+	}
+
+	refunds, err := s.transferRepo.GetRefunds(original.ID)
+	if err != nil {
+		log.Printf("Falha ao buscar estornos da transferência %s: %v", original.ID, err)
+		return fmt.Errorf("falha ao buscar estornos da transferência: %v", err)
+	}
+
+	transactions, err := s.transferRepo.GetTransactions(original.ID)
+	if err != nil {
+		log.Printf("Falha ao buscar transações da transferência %s: %v", original.ID, err)
+		return fmt.Errorf("falha ao buscar transações da transferência: %v", err)
+	}
+
+	for _, transaction := range transactions {
+		if !transaction.Status.Refundable() {
+			log.Printf("Erro: transação %s da transferência %s está %s", transaction.ID, original.ID, transaction.Status)
+			return apperrors.ErrTransferNotRefundable.WithDetails(map[string]interface{}{"reason": "transferência não concluída", "status": transaction.Status})
+		}
+	}
+
+	remaining := original.Value
+	for _, previous := range refunds {
+		remaining = remaining.Sub(previous.Value)
+	}
+
+	if !remaining.IsPositive() {
+		return apperrors.ErrTransferNotRefundable.WithDetails(map[string]interface{}{"reason": "transferência já totalmente estornada"})
+	}
+
+	value := requested
+	if value.IsZero() {
+		value = remaining
+	}
+
+	if value.GreaterThan(remaining) {
+		return apperrors.ErrRefundExceedsAmount.WithDetails(map[string]interface{}{
+			"requested":  value.String(),
+			"refundable": remaining.String(),
+		})
+	}
+
+	if payer.Balance.LessThan(value) {
+		log.Printf("Erro: saldo insuficiente para o estorno de %.2f de %d para %d", value.InexactFloat64(), refund.Payer, refund.Payee)
+		return insufficientFunds(refund.Payer, value)
+	}
+
+	refund.Value = value
+	status := StatusPartiallyRefunded
+	if value.Equal(remaining) {
+		status = StatusRefunded
+		if refund.Reason == ReasonFraud || refund.Reason == ReasonDuplicate {
+			status = StatusReversed
+		}
+	}
+
+	changes := make([]*StatusChange, 0, len(transactions))
+	for i := range transactions {
+		changes = append(changes, newStatusChange(&transactions[i], status, string(refund.Reason)))
+	}
+
+	transaction := &Transaction{
+		ID:         generateID(),
+		TransferID: refund.ID,
+		Amount:     value,
+		Status:     StatusSettled,
+		CreatedAt:  time.Now(),
+	}
+
+	return s.unitOfWork.Do(func(tx Tx) error {
+		if err := tx.Transfers().CreateTransfer(refund); err != nil {
+			log.Printf("Falha ao salvar o estorno de %.2f: %v", value.InexactFloat64(), err)
+			return fmt.Errorf("falha ao salvar o estorno: %v", err)
+		}
+
+		if err := tx.Wallets().Transfer(payer, refund.Payee, value, refund.ID); err != nil {
+			log.Printf("Falha ao movimentar saldo de %d para %d: %v", refund.Payer, refund.Payee, err)
+			return fmt.Errorf("falha ao movimentar o saldo de %d para %d: %w", refund.Payer, refund.Payee, err)
+		}
+
+		if err := tx.Transfers().CreateTransaction(transaction); err != nil {
+			log.Printf("Falha ao salvar transação do estorno de %.2f: %v", value.InexactFloat64(), err)
+			return fmt.Errorf("falha ao salvar a transação: %v", err)
+		}
+
+		for _, change := range changes {
+			if err := tx.Transfers().UpdateTransactionStatus(change); err != nil {
+				log.Printf("Falha ao atualizar transação %s: %v", change.TransactionID, err)
+				return fmt.Errorf("falha ao atualizar a transação: %w", err)
+			}
+		}
+
+		notifications := append([]notification.Notification{
+			newNotification(notification.EventRefundIssued, recipient, sender, value),
+		}, s.lowBalanceNotifications(sender, payer.Balance, value)...)
+		return enqueueNotifications(tx, notifications...)
+	})
+}
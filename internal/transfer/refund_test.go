@@ -0,0 +1,221 @@
+package transfer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type refundFixture struct {
+	service       TransferUsecase
+	walletService *wallet.WalletService
+	transferRepo  TransferRepository
+}
+
+func newMemoryRefundFixture(t *testing.T) *refundFixture {
+	userRepo := user.NewMemoryUserRepository()
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	transferRepo := NewMemoryTransferRepository()
+	return newRefundFixture(t, userRepo, ledgerRepo, transferRepo, NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository()))
+}
+
+func newSQLRefundFixture(t *testing.T, driver string) *refundFixture {
+	db := databasetest.Open(t, driver)
+	return newRefundFixture(t, user.NewSQLUserRepository(db), ledger.NewSQLLedgerRepository(db), NewSQLTransferRepository(db), NewSQLUnitOfWork(db))
+}
+
+func newRefundFixture(t *testing.T, userRepo user.UserRepository, ledgerRepo ledger.LedgerRepository, transferRepo TransferRepository, unitOfWork UnitOfWork) *refundFixture {
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 1, FullName: "Cliente", Email: "cliente@email.com", DocumentNumber: "1", UserType: user.CommonUser}))
+	require.NoError(t, userRepo.SaveUser(&user.User{ID: 2, FullName: "Loja", Email: "loja@email.com", DocumentNumber: "2", UserType: user.Merchant}))
+
+	walletService := wallet.NewWalletService(ledgerRepo)
+	require.NoError(t, walletService.CreateWallet(1, decimal.NewFromInt(1000)))
+	require.NoError(t, walletService.CreateWallet(2, decimal.NewFromInt(50)))
+
+	authorizationService := new(MockAuthorizationService)
+	authorizationService.On("CheckAuthorization").Return(true, nil)
+
+	return &refundFixture{
+		service:       NewTransferService(user.NewUserService(userRepo), walletService, transferRepo, unitOfWork, authorizationService),
+		walletService: walletService,
+		transferRepo:  transferRepo,
+	}
+}
+
+func (f *refundFixture) purchase(t *testing.T, value int64) *Transfer {
+	require.NoError(t, f.service.Transfer(decimal.NewFromInt(value), 1, 2))
+
+	page, err := f.service.ListTransfers(TransferFilter{UserID: 1, Direction: Sent, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 1)
+	return &page.Transfers[0].Transfer
+}
+
+func (f *refundFixture) assertBalances(t *testing.T, customer int64, merchant int64) {
+	customerBalance, err := f.walletService.GetBalance(1)
+	require.NoError(t, err)
+	merchantBalance, err := f.walletService.GetBalance(2)
+	require.NoError(t, err)
+	assert.True(t, customerBalance.Equal(decimal.NewFromInt(customer)), "cliente: %s", customerBalance)
+	assert.True(t, merchantBalance.Equal(decimal.NewFromInt(merchant)), "lojista: %s", merchantBalance)
+}
+
+func (f *refundFixture) assertStatus(t *testing.T, transferID string, status Status) {
+	details, err := f.service.GetTransfer(transferID)
+	require.NoError(t, err)
+	require.Len(t, details.Transactions, 1)
+	assert.Equal(t, status, details.Transactions[0].Status)
+}
+
+func TestRefund(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		runRefundTests(t, newMemoryRefundFixture)
+	})
+
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			runRefundTests(t, func(t *testing.T) *refundFixture {
+				return newSQLRefundFixture(t, driver)
+			})
+		})
+	}
+}
+
+func runRefundTests(t *testing.T, newFixture func(t *testing.T) *refundFixture) {
+	t.Run("FullRefund", func(t *testing.T) {
+		f := newFixture(t)
+		original := f.purchase(t, 200)
+
+		refund, err := f.service.Refund(original.ID, RefundRequest{Reason: ReasonCustomerReturn})
+		require.NoError(t, err)
+
+		assert.True(t, refund.Value.Equal(decimal.NewFromInt(200)))
+		assert.Equal(t, original.ID, refund.RefundOf)
+		assert.Equal(t, ReasonCustomerReturn, refund.Reason)
+		assert.Equal(t, 2, refund.Payer)
+		assert.Equal(t, 1, refund.Payee)
+		f.assertBalances(t, 1000, 50)
+		f.assertStatus(t, original.ID, StatusRefunded)
+		f.assertStatus(t, refund.ID, StatusSettled)
+
+		stored, err := f.transferRepo.GetTransfer(refund.ID)
+		require.NoError(t, err)
+		assert.Equal(t, original.ID, stored.RefundOf)
+		assert.Equal(t, ReasonCustomerReturn, stored.Reason)
+
+		_, err = f.service.Refund(original.ID, RefundRequest{Reason: ReasonCustomerReturn})
+		assert.True(t, errors.Is(err, apperrors.ErrTransferNotRefundable), "segundo estorno: %v", err)
+	})
+
+	t.Run("PartialRefunds", func(t *testing.T) {
+		f := newFixture(t)
+		original := f.purchase(t, 200)
+
+		_, err := f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(50), Reason: ReasonOther})
+		require.NoError(t, err)
+		f.assertBalances(t, 850, 200)
+		f.assertStatus(t, original.ID, StatusPartiallyRefunded)
+
+		_, err = f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(151), Reason: ReasonOther})
+		assert.True(t, errors.Is(err, apperrors.ErrRefundExceedsAmount), "excedente: %v", err)
+
+		rest, err := f.service.Refund(original.ID, RefundRequest{Reason: ReasonOther})
+		require.NoError(t, err)
+		assert.True(t, rest.Value.Equal(decimal.NewFromInt(150)))
+		f.assertBalances(t, 1000, 50)
+		f.assertStatus(t, original.ID, StatusRefunded)
+	})
+
+	t.Run("FraudReversesTransfer", func(t *testing.T) {
+		f := newFixture(t)
+		original := f.purchase(t, 200)
+
+		_, err := f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(50), Reason: ReasonFraud})
+		require.NoError(t, err)
+		f.assertStatus(t, original.ID, StatusPartiallyRefunded)
+
+		_, err = f.service.Refund(original.ID, RefundRequest{Reason: ReasonFraud})
+		require.NoError(t, err)
+		f.assertBalances(t, 1000, 50)
+		f.assertStatus(t, original.ID, StatusReversed)
+	})
+
+	t.Run("RejectsUnsettledTransfer", func(t *testing.T) {
+		f := newFixture(t)
+		err := f.service.Transfer(decimal.NewFromInt(5000), 1, 2)
+		assert.True(t, errors.Is(err, apperrors.ErrInsufficientFunds), "Transfer: %v", err)
+
+		page, err := f.service.ListTransfers(TransferFilter{UserID: 1, Direction: Sent, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page.Transfers, 1)
+		failed := page.Transfers[0].Transfer
+		f.assertStatus(t, failed.ID, StatusFailed)
+
+		_, err = f.service.Refund(failed.ID, RefundRequest{Reason: ReasonOther})
+		assert.True(t, errors.Is(err, apperrors.ErrTransferNotRefundable), "Refund: %v", err)
+		f.assertBalances(t, 1000, 50)
+	})
+
+	t.Run("RespectsPayeeBalance", func(t *testing.T) {
+		f := newFixture(t)
+		original := f.purchase(t, 200)
+		require.NoError(t, f.walletService.UpdateBalance(2, decimal.NewFromInt(-200)))
+
+		_, err := f.service.Refund(original.ID, RefundRequest{Reason: ReasonFraud})
+		assert.True(t, errors.Is(err, apperrors.ErrInsufficientFunds), "Refund: %v", err)
+		f.assertBalances(t, 800, 50)
+		f.assertStatus(t, original.ID, StatusSettled)
+	})
+
+	t.Run("RejectsInvalidRequests", func(t *testing.T) {
+		f := newFixture(t)
+		original := f.purchase(t, 200)
+
+		_, err := f.service.Refund(original.ID, RefundRequest{Reason: "arrependimento"})
+		assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "motivo: %v", err)
+
+		_, err = f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(-1), Reason: ReasonOther})
+		assert.True(t, errors.Is(err, apperrors.ErrInvalidAmount), "valor: %v", err)
+
+		_, err = f.service.Refund("inexistente", RefundRequest{Reason: ReasonOther})
+		assert.True(t, errors.Is(err, apperrors.ErrTransferNotFound), "transferência: %v", err)
+
+		refund, err := f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(10), Reason: ReasonOther})
+		require.NoError(t, err)
+
+		_, err = f.service.Refund(refund.ID, RefundRequest{Reason: ReasonOther})
+		assert.True(t, errors.Is(err, apperrors.ErrTransferNotRefundable), "estorno do estorno: %v", err)
+	})
+
+	t.Run("ConcurrentRefundsNeverExceedOriginal", func(t *testing.T) {
+		f := newFixture(t)
+		original := f.purchase(t, 100)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f.service.Refund(original.ID, RefundRequest{Value: decimal.NewFromInt(30), Reason: ReasonDuplicate})
+			}()
+		}
+		wg.Wait()
+
+		refunds, err := f.transferRepo.GetRefunds(original.ID)
+		require.NoError(t, err)
+		assert.Len(t, refunds, 3)
+		f.assertBalances(t, 990, 60)
+		f.assertStatus(t, original.ID, StatusPartiallyRefunded)
+	})
+}
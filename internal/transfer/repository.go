@@ -1,16 +1,49 @@
 package transfer
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/pagination"
+)
+
+var (
+	ErrTransferNotFound         = apperrors.ErrTransferNotFound
+	ErrTransferAlreadyExists    = errors.New("transferência já registrada")
+	ErrTransactionAlreadyExists = errors.New("transação já registrada")
+	ErrTransactionNotFound      = errors.New("transação não encontrada")
+)
 
 type TransferRepository interface {
 	CreateTransfer(transfer *Transfer) error
 	CreateTransaction(transaction *Transaction) error
-	UpdateTransactionStatus(transactionID string, status string) error
+	UpdateTransactionStatus(change *StatusChange) error
+	GetStatusHistory(transferID string) ([]StatusChange, error)
+	GetTransfer(transferID string) (*Transfer, error)
+	GetTransactions(transferID string) ([]Transaction, error)
+	ListTransfers(filter TransferFilter) ([]Transfer, string, error)
+	GetRefunds(transferID string) ([]Transfer, error)
+	// ClaimPendingTransfers reserva para owner, até until, as transferências
+	// com transação em created, authorizing ou authorized cujo lease venceu em
+	// now, e as devolve em ordem de criação. As que outra instância ainda
+	// está processando ficam de fora.
+	ClaimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, error)
+	// ExtendLease prolonga até until o lease de owner sobre a transferência e
+	// devolve false se outra instância a reservou nesse meio-tempo, ou se ela
+	// não existe.
+	ExtendLease(transferID string, owner string, until time.Time) (bool, error)
 }
 
 type MemoryTransferRepository struct {
+	mu           sync.RWMutex
 	transfers    map[string]Transfer
 	transactions map[string]Transaction
+	history      []StatusChange
+	seq          int64
 }
 
 func NewMemoryTransferRepository() *MemoryTransferRepository {
@@ -21,21 +54,326 @@ func NewMemoryTransferRepository() *MemoryTransferRepository {
 }
 
 func (r *MemoryTransferRepository) CreateTransfer(transfer *Transfer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createTransfer(transfer)
+}
+
+func (r *MemoryTransferRepository) CreateTransaction(transaction *Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createTransaction(transaction)
+}
+
+func (r *MemoryTransferRepository) UpdateTransactionStatus(change *StatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.updateTransactionStatus(change)
+}
+
+func (r *MemoryTransferRepository) GetStatusHistory(transferID string) ([]StatusChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getStatusHistory(transferID), nil
+}
+
+func (r *MemoryTransferRepository) GetTransfer(transferID string) (*Transfer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getTransfer(transferID)
+}
+
+func (r *MemoryTransferRepository) GetTransactions(transferID string) ([]Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getTransactions(transferID), nil
+}
+
+func (r *MemoryTransferRepository) ListTransfers(filter TransferFilter) ([]Transfer, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listTransfers(filter)
+}
+
+func (r *MemoryTransferRepository) GetRefunds(transferID string) ([]Transfer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getRefunds(transferID), nil
+}
+
+func (r *MemoryTransferRepository) ClaimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claimed, _ := r.claimPendingTransfers(owner, now, until)
+	return claimed, nil
+}
+
+func (r *MemoryTransferRepository) ExtendLease(transferID string, owner string, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, extended := r.extendLease(transferID, owner, until)
+	return extended, nil
+}
+
+// Begin bloqueia o repositório até Commit ou Rollback. Dentro da transação,
+// use apenas o MemoryTransferTx retornado para evitar deadlock.
+func (r *MemoryTransferRepository) Begin() *MemoryTransferTx {
+	r.mu.Lock()
+	return &MemoryTransferTx{repo: r}
+}
+
+func (r *MemoryTransferRepository) createTransfer(transfer *Transfer) error {
+	if _, exists := r.transfers[transfer.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrTransferAlreadyExists, transfer.ID)
+	}
+	r.seq++
+	transfer.Seq = r.seq
 	r.transfers[transfer.ID] = *transfer
 	return nil
 }
 
-func (r *MemoryTransferRepository) CreateTransaction(transaction *Transaction) error {
-	r.transactions[transaction.ID] = *transaction
+func (r *MemoryTransferRepository) createTransaction(transaction *Transaction) error {
+	change := initialChange(transaction)
+	if err := change.Validate(); err != nil {
+		return err
+	}
+	if _, exists := r.transactions[transaction.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrTransactionAlreadyExists, transaction.ID)
+	}
+	stored := *transaction
+	stored.History = nil
+	r.transactions[transaction.ID] = stored
+	r.history = append(r.history, *change)
 	return nil
 }
 
-func (r *MemoryTransferRepository) UpdateTransactionStatus(transactionID string, status string) error {
-	transaction, exists := r.transactions[transactionID]
+func (r *MemoryTransferRepository) getTransfer(transferID string) (*Transfer, error) {
+	transfer, exists := r.transfers[transferID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, transferID)
+	}
+	return &transfer, nil
+}
+
+func (r *MemoryTransferRepository) getTransactions(transferID string) []Transaction {
+	transactions := []Transaction{}
+	for _, transaction := range r.transactions {
+		if transaction.TransferID == transferID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sortTransactions(transactions)
+	return transactions
+}
+
+func (r *MemoryTransferRepository) getRefunds(transferID string) []Transfer {
+	refunds := []Transfer{}
+	for _, transfer := range r.transfers {
+		if transfer.RefundOf == transferID {
+			refunds = append(refunds, transfer)
+		}
+	}
+	sort.Slice(refunds, func(i, j int) bool {
+		return refunds[i].Seq < refunds[j].Seq
+	})
+	return refunds
+}
+
+// claimPendingTransfers devolve também as transferências como estavam antes
+// da reserva, para que MemoryTransferTx possa desfazê-la.
+func (r *MemoryTransferRepository) claimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, []Transfer) {
+	pending := []Transfer{}
+	for _, transaction := range r.transactions {
+		transfer := r.transfers[transaction.TransferID]
+		if transaction.Status.pending() && !transfer.LeaseUntil.After(now) {
+			pending = append(pending, transfer)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Seq < pending[j].Seq
+	})
+
+	claimed := make([]Transfer, len(pending))
+	for i, transfer := range pending {
+		transfer.LeaseOwner = owner
+		transfer.LeaseUntil = until
+		r.transfers[transfer.ID] = transfer
+		claimed[i] = transfer
+	}
+	return claimed, pending
+}
+
+func (r *MemoryTransferRepository) extendLease(transferID string, owner string, until time.Time) (Transfer, bool) {
+	transfer, exists := r.transfers[transferID]
+	if !exists || transfer.LeaseOwner != owner {
+		return transfer, false
+	}
+
+	previous := transfer
+	transfer.LeaseUntil = until
+	r.transfers[transferID] = transfer
+	return previous, true
+}
+
+func (r *MemoryTransferRepository) listTransfers(filter TransferFilter) ([]Transfer, string, error) {
+	before, err := pagination.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	transfers := []Transfer{}
+	for _, transfer := range r.transfers {
+		if before > 0 && transfer.Seq >= before {
+			continue
+		}
+		if filter.matches(&transfer) {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].Seq > transfers[j].Seq
+	})
+	transfers, next := pagination.Page(transfers, filter.Limit, Transfer.position)
+	return transfers, next, nil
+}
+
+func (r *MemoryTransferRepository) updateTransactionStatus(change *StatusChange) error {
+	if err := change.Validate(); err != nil {
+		return err
+	}
+
+	transaction, exists := r.transactions[change.TransactionID]
 	if !exists {
-		return fmt.Errorf("transaction not found")
+		return fmt.Errorf("%w: %s", ErrTransactionNotFound, change.TransactionID)
+	}
+	if transaction.Status != change.From {
+		return fmt.Errorf("%w: transação %s está em %s, não em %s", ErrInvalidTransition, change.TransactionID, transaction.Status, change.From)
+	}
+
+	transaction.Status = change.To
+	if change.Decision != nil {
+		transaction.Authorization = change.Decision
+	}
+	r.transactions[change.TransactionID] = transaction
+	r.history = append(r.history, *change)
+	return nil
+}
+
+func (r *MemoryTransferRepository) getStatusHistory(transferID string) []StatusChange {
+	history := []StatusChange{}
+	for _, change := range r.history {
+		if r.transactions[change.TransactionID].TransferID == transferID {
+			history = append(history, change)
+		}
+	}
+	return history
+}
+
+type MemoryTransferTx struct {
+	repo *MemoryTransferRepository
+	undo []func()
+	done bool
+}
+
+func (t *MemoryTransferTx) CreateTransfer(transfer *Transfer) error {
+	if err := t.repo.createTransfer(transfer); err != nil {
+		return err
 	}
-	transaction.Status = status
-	r.transactions[transactionID] = transaction
+	t.undo = append(t.undo, func() { delete(t.repo.transfers, transfer.ID) })
 	return nil
 }
+
+func (t *MemoryTransferTx) CreateTransaction(transaction *Transaction) error {
+	if err := t.repo.createTransaction(transaction); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() {
+		delete(t.repo.transactions, transaction.ID)
+		t.repo.history = t.repo.history[:len(t.repo.history)-1]
+	})
+	return nil
+}
+
+func (t *MemoryTransferTx) UpdateTransactionStatus(change *StatusChange) error {
+	decision := t.repo.transactions[change.TransactionID].Authorization
+	if err := t.repo.updateTransactionStatus(change); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() {
+		transaction := t.repo.transactions[change.TransactionID]
+		transaction.Status = change.From
+		transaction.Authorization = decision
+		t.repo.transactions[change.TransactionID] = transaction
+		t.repo.history = t.repo.history[:len(t.repo.history)-1]
+	})
+	return nil
+}
+
+func (t *MemoryTransferTx) GetStatusHistory(transferID string) ([]StatusChange, error) {
+	return t.repo.getStatusHistory(transferID), nil
+}
+
+func (t *MemoryTransferTx) GetTransfer(transferID string) (*Transfer, error) {
+	return t.repo.getTransfer(transferID)
+}
+
+func (t *MemoryTransferTx) GetTransactions(transferID string) ([]Transaction, error) {
+	return t.repo.getTransactions(transferID), nil
+}
+
+func (t *MemoryTransferTx) ListTransfers(filter TransferFilter) ([]Transfer, string, error) {
+	return t.repo.listTransfers(filter)
+}
+
+func (t *MemoryTransferTx) GetRefunds(transferID string) ([]Transfer, error) {
+	return t.repo.getRefunds(transferID), nil
+}
+
+func (t *MemoryTransferTx) ClaimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, error) {
+	claimed, previous := t.repo.claimPendingTransfers(owner, now, until)
+	t.undo = append(t.undo, func() {
+		for _, transfer := range previous {
+			t.repo.transfers[transfer.ID] = transfer
+		}
+	})
+	return claimed, nil
+}
+
+func (t *MemoryTransferTx) ExtendLease(transferID string, owner string, until time.Time) (bool, error) {
+	previous, extended := t.repo.extendLease(transferID, owner, until)
+	if extended {
+		t.undo = append(t.undo, func() { t.repo.transfers[transferID] = previous })
+	}
+	return extended, nil
+}
+
+func (t *MemoryTransferTx) Commit() {
+	if t.done {
+		return
+	}
+	t.done = true
+	t.repo.mu.Unlock()
+}
+
+func (t *MemoryTransferTx) Rollback() {
+	if t.done {
+		return
+	}
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	t.done = true
+	t.repo.mu.Unlock()
+}
+
+func sortTransactions(transactions []Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+}
+
+// position é a posição da transferência no cursor da listagem.
+func (t Transfer) position() int64 {
+	return t.Seq
+}
@@ -0,0 +1,25 @@
+package transfer_test
+
+import (
+	"testing"
+
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/transfer"
+	"pag-simples/internal/transfer/transfertest"
+)
+
+func TestMemoryTransferRepository(t *testing.T) {
+	transfertest.RunTransferRepositoryTests(t, func(t *testing.T) transfer.TransferRepository {
+		return transfer.NewMemoryTransferRepository()
+	})
+}
+
+func TestSQLTransferRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			transfertest.RunTransferRepositoryTests(t, func(t *testing.T) transfer.TransferRepository {
+				return transfer.NewSQLTransferRepository(databasetest.Open(t, driver))
+			})
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package transfer
+
+import "time"
+
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     10 * time.Millisecond,
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	return p.Backoff * time.Duration(attempt)
+}
+
+type Option func(*TransferService)
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *TransferService) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		s.retryPolicy = policy
+	}
+}
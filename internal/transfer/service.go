@@ -1,11 +1,13 @@
 package transfer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 
+	"pag-simples/internal/apperrors"
 	"pag-simples/internal/user"
 	"pag-simples/internal/wallet"
 	"pag-simples/pkg/authorization"
@@ -15,144 +17,464 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-var mu sync.Mutex
-
 type TransferService struct {
 	userUsecase          user.UserUsecase
 	walletService        wallet.WalletUseCase
 	transferRepo         TransferRepository
+	unitOfWork           UnitOfWork
 	authorizationService authorization.AuthorizationService
+	locker               *wallet.Locker
+	payers               *wallet.Locker
+	retryPolicy          RetryPolicy
+	workers              *WorkerPool
+	lowBalanceThreshold  decimal.Decimal
+	limits               LimitChecker
+	// instance identifica este processo no lease das transferências.
+	instance string
+	lease    time.Duration
 }
 
 func NewTransferService(
 	userUsecase user.UserUsecase,
 	walletService wallet.WalletUseCase,
 	transferRepo TransferRepository,
+	unitOfWork UnitOfWork,
 	authorizationService authorization.AuthorizationService,
+	opts ...Option,
 ) TransferUsecase {
-	return &TransferService{
+	service := &TransferService{
 		userUsecase:          userUsecase,
 		walletService:        walletService,
 		transferRepo:         transferRepo,
+		unitOfWork:           unitOfWork,
 		authorizationService: authorizationService,
+		locker:               wallet.NewLocker(),
+		payers:               wallet.NewLocker(),
+		retryPolicy:          DefaultRetryPolicy,
+		instance:             generateID(),
+		lease:                DefaultLease,
+	}
+
+	for _, opt := range opts {
+		opt(service)
 	}
+
+	return service
 }
 
 func (s *TransferService) Transfer(value decimal.Decimal, payerID int, payeeID int) error {
-	mu.Lock()
-	defer mu.Unlock()
+	return s.Execute(context.Background(), TransferRequest{Value: value, Payer: payerID, Payee: payeeID})
+}
+
+// Execute é a versão síncrona de Submit: processa a transferência antes de
+// retornar. Cancelar ctx interrompe a autorização, e a transferência fica
+// registrada como failed.
+func (s *TransferService) Execute(ctx context.Context, request TransferRequest) error {
+	pending, err := s.prepare(request)
+	if err != nil {
+		return err
+	}
+
+	return s.process(ctx, pending)
+}
+
+// pendingTransfer é uma transferência já gravada como created, pronta para
+// autorização e liquidação.
+type pendingTransfer struct {
+	transfer    *Transfer
+	transaction *Transaction
+	payer       *user.User
+	payee       *user.User
+	// metadata não é persistida: transferências retomadas após um restart
+	// chegam ao autorizador sem ela.
+	metadata authorization.Metadata
+}
+
+func (p *pendingTransfer) authorizationRequest() authorization.Request {
+	return authorization.Request{
+		TransferID: p.transfer.ID,
+		Payer:      authorization.Party{ID: p.payer.ID, Type: string(p.payer.UserType)},
+		Payee:      authorization.Party{ID: p.payee.ID, Type: string(p.payee.UserType)},
+		Amount:     p.transfer.Value,
+		Metadata:   p.metadata,
+	}
+}
 
+// prepare valida a requisição e grava a transferência antes da autorização,
+// para que falhas posteriores fiquem registradas em vez de desaparecerem.
+func (s *TransferService) prepare(request TransferRequest) (*pendingTransfer, error) {
+	value, payerID, payeeID := request.Value, request.Payer, request.Payee
 	log.Printf("Iniciando transferência de %.2f de %d para %d", value.InexactFloat64(), payerID, payeeID)
 
-	payer, err := s.userUsecase.GetUser(payerID)
+	if !value.IsPositive() {
+		log.Printf("Erro: valor %s inválido para a transferência de %d para %d", value.String(), payerID, payeeID)
+		return nil, apperrors.ErrInvalidAmount.WithDetails(map[string]interface{}{"reason": "valor da transferência deve ser positivo"})
+	}
+
+	if payerID == payeeID {
+		log.Printf("Erro: transferência de %d para si mesmo", payerID)
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "pagador e recebedor devem ser diferentes").WithDetails(map[string]interface{}{
+			"payer": payerID,
+			"payee": payeeID,
+		})
+	}
+
+	payer, err := s.getParty(payerID, apperrors.ErrPayerNotFound)
 	if err != nil {
 		log.Printf("Erro ao encontrar pagador %d: %v", payerID, err)
-		return fmt.Errorf("pagador não encontrado: %v", err)
+		return nil, err
 	}
 
-	payee, err := s.userUsecase.GetUser(payeeID)
+	payee, err := s.getParty(payeeID, apperrors.ErrPayeeNotFound)
 	if err != nil {
 		log.Printf("Erro ao encontrar recebedor %d: %v", payeeID, err)
-		return fmt.Errorf("recebedor não encontrado: %v", err)
+		return nil, err
 	}
 
-	if payer.UserType == "merchant" {
+	if payer.UserType == user.Merchant {
 		log.Printf("Erro: usuário %d é um lojista e não pode realizar transferência", payerID)
-		return fmt.Errorf("um lojista não pode realizar transferências")
+		return nil, apperrors.ErrMerchantCannotPay
 	}
 
-	payerBalance, err := s.walletService.GetBalance(payerID)
-	if err != nil {
-		log.Printf("Falha ao obter saldo do pagador %d: %v", payerID, err)
-		return fmt.Errorf("falha ao obter o saldo do pagador: %v", err)
+	transfer := &Transfer{
+		ID:          generateID(),
+		Value:       value,
+		Payer:       payerID,
+		Payee:       payeeID,
+		CreatedAt:   time.Now(),
+		CallbackURL: request.CallbackURL,
+	}
+	transfer.LeaseOwner, transfer.LeaseUntil = s.instance, transfer.CreatedAt.Add(s.lease)
+	transaction := &Transaction{
+		ID:         generateID(),
+		TransferID: transfer.ID,
+		Amount:     value,
+		Status:     StatusCreated,
+		CreatedAt:  transfer.CreatedAt,
+	}
+
+	if err := s.record(transfer, transaction); err != nil {
+		return nil, err
 	}
 
-	payeeBalance, err := s.walletService.GetBalance(payeeID)
+	return &pendingTransfer{
+		transfer:    transfer,
+		transaction: transaction,
+		payer:       payer,
+		payee:       payee,
+		metadata:    request.Metadata,
+	}, nil
+}
+
+// getParty busca um dos lados da transferência. Só user.ErrUserNotFound vira
+// notFound; outras falhas, como a do banco, seguem como erro interno.
+func (s *TransferService) getParty(userID int, notFound error) (*user.User, error) {
+	party, err := s.userUsecase.GetUser(userID)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, fmt.Errorf("%w: %v", notFound, err)
+	}
 	if err != nil {
-		log.Printf("Falha ao obter saldo do recebedor %d: %v", payeeID, err)
-		return fmt.Errorf("falha ao obter o saldo do recebedor: %v", err)
+		return nil, fmt.Errorf("erro ao buscar usuário %d: %w", userID, err)
 	}
+	return party, nil
+}
 
-	if payerBalance.LessThan(value) {
-		log.Printf("Erro: saldo insuficiente para a transferência de %.2f de %d para %d", value.InexactFloat64(), payerID, payeeID)
-		return fmt.Errorf("saldo insuficiente para a transferência")
+// process conduz uma transferência gravada até settled, ou até o status de
+// falha correspondente ao erro. Transferências retomadas por Resume continuam
+// do status em que pararam.
+//
+// As transferências de um mesmo pagador passam por aqui uma de cada vez, da
+// autorização à liquidação: os limites diários e mensais das regras somam só
+// o que já foi liquidado, e duas autorizações simultâneas veriam o mesmo total.
+// O bloqueio só vale neste processo e evita recusas desnecessárias; entre
+// réplicas, quem garante os limites é a conferência de WithLimitChecker em
+// settle. O bloqueio é separado do de settle, que é tomado por dentro dele.
+func (s *TransferService) process(ctx context.Context, pending *pendingTransfer) error {
+	transfer, transaction := pending.transfer, pending.transaction
+	value, payerID, payeeID := transfer.Value, transfer.Payer, transfer.Payee
+
+	unlock := s.payers.Lock(payerID)
+	defer unlock()
+
+	if transaction.Status == StatusCreated {
+		if err := s.checkBalance(value, payerID, payeeID); err != nil {
+			return s.fail(transaction, StatusFailed, err)
+		}
+
+		if err := s.transition(transaction, StatusAuthorizing, ""); err != nil {
+			return err
+		}
+	}
+
+	// Uma transação retomada em authorizing é autorizada de novo: o
+	// autorizador responde a mesma decisão para o mesmo transfer_id.
+	if transaction.Status == StatusAuthorizing {
+		if err := s.authorize(ctx, pending); err != nil {
+			return err
+		}
 	}
 
-	authorized, err := s.authorizationService.CheckAuthorization()
+	err := s.settle(transfer, func(transfer *Transfer) error {
+		return s.trySettle(pending)
+	})
 	if err != nil {
-		log.Printf("Falha na autorização: %v", err)
-		return fmt.Errorf("falha na autorização: %v", err)
+		return s.fail(transaction, StatusFailed, err)
 	}
 
-	if !authorized {
-		log.Printf("Falha na autorização da transferência de %.2f de %d para %d", value.InexactFloat64(), payerID, payeeID)
-		return fmt.Errorf("transferência não autorizada")
+	log.Printf("Transferência de %.2f realizada com sucesso de %d para %d", value.InexactFloat64(), payerID, payeeID)
+	return nil
+}
+
+// authorize leva a transação de authorizing a authorized, ou a declined ou
+// failed.
+func (s *TransferService) authorize(ctx context.Context, pending *pendingTransfer) error {
+	transfer, transaction := pending.transfer, pending.transaction
+	value, payerID, payeeID := transfer.Value, transfer.Payer, transfer.Payee
+
+	decision, err := s.authorizationService.CheckAuthorization(ctx, pending.authorizationRequest())
+	if err != nil {
+		log.Printf("Falha na autorização: %v", err)
+		return s.fail(transaction, StatusFailed, fmt.Errorf("%w: %v", apperrors.ErrAuthorizerUnavailable, err))
 	}
 
-	transfer := &Transfer{
-		ID:    generateID(),
-		Value: value,
-		Payer: payerID,
-		Payee: payeeID,
+	if !decision.Authorized {
+		log.Printf("Falha na autorização da transferência de %.2f de %d para %d: %s (%s)", value.InexactFloat64(), payerID, payeeID, decision.ReasonCode, decision.ReferenceID)
+		declined := newStatusChange(transaction, StatusDeclined, apperrors.ErrAuthorizationDenied.Error())
+		declined.Decision = decision
+		if err := s.apply(transaction, declined); err != nil {
+			log.Printf("Transação %s não pôde ser marcada como %s: %v", transaction.ID, StatusDeclined, err)
+		}
+		return apperrors.ErrAuthorizationDenied.WithDetails(map[string]interface{}{
+			"reason_code":  decision.ReasonCode,
+			"reference_id": decision.ReferenceID,
+			"explanation":  decision.Explanation,
+		})
 	}
 
-	err = s.transferRepo.CreateTransfer(transfer)
+	authorized := newStatusChange(transaction, StatusAuthorized, "")
+	authorized.Decision = decision
+	return s.apply(transaction, authorized)
+}
+
+func (s *TransferService) GetTransfer(transferID string) (*TransferDetails, error) {
+	transfer, err := s.transferRepo.GetTransfer(transferID)
 	if err != nil {
-		log.Printf("Falha ao salvar a transferência de %.2f: %v", value.InexactFloat64(), err)
-		return fmt.Errorf("falha ao salvar a transferência: %v", err)
+		log.Printf("Erro ao buscar transferência %s: %v", transferID, err)
+		return nil, err
 	}
 
-	err = s.walletService.UpdateBalance(payerID, payerBalance.Sub(value))
+	transactions, err := s.transferRepo.GetTransactions(transferID)
 	if err != nil {
-		log.Printf("Falha ao atualizar saldo do pagador %d: %v", payerID, err)
-		return fmt.Errorf("falha ao atualizar o saldo do pagador %d: %v", payerID, err)
+		log.Printf("Erro ao buscar transações da transferência %s: %v", transferID, err)
+		return nil, err
 	}
 
-	err = s.walletService.UpdateBalance(payeeID, payeeBalance.Add(value))
+	history, err := s.transferRepo.GetStatusHistory(transferID)
 	if err != nil {
-		log.Printf("Falha ao atualizar saldo do recebedor %d: %v", payeeID, err)
-		return fmt.Errorf("falha ao atualizar o saldo do recebedor %d: %v", payeeID, err)
+		log.Printf("Erro ao buscar histórico da transferência %s: %v", transferID, err)
+		return nil, err
 	}
 
-	transaction := &Transaction{
-		ID:         generateID(),
-		TransferID: transfer.ID,
-		Amount:     value,
-		Status:     "sucesso",
-		CreatedAt:  time.Now(),
+	for i := range transactions {
+		for _, change := range history {
+			if change.TransactionID == transactions[i].ID {
+				transactions[i].History = append(transactions[i].History, change)
+			}
+		}
+	}
+
+	return &TransferDetails{
+		Transfer:     *transfer,
+		Transactions: transactions,
+	}, nil
+}
+
+func (s *TransferService) GetStatusHistory(transferID string) ([]StatusChange, error) {
+	if _, err := s.transferRepo.GetTransfer(transferID); err != nil {
+		log.Printf("Erro ao buscar transferência %s: %v", transferID, err)
+		return nil, err
 	}
 
-	err = s.transferRepo.CreateTransaction(transaction)
+	return s.transferRepo.GetStatusHistory(transferID)
+}
+
+func (s *TransferService) ListTransfers(filter TransferFilter) (*TransferPage, error) {
+	if !filter.Direction.Valid() {
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "direção inválida").WithDetails(map[string]interface{}{
+			"direction": filter.Direction,
+		})
+	}
+
+	if _, err := s.userUsecase.GetUser(filter.UserID); err != nil {
+		log.Printf("Erro ao encontrar usuário %d: %v", filter.UserID, err)
+		return nil, err
+	}
+
+	transfers, nextCursor, err := s.transferRepo.ListTransfers(filter)
 	if err != nil {
-		log.Printf("Falha ao salvar transação de %.2f: %v", value.InexactFloat64(), err)
-		return fmt.Errorf("falha ao salvar a transação: %v", err)
+		log.Printf("Erro ao listar transferências do usuário %d: %v", filter.UserID, err)
+		return nil, err
 	}
 
-	log.Printf("Transferência de %.2f realizada com sucesso de %d para %d", value.InexactFloat64(), payerID, payeeID)
+	page := &TransferPage{
+		Transfers:  make([]UserTransfer, 0, len(transfers)),
+		NextCursor: nextCursor,
+	}
+	for i := range transfers {
+		page.Transfers = append(page.Transfers, UserTransfer{
+			Transfer:  transfers[i],
+			Direction: transfers[i].directionFor(filter.UserID),
+		})
+	}
+	return page, nil
+}
+
+func (s *TransferService) checkBalance(value decimal.Decimal, payerID int, payeeID int) error {
+	payerBalance, err := s.walletService.GetBalance(payerID)
+	if err != nil {
+		log.Printf("Falha ao obter saldo do pagador %d: %v", payerID, err)
+		return fmt.Errorf("falha ao obter o saldo do pagador: %w", err)
+	}
 
-	go s.notifyUser(payer, fmt.Sprintf("Transferência de %.2f para %s foi realizada com sucesso", value.InexactFloat64(), payee.FullName))
-	go s.notifyUser(payee, fmt.Sprintf("Você recebeu %.2f de %s", value.InexactFloat64(), payer.FullName))
+	if payerBalance.LessThan(value) {
+		log.Printf("Erro: saldo insuficiente para a transferência de %.2f de %d para %d", value.InexactFloat64(), payerID, payeeID)
+		return insufficientFunds(payerID, value)
+	}
 
 	return nil
 }
 
-func generateID() string {
-	newUUID := uuid.New()
-	return newUUID.String()
+// settle executa try com as wallets envolvidas bloqueadas neste processo;
+// conflitos de versão vindos de outras réplicas são repetidos conforme a
+// RetryPolicy.
+func (s *TransferService) settle(transfer *Transfer, try func(*Transfer) error) error {
+	unlock := s.locker.Lock(transfer.Payer, transfer.Payee)
+	defer unlock()
+
+	for attempt := 1; ; attempt++ {
+		err := try(transfer)
+
+		var conflict *wallet.ConflictError
+		if !errors.As(err, &conflict) {
+			return err
+		}
+
+		if attempt >= s.retryPolicy.MaxAttempts {
+			log.Printf("Conflito de versão persistente na transferência %s após %d tentativas: %v", transfer.ID, attempt, conflict)
+			return fmt.Errorf("%w após %d tentativas: %w", apperrors.ErrConcurrentUpdate, attempt, err)
+		}
+
+		log.Printf("Conflito de versão na transferência %s (tentativa %d): %v", transfer.ID, attempt, conflict)
+		time.Sleep(s.retryPolicy.delay(attempt))
+	}
+}
+
+func (s *TransferService) record(transfer *Transfer, transaction *Transaction) error {
+	return s.unitOfWork.Do(func(tx Tx) error {
+		if err := tx.Transfers().CreateTransfer(transfer); err != nil {
+			log.Printf("Falha ao salvar a transferência de %.2f: %v", transfer.Value.InexactFloat64(), err)
+			return fmt.Errorf("falha ao salvar a transferência: %v", err)
+		}
+
+		if err := tx.Transfers().CreateTransaction(transaction); err != nil {
+			log.Printf("Falha ao salvar transação de %.2f: %v", transaction.Amount.InexactFloat64(), err)
+			return fmt.Errorf("falha ao salvar a transação: %v", err)
+		}
+
+		return nil
+	})
 }
 
-func (s *TransferService) notifyUser(user *user.User, message string) error {
-	notificationRequest := notification.NotificationRequest{
-		Email:   user.Email,
-		Message: message,
+func (s *TransferService) trySettle(pending *pendingTransfer) error {
+	transfer, transaction := pending.transfer, pending.transaction
+	value, payerID, payeeID := transfer.Value, transfer.Payer, transfer.Payee
+
+	payer, err := s.walletService.GetWallet(payerID)
+	if err != nil {
+		log.Printf("Falha ao obter wallet do pagador %d: %v", payerID, err)
+		return fmt.Errorf("falha ao obter o saldo do pagador: %w", err)
+	}
+
+	if payer.Balance.LessThan(value) {
+		log.Printf("Erro: saldo insuficiente para a transferência de %.2f de %d para %d", value.InexactFloat64(), payerID, payeeID)
+		return insufficientFunds(payerID, value)
+	}
+
+	if err := s.checkLimits(pending); err != nil {
+		return err
 	}
 
-	err := notification.SendNotification(notificationRequest)
+	settled := newStatusChange(transaction, StatusSettled, "")
+	err = s.unitOfWork.Do(func(tx Tx) error {
+		if err := tx.Wallets().Transfer(payer, payeeID, value, transfer.ID); err != nil {
+			log.Printf("Falha ao movimentar saldo de %d para %d: %v", payerID, payeeID, err)
+			return fmt.Errorf("falha ao movimentar o saldo de %d para %d: %w", payerID, payeeID, err)
+		}
+
+		if err := tx.Transfers().UpdateTransactionStatus(settled); err != nil {
+			log.Printf("Falha ao liquidar transação %s: %v", transaction.ID, err)
+			return fmt.Errorf("falha ao atualizar a transação: %w", err)
+		}
+
+		notifications := []notification.Notification{
+			newNotification(notification.EventTransferSent, pending.payer, pending.payee, value),
+			newNotification(notification.EventTransferReceived, pending.payee, pending.payer, value),
+		}
+		notifications = append(notifications, s.lowBalanceNotifications(pending.payer, payer.Balance, value)...)
+		return enqueueNotifications(tx, notifications...)
+	})
 	if err != nil {
-		log.Printf("Falha ao enviar notificação para o usuário %d: %v", user.ID, err)
-		return fmt.Errorf("falha ao enviar a notificação: %v", err)
+		return err
+	}
+
+	transaction.Status = settled.To
+	return nil
+}
+
+func newStatusChange(transaction *Transaction, to Status, reason string) *StatusChange {
+	return &StatusChange{
+		TransactionID: transaction.ID,
+		From:          transaction.Status,
+		To:            to,
+		Reason:        reason,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func (s *TransferService) transition(transaction *Transaction, to Status, reason string) error {
+	return s.apply(transaction, newStatusChange(transaction, to, reason))
+}
+
+func (s *TransferService) apply(transaction *Transaction, change *StatusChange) error {
+	if err := s.transferRepo.UpdateTransactionStatus(change); err != nil {
+		log.Printf("Falha ao mudar transação %s de %s para %s: %v", transaction.ID, transaction.Status, change.To, err)
+		return fmt.Errorf("falha ao atualizar a transação: %w", err)
 	}
 
-	log.Printf("Notificação enviada ao usuário %d: %s", user.ID, message)
+	transaction.Status = change.To
+	if change.Decision != nil {
+		transaction.Authorization = change.Decision
+	}
 	return nil
 }
+
+// fail registra o motivo da falha na transação e devolve cause, que continua
+// sendo o erro reportado ao chamador.
+func (s *TransferService) fail(transaction *Transaction, to Status, cause error) error {
+	if err := s.transition(transaction, to, cause.Error()); err != nil {
+		log.Printf("Transação %s não pôde ser marcada como %s: %v", transaction.ID, to, err)
+	}
+	return cause
+}
+
+func insufficientFunds(payerID int, value decimal.Decimal) error {
+	return apperrors.ErrInsufficientFunds.WithDetails(map[string]interface{}{
+		"payer": payerID,
+		"value": value.String(),
+	})
+}
+
+func generateID() string {
+	newUUID := uuid.New()
+	return newUUID.String()
+}
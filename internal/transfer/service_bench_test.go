@@ -0,0 +1,98 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
+	"pag-simples/pkg/authorization"
+
+	"github.com/shopspring/decimal"
+)
+
+const benchmarkAuthorizerLatency = 2 * time.Millisecond
+
+type slowAuthorizationService struct{}
+
+func (slowAuthorizationService) CheckAuthorization(ctx context.Context, request authorization.Request) (*authorization.Decision, error) {
+	time.Sleep(benchmarkAuthorizerLatency)
+	return &authorization.Decision{Authorized: true, ReasonCode: authorization.ReasonApproved}, nil
+}
+
+func newBenchmarkTransferService(b *testing.B, pairs int) TransferUsecase {
+	userRepo := user.NewMemoryUserRepository()
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	walletService := wallet.NewWalletService(ledgerRepo)
+
+	for id := 1; id <= pairs*2; id++ {
+		userRepo.SaveUser(&user.User{
+			ID:             id,
+			FullName:       fmt.Sprintf("Usuário %d", id),
+			DocumentNumber: fmt.Sprintf("%011d", id),
+			Email:          fmt.Sprintf("usuario%d@email.com", id),
+			UserType:       user.CommonUser,
+		})
+		if err := walletService.CreateWallet(id, decimal.NewFromInt(1_000_000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	transferRepo := NewMemoryTransferRepository()
+	return NewTransferService(
+		user.NewUserService(userRepo),
+		walletService,
+		transferRepo,
+		NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository()),
+		slowAuthorizationService{},
+	)
+}
+
+func runTransferBenchmark(b *testing.B, transfer func(service TransferUsecase, payerID int, payeeID int) error) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	const pairs = 16
+	service := newBenchmarkTransferService(b, pairs)
+	var next int64
+
+	b.SetParallelism(pairs)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		pair := int(atomic.AddInt64(&next, 1)-1) % pairs
+		payerID, payeeID := pair*2+1, pair*2+2
+		for pb.Next() {
+			if err := transfer(service, payerID, payeeID); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+// BenchmarkTransferUnrelatedWallets mede transferências entre pares de wallets
+// distintos, que agora só disputam os locks das próprias wallets.
+func BenchmarkTransferUnrelatedWallets(b *testing.B) {
+	runTransferBenchmark(b, func(service TransferUsecase, payerID int, payeeID int) error {
+		return service.Transfer(decimal.NewFromInt(1), payerID, payeeID)
+	})
+}
+
+// BenchmarkTransferGlobalLock reproduz o mutex global anterior como base de
+// comparação para BenchmarkTransferUnrelatedWallets.
+func BenchmarkTransferGlobalLock(b *testing.B) {
+	var mu sync.Mutex
+	runTransferBenchmark(b, func(service TransferUsecase, payerID int, payeeID int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return service.Transfer(decimal.NewFromInt(1), payerID, payeeID)
+	})
+}
@@ -0,0 +1,370 @@
+package transfer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"pag-simples/internal/database"
+	"pag-simples/internal/pagination"
+	"pag-simples/pkg/authorization"
+)
+
+const transferColumns = "seq, id, value, payer, payee, created_at, refund_of, reason, callback_url, lease_owner, lease_until"
+
+type SQLTransferRepository struct {
+	db database.Executor
+}
+
+func NewSQLTransferRepository(db database.Executor) *SQLTransferRepository {
+	return &SQLTransferRepository{
+		db: db,
+	}
+}
+
+func (r *SQLTransferRepository) CreateTransfer(transfer *Transfer) error {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM transfers WHERE id = $1", transfer.ID).Scan(&count); err != nil {
+		return fmt.Errorf("erro ao verificar transferência %s: %v", transfer.ID, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrTransferAlreadyExists, transfer.ID)
+	}
+
+	refundOf := sql.NullString{String: transfer.RefundOf, Valid: transfer.RefundOf != ""}
+	err := r.db.QueryRow(
+		"INSERT INTO transfers (id, value, payer, payee, created_at, refund_of, reason, callback_url, lease_owner, lease_until) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING seq",
+		transfer.ID, transfer.Value, transfer.Payer, transfer.Payee, transfer.CreatedAt.UnixMilli(), refundOf, string(transfer.Reason), transfer.CallbackURL, transfer.LeaseOwner, leaseMillis(transfer.LeaseUntil),
+	).Scan(&transfer.Seq)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar transferência %s: %v", transfer.ID, err)
+	}
+	return nil
+}
+
+func (r *SQLTransferRepository) CreateTransaction(transaction *Transaction) error {
+	change := initialChange(transaction)
+	if err := change.Validate(); err != nil {
+		return err
+	}
+
+	return database.RunInTx(r.db, func(tx database.Executor) error {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM transactions WHERE id = $1", transaction.ID).Scan(&count); err != nil {
+			return fmt.Errorf("erro ao verificar transação %s: %v", transaction.ID, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %s", ErrTransactionAlreadyExists, transaction.ID)
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO transactions (id, transfer_id, amount, status, created_at) VALUES ($1, $2, $3, $4, $5)",
+			transaction.ID, transaction.TransferID, transaction.Amount, string(transaction.Status), transaction.CreatedAt.UnixMilli(),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao salvar transação %s: %v", transaction.ID, err)
+		}
+
+		return insertStatusChange(tx, change)
+	})
+}
+
+// UpdateTransactionStatus só altera a transação se ela ainda estiver em
+// change.From, o que impede duas transições concorrentes a partir do mesmo status.
+func (r *SQLTransferRepository) UpdateTransactionStatus(change *StatusChange) error {
+	if err := change.Validate(); err != nil {
+		return err
+	}
+
+	return database.RunInTx(r.db, func(tx database.Executor) error {
+		result, err := tx.Exec(
+			"UPDATE transactions SET status = $1 WHERE id = $2 AND status = $3",
+			string(change.To), change.TransactionID, string(change.From),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar transação %s: %v", change.TransactionID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar transação %s: %v", change.TransactionID, err)
+		}
+		if affected == 0 {
+			var current string
+			err := tx.QueryRow("SELECT status FROM transactions WHERE id = $1", change.TransactionID).Scan(&current)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: %s", ErrTransactionNotFound, change.TransactionID)
+			}
+			if err != nil {
+				return fmt.Errorf("erro ao atualizar transação %s: %v", change.TransactionID, err)
+			}
+			return fmt.Errorf("%w: transação %s está em %s, não em %s", ErrInvalidTransition, change.TransactionID, current, change.From)
+		}
+
+		if change.Decision != nil {
+			if err := saveDecision(tx, change.TransactionID, change.Decision); err != nil {
+				return err
+			}
+		}
+
+		return insertStatusChange(tx, change)
+	})
+}
+
+func (r *SQLTransferRepository) GetStatusHistory(transferID string) ([]StatusChange, error) {
+	rows, err := r.db.Query(`SELECT h.transaction_id, h.from_status, h.to_status, h.reason, h.created_at
+		FROM transaction_status_history h
+		JOIN transactions t ON t.id = h.transaction_id
+		WHERE t.transfer_id = $1
+		ORDER BY h.seq`, transferID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar histórico da transferência %s: %v", transferID, err)
+	}
+	defer rows.Close()
+
+	history := []StatusChange{}
+	for rows.Next() {
+		var change StatusChange
+		var from, to string
+		var createdAt int64
+		if err := rows.Scan(&change.TransactionID, &from, &to, &change.Reason, &createdAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler histórico: %v", err)
+		}
+		change.From = Status(from)
+		change.To = Status(to)
+		change.CreatedAt = time.UnixMilli(createdAt)
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao buscar histórico da transferência %s: %v", transferID, err)
+	}
+	return history, nil
+}
+
+func insertStatusChange(tx database.Executor, change *StatusChange) error {
+	_, err := tx.Exec(
+		"INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, created_at) VALUES ($1, $2, $3, $4, $5)",
+		change.TransactionID, string(change.From), string(change.To), change.Reason, change.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar histórico da transação %s: %v", change.TransactionID, err)
+	}
+	return nil
+}
+
+func (r *SQLTransferRepository) GetTransfer(transferID string) (*Transfer, error) {
+	transfer, err := scanTransfer(r.db.QueryRow("SELECT "+transferColumns+" FROM transfers WHERE id = $1", transferID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, transferID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transferência %s: %v", transferID, err)
+	}
+	return transfer, nil
+}
+
+func (r *SQLTransferRepository) GetTransactions(transferID string) ([]Transaction, error) {
+	rows, err := r.db.Query("SELECT id, transfer_id, amount, status, created_at, authorization_decision FROM transactions WHERE transfer_id = $1", transferID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações da transferência %s: %v", transferID, err)
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	for rows.Next() {
+		var transaction Transaction
+		var status, decision string
+		var createdAt int64
+		if err := rows.Scan(&transaction.ID, &transaction.TransferID, &transaction.Amount, &status, &createdAt, &decision); err != nil {
+			return nil, fmt.Errorf("erro ao ler transação: %v", err)
+		}
+		transaction.Status = Status(status)
+		transaction.CreatedAt = time.UnixMilli(createdAt)
+		if transaction.Authorization, err = decodeDecision(decision); err != nil {
+			return nil, fmt.Errorf("erro ao ler autorização da transação %s: %v", transaction.ID, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações da transferência %s: %v", transferID, err)
+	}
+
+	sortTransactions(transactions)
+	return transactions, nil
+}
+
+func (r *SQLTransferRepository) ListTransfers(filter TransferFilter) ([]Transfer, string, error) {
+	before, err := pagination.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch filter.Direction {
+	case Sent:
+		conditions = append(conditions, "payer = "+arg(filter.UserID))
+	case Received:
+		conditions = append(conditions, "payee = "+arg(filter.UserID))
+	default:
+		userID := arg(filter.UserID)
+		conditions = append(conditions, "(payer = "+userID+" OR payee = "+userID+")")
+	}
+	if before > 0 {
+		conditions = append(conditions, "seq < "+arg(before))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(filter.From.UnixMilli()))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(filter.To.UnixMilli()))
+	}
+
+	limit := pagination.NormalizeLimit(filter.Limit)
+	query := "SELECT " + transferColumns + " FROM transfers WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY seq DESC LIMIT " + arg(limit+1)
+
+	transfers, err := r.queryTransfers(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao listar transferências do usuário %d: %v", filter.UserID, err)
+	}
+
+	transfers, next := pagination.Page(transfers, limit, Transfer.position)
+	return transfers, next, nil
+}
+
+func (r *SQLTransferRepository) GetRefunds(transferID string) ([]Transfer, error) {
+	refunds, err := r.queryTransfers("SELECT "+transferColumns+" FROM transfers WHERE refund_of = $1 ORDER BY seq", transferID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar estornos da transferência %s: %v", transferID, err)
+	}
+	return refunds, nil
+}
+
+// ClaimPendingTransfers só fica com as transferências cujo lease ainda é o
+// lido, o que impede duas instâncias de reservarem a mesma transferência.
+func (r *SQLTransferRepository) ClaimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, error) {
+	due, err := r.queryTransfers(
+		"SELECT "+transferColumns+" FROM transfers WHERE lease_until <= $1 AND id IN (SELECT transfer_id FROM transactions WHERE status IN ($2, $3, $4)) ORDER BY seq",
+		now.UnixMilli(), string(StatusCreated), string(StatusAuthorizing), string(StatusAuthorized),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transferências pendentes: %v", err)
+	}
+
+	claimed := []Transfer{}
+	for _, transfer := range due {
+		result, err := r.db.Exec(
+			"UPDATE transfers SET lease_owner = $1, lease_until = $2 WHERE id = $3 AND lease_owner = $4 AND lease_until = $5",
+			owner, until.UnixMilli(), transfer.ID, transfer.LeaseOwner, leaseMillis(transfer.LeaseUntil),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reservar transferência %s: %v", transfer.ID, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reservar transferência %s: %v", transfer.ID, err)
+		}
+		if affected == 0 {
+			continue
+		}
+
+		transfer.LeaseOwner = owner
+		transfer.LeaseUntil = time.UnixMilli(until.UnixMilli())
+		claimed = append(claimed, transfer)
+	}
+	return claimed, nil
+}
+
+func (r *SQLTransferRepository) ExtendLease(transferID string, owner string, until time.Time) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE transfers SET lease_until = $1 WHERE id = $2 AND lease_owner = $3",
+		until.UnixMilli(), transferID, owner,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao renovar reserva da transferência %s: %v", transferID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao renovar reserva da transferência %s: %v", transferID, err)
+	}
+	return affected == 1, nil
+}
+
+func (r *SQLTransferRepository) queryTransfers(query string, args ...interface{}) ([]Transfer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []Transfer{}
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+	return transfers, rows.Err()
+}
+
+func scanTransfer(row database.RowScanner) (*Transfer, error) {
+	var transfer Transfer
+	var createdAt int64
+	var refundOf sql.NullString
+	var reason string
+	var leaseUntil int64
+	if err := row.Scan(&transfer.Seq, &transfer.ID, &transfer.Value, &transfer.Payer, &transfer.Payee, &createdAt, &refundOf, &reason, &transfer.CallbackURL, &transfer.LeaseOwner, &leaseUntil); err != nil {
+		return nil, err
+	}
+	transfer.CreatedAt = time.UnixMilli(createdAt)
+	if leaseUntil != 0 {
+		transfer.LeaseUntil = time.UnixMilli(leaseUntil)
+	}
+	transfer.RefundOf = refundOf.String
+	transfer.Reason = RefundReason(reason)
+	return &transfer, nil
+}
+
+// leaseMillis grava o lease zerado como 0, que qualquer instância pode
+// reservar.
+func leaseMillis(until time.Time) int64 {
+	if until.IsZero() {
+		return 0
+	}
+	return until.UnixMilli()
+}
+
+func saveDecision(tx database.Executor, transactionID string, decision *authorization.Decision) error {
+	encoded, err := json.Marshal(decision)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar autorização da transação %s: %v", transactionID, err)
+	}
+	if _, err := tx.Exec("UPDATE transactions SET authorization_decision = $1 WHERE id = $2", string(encoded), transactionID); err != nil {
+		return fmt.Errorf("erro ao salvar autorização da transação %s: %v", transactionID, err)
+	}
+	return nil
+}
+
+// decodeDecision devolve nil para transações sem decisão gravada, como os
+// estornos e as transferências anteriores à coluna.
+func decodeDecision(encoded string) (*authorization.Decision, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	var decision authorization.Decision
+	if err := json.Unmarshal([]byte(encoded), &decision); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
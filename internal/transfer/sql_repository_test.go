@@ -0,0 +1,88 @@
+package transfer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"pag-simples/internal/database"
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/wallet"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLUnitOfWorkRollsBackOnFailure(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			testSQLUnitOfWorkRollsBackOnFailure(t, databasetest.Open(t, driver))
+		})
+	}
+}
+
+func testSQLUnitOfWorkRollsBackOnFailure(t *testing.T, db *database.DB) {
+	walletService := wallet.NewWalletService(ledger.NewSQLLedgerRepository(db))
+	assert.NoError(t, walletService.CreateWallet(1, decimal.NewFromInt(1000)))
+	assert.NoError(t, walletService.CreateWallet(2, decimal.NewFromInt(500)))
+
+	err := NewSQLUnitOfWork(db).Do(func(tx Tx) error {
+		if err := tx.Transfers().CreateTransfer(&Transfer{ID: "t1", Value: decimal.NewFromInt(100), Payer: 1, Payee: 2}); err != nil {
+			return err
+		}
+		payer, err := tx.Wallets().GetWallet(1)
+		if err != nil {
+			return err
+		}
+		if err := tx.Wallets().Transfer(payer, 2, decimal.NewFromInt(100), "t1"); err != nil {
+			return err
+		}
+		return fmt.Errorf("falha injetada")
+	})
+
+	assert.Error(t, err)
+
+	payerBalance, _ := walletService.GetBalance(1)
+	payeeBalance, _ := walletService.GetBalance(2)
+	assert.True(t, payerBalance.Equal(decimal.NewFromInt(1000)))
+	assert.True(t, payeeBalance.Equal(decimal.NewFromInt(500)))
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transfers").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestSQLLedgerSerializesConcurrentWithdrawals(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			testSQLLedgerSerializesConcurrentWithdrawals(t, databasetest.Open(t, driver))
+		})
+	}
+}
+
+func testSQLLedgerSerializesConcurrentWithdrawals(t *testing.T, db *database.DB) {
+	walletService := wallet.NewWalletService(ledger.NewSQLLedgerRepository(db))
+	assert.NoError(t, walletService.CreateWallet(1, decimal.NewFromInt(100)))
+	assert.NoError(t, walletService.CreateWallet(2, decimal.Zero))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := walletService.UpdateBalance(1, decimal.NewFromInt(-10)); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	payerBalance, _ := walletService.GetBalance(1)
+	assert.Equal(t, 10, succeeded)
+	assert.True(t, payerBalance.IsZero())
+}
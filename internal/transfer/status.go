@@ -0,0 +1,110 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/pkg/authorization"
+)
+
+type Status string
+
+const (
+	StatusCreated           Status = "created"
+	StatusAuthorizing       Status = "authorizing"
+	StatusAuthorized        Status = "authorized"
+	StatusSettled           Status = "settled"
+	StatusDeclined          Status = "declined"
+	StatusFailed            Status = "failed"
+	StatusReversed          Status = "reversed"
+	StatusRefunded          Status = "refunded"
+	StatusPartiallyRefunded Status = "partially_refunded"
+)
+
+var ErrInvalidTransition = apperrors.ErrInvalidStatusTransition
+
+// transitions lista, para cada status, os próximos status permitidos. Status
+// ausentes do mapa são finais.
+var transitions = map[Status][]Status{
+	StatusCreated:           {StatusAuthorizing, StatusFailed},
+	StatusAuthorizing:       {StatusAuthorized, StatusDeclined, StatusFailed},
+	StatusAuthorized:        {StatusSettled, StatusFailed},
+	StatusSettled:           {StatusPartiallyRefunded, StatusRefunded, StatusReversed},
+	StatusPartiallyRefunded: {StatusPartiallyRefunded, StatusRefunded, StatusReversed},
+}
+
+var initialStatuses = []Status{StatusCreated, StatusSettled}
+
+// pendingStatuses são os status de uma transação aceita que ainda não chegou
+// à liquidação nem a uma falha; uma parada do servidor pode deixá-la em
+// qualquer um deles.
+var pendingStatuses = []Status{StatusCreated, StatusAuthorizing, StatusAuthorized}
+
+func (s Status) pending() bool {
+	for _, status := range pendingStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Status) Final() bool {
+	return len(transitions[s]) == 0
+}
+
+func (s Status) Refundable() bool {
+	return s == StatusSettled || s == StatusPartiallyRefunded
+}
+
+// settled indica se o dinheiro chegou a ser movimentado, mesmo que depois
+// tenha sido estornado.
+func (s Status) settled() bool {
+	return s.Refundable() || s == StatusRefunded || s == StatusReversed
+}
+
+// StatusChange é uma linha do histórico de uma transação. From vazio indica a
+// criação da transação. Decision, quando presente, é gravada na transação junto
+// com o novo status.
+type StatusChange struct {
+	TransactionID string                  `json:"transaction_id"`
+	From          Status                  `json:"from,omitempty"`
+	To            Status                  `json:"to"`
+	Reason        string                  `json:"reason,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	Decision      *authorization.Decision `json:"-"`
+}
+
+func (c *StatusChange) Validate() error {
+	if c.From == "" {
+		for _, status := range initialStatuses {
+			if c.To == status {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: transação %s não pode ser criada como %s", ErrInvalidTransition, c.TransactionID, c.To)
+	}
+
+	if !c.From.CanTransitionTo(c.To) {
+		return fmt.Errorf("%w: transação %s de %s para %s", ErrInvalidTransition, c.TransactionID, c.From, c.To)
+	}
+	return nil
+}
+
+func initialChange(transaction *Transaction) *StatusChange {
+	return &StatusChange{
+		TransactionID: transaction.ID,
+		To:            transaction.Status,
+		CreatedAt:     transaction.CreatedAt,
+	}
+}
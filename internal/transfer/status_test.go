@@ -0,0 +1,115 @@
+package transfer
+
+import (
+	"errors"
+	"testing"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/pkg/authorization"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from    Status
+		to      Status
+		allowed bool
+	}{
+		{StatusCreated, StatusAuthorizing, true},
+		{StatusCreated, StatusFailed, true},
+		{StatusCreated, StatusSettled, false},
+		{StatusAuthorizing, StatusAuthorized, true},
+		{StatusAuthorizing, StatusDeclined, true},
+		{StatusAuthorizing, StatusSettled, false},
+		{StatusAuthorized, StatusSettled, true},
+		{StatusAuthorized, StatusDeclined, false},
+		{StatusSettled, StatusPartiallyRefunded, true},
+		{StatusSettled, StatusRefunded, true},
+		{StatusSettled, StatusReversed, true},
+		{StatusSettled, StatusFailed, false},
+		{StatusPartiallyRefunded, StatusPartiallyRefunded, true},
+		{StatusPartiallyRefunded, StatusRefunded, true},
+		{StatusRefunded, StatusPartiallyRefunded, false},
+		{StatusReversed, StatusRefunded, false},
+		{StatusDeclined, StatusAuthorized, false},
+		{StatusFailed, StatusAuthorizing, false},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.from)+"->"+string(c.to), func(t *testing.T) {
+			assert.Equal(t, c.allowed, c.from.CanTransitionTo(c.to))
+
+			err := (&StatusChange{TransactionID: "tx1", From: c.from, To: c.to}).Validate()
+			if c.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, apperrors.ErrInvalidStatusTransition), "Validate: %v", err)
+			}
+		})
+	}
+
+	for _, status := range []Status{StatusDeclined, StatusFailed, StatusRefunded, StatusReversed} {
+		assert.True(t, status.Final(), string(status))
+	}
+	assert.False(t, StatusSettled.Final())
+}
+
+func TestTransferLifecycle(t *testing.T) {
+	t.Run("Settled", func(t *testing.T) {
+		f := newMemoryRefundFixture(t)
+		original := f.purchase(t, 200)
+
+		details, err := f.service.GetTransfer(original.ID)
+		require.NoError(t, err)
+		require.Len(t, details.Transactions, 1)
+		assert.Equal(t, StatusSettled, details.Transactions[0].Status)
+		assert.Equal(t, []Status{StatusCreated, StatusAuthorizing, StatusAuthorized, StatusSettled}, historyStatuses(details.Transactions[0].History))
+		require.NotNil(t, details.Transactions[0].Authorization)
+		assert.True(t, details.Transactions[0].Authorization.Authorized)
+
+		history, err := f.service.GetStatusHistory(original.ID)
+		require.NoError(t, err)
+		assert.Equal(t, details.Transactions[0].History, history)
+
+		_, err = f.service.GetStatusHistory("inexistente")
+		assert.True(t, errors.Is(err, apperrors.ErrTransferNotFound), "GetStatusHistory: %v", err)
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		f := newMemoryRefundFixture(t)
+		authorizationService := new(MockAuthorizationService)
+		authorizationService.On("CheckAuthorization").Return(false, nil)
+		f.service.(*TransferService).authorizationService = authorizationService
+
+		err := f.service.Transfer(decimal.NewFromInt(200), 1, 2)
+		assert.True(t, errors.Is(err, apperrors.ErrAuthorizationDenied), "Transfer: %v", err)
+		f.assertBalances(t, 1000, 50)
+
+		page, err := f.service.ListTransfers(TransferFilter{UserID: 1})
+		require.NoError(t, err)
+		require.Len(t, page.Transfers, 1)
+
+		history, err := f.service.GetStatusHistory(page.Transfers[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, []Status{StatusCreated, StatusAuthorizing, StatusDeclined}, historyStatuses(history))
+		assert.Equal(t, apperrors.ErrAuthorizationDenied.Error(), history[2].Reason)
+
+		details, err := f.service.GetTransfer(page.Transfers[0].ID)
+		require.NoError(t, err)
+		require.NotNil(t, details.Transactions[0].Authorization)
+		assert.False(t, details.Transactions[0].Authorization.Authorized)
+		assert.Equal(t, authorization.ReasonDenied, details.Transactions[0].Authorization.ReasonCode)
+		assert.Equal(t, "ref-"+page.Transfers[0].ID, details.Transactions[0].Authorization.ReferenceID)
+	})
+}
+
+func historyStatuses(history []StatusChange) []Status {
+	statuses := make([]Status, 0, len(history))
+	for _, change := range history {
+		statuses = append(statuses, change.To)
+	}
+	return statuses
+}
@@ -3,25 +3,105 @@ package transfer
 import (
 	"time"
 
+	"pag-simples/internal/wallet"
+	"pag-simples/pkg/authorization"
+
 	"github.com/shopspring/decimal"
 )
 
+type Direction = wallet.Direction
+
+const (
+	Sent     = wallet.Sent
+	Received = wallet.Received
+)
+
 type Transfer struct {
-	ID        string    `json:"id"`
-	Value     decimal.Decimal   `json:"value"`
-	Payer     int       `json:"payer"`
-	Payee     int       `json:"payee"`
+	ID          string          `json:"id"`
+	Value       decimal.Decimal `json:"value"`
+	Payer       int             `json:"payer"`
+	Payee       int             `json:"payee"`
+	CreatedAt   time.Time       `json:"created_at"`
+	RefundOf    string          `json:"refund_of,omitempty"`
+	Reason      RefundReason    `json:"reason,omitempty"`
+	CallbackURL string          `json:"callback_url,omitempty"`
+	Seq         int64           `json:"-"`
+	// LeaseOwner é a instância que está processando a transferência, e
+	// LeaseUntil, até quando as outras devem deixá-la em paz.
+	LeaseOwner string    `json:"-"`
+	LeaseUntil time.Time `json:"-"`
 }
 
 type Transaction struct {
-	ID				string  `json:"id"`
-	TransferID string    `json:"transfer_id"`
+	ID         string          `json:"id"`
+	TransferID string          `json:"transfer_id"`
 	Amount     decimal.Decimal `json:"amount"`
-	Status     string  `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
+	Status     Status          `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	History    []StatusChange  `json:"history,omitempty"`
+	// Authorization guarda a decisão do autorizador para auditoria.
+	Authorization *authorization.Decision `json:"authorization,omitempty"`
 }
 
 type Notification struct {
 	UserID  int    `json:"user_id"`
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
+
+type TransferDetails struct {
+	Transfer
+	Transactions []Transaction `json:"transactions"`
+}
+
+// TransferFilter seleciona as transferências de um usuário; From é inclusivo,
+// To é exclusivo e datas zeradas não limitam o período.
+type TransferFilter struct {
+	UserID    int
+	Direction Direction
+	From      time.Time
+	To        time.Time
+	Cursor    string
+	Limit     int
+}
+
+type UserTransfer struct {
+	Transfer
+	Direction Direction `json:"direction"`
+}
+
+type TransferPage struct {
+	Transfers  []UserTransfer `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func (f TransferFilter) matches(transfer *Transfer) bool {
+	switch f.Direction {
+	case Sent:
+		if transfer.Payer != f.UserID {
+			return false
+		}
+	case Received:
+		if transfer.Payee != f.UserID {
+			return false
+		}
+	default:
+		if transfer.Payer != f.UserID && transfer.Payee != f.UserID {
+			return false
+		}
+	}
+
+	if !f.From.IsZero() && transfer.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !transfer.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+func (t *Transfer) directionFor(userID int) Direction {
+	if t.Payer == userID {
+		return Sent
+	}
+	return Received
+}
@@ -1,10 +1,17 @@
 package transfer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
 	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
 	"pag-simples/pkg/authorization"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -40,6 +47,27 @@ func (m *MockUserUsecase) ValidateUniqueUser(param1 string, param2 string) error
 	return args.Error(0)
 }
 
+func (m *MockUserUsecase) Authenticate(email string, password string) (*user.User, error) {
+	args := m.Called(email, password)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
+
+func (m *MockUserUsecase) ChangePassword(userID int, current string, next string) error {
+	args := m.Called(userID, current, next)
+	return args.Error(0)
+}
+
+func (m *MockUserUsecase) RequestPasswordReset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockUserUsecase) ResetPassword(token string, password string) error {
+	args := m.Called(token, password)
+	return args.Error(0)
+}
+
 type MockWalletService struct {
 	mock.Mock
 }
@@ -59,12 +87,33 @@ func (m *MockWalletService) UpdateBalance(userID int, newBalance decimal.Decimal
 	return args.Error(0)
 }
 
+func (m *MockWalletService) GetWallet(userID int) (*wallet.Wallet, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*wallet.Wallet), args.Error(1)
+}
+
+func (m *MockWalletService) Transfer(payer *wallet.Wallet, payeeID int, amount decimal.Decimal, reference string) error {
+	args := m.Called(payer, payeeID, amount, reference)
+	return args.Error(0)
+}
+
+func (m *MockWalletService) GetStatement(userID int, filter wallet.StatementFilter) (*wallet.Statement, error) {
+	args := m.Called(userID, filter)
+	return args.Get(0).(*wallet.Statement), args.Error(1)
+}
+
 type MockTransferRepository struct {
 	mock.Mock
 }
 
-func (m *MockTransferRepository) UpdateTransactionStatus(transactionID string, status string) error {
-	panic("error")
+func (m *MockTransferRepository) UpdateTransactionStatus(change *StatusChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockTransferRepository) GetStatusHistory(transferID string) ([]StatusChange, error) {
+	args := m.Called(transferID)
+	return args.Get(0).([]StatusChange), args.Error(1)
 }
 
 func (m *MockTransferRepository) CreateTransfer(transfer *Transfer) error {
@@ -77,13 +126,71 @@ func (m *MockTransferRepository) CreateTransaction(transaction *Transaction) err
 	return args.Error(0)
 }
 
+func (m *MockTransferRepository) GetTransfer(transferID string) (*Transfer, error) {
+	args := m.Called(transferID)
+	return args.Get(0).(*Transfer), args.Error(1)
+}
+
+func (m *MockTransferRepository) GetTransactions(transferID string) ([]Transaction, error) {
+	args := m.Called(transferID)
+	return args.Get(0).([]Transaction), args.Error(1)
+}
+
+func (m *MockTransferRepository) ListTransfers(filter TransferFilter) ([]Transfer, string, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]Transfer), args.String(1), args.Error(2)
+}
+
+func (m *MockTransferRepository) GetRefunds(transferID string) ([]Transfer, error) {
+	args := m.Called(transferID)
+	return args.Get(0).([]Transfer), args.Error(1)
+}
+
+func (m *MockTransferRepository) ClaimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, error) {
+	args := m.Called(owner, now, until)
+	return args.Get(0).([]Transfer), args.Error(1)
+}
+
+func (m *MockTransferRepository) ExtendLease(transferID string, owner string, until time.Time) (bool, error) {
+	args := m.Called(transferID, owner, until)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockUnitOfWork struct {
+	transferRepo  TransferRepository
+	walletService wallet.WalletUseCase
+}
+
+func (m *MockUnitOfWork) Do(fn func(tx Tx) error) error {
+	return fn(m)
+}
+
+func (m *MockUnitOfWork) Transfers() TransferRepository {
+	return m.transferRepo
+}
+
+func (m *MockUnitOfWork) Wallets() wallet.WalletUseCase {
+	return m.walletService
+}
+
+func (m *MockUnitOfWork) Outbox() outbox.OutboxRepository {
+	return outbox.NewMemoryOutboxRepository()
+}
+
 type MockAuthorizationService struct {
 	mock.Mock
 }
 
-func (m *MockAuthorizationService) CheckAuthorization() (bool, error) {
+// CheckAuthorization converte o bool configurado no mock em uma decisão.
+func (m *MockAuthorizationService) CheckAuthorization(ctx context.Context, request authorization.Request) (*authorization.Decision, error) {
 	args := m.Called()
-	return args.Bool(0), args.Error(1)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	if !args.Bool(0) {
+		return &authorization.Decision{ReasonCode: authorization.ReasonDenied, ReferenceID: "ref-" + request.TransferID}, nil
+	}
+	return &authorization.Decision{Authorized: true, ReasonCode: authorization.ReasonApproved, ReferenceID: "ref-" + request.TransferID}, nil
 }
 
 var _ authorization.AuthorizationService = (*MockAuthorizationService)(nil)
@@ -94,27 +201,29 @@ func TestTransferSuccess(t *testing.T) {
 	transferRepo := new(MockTransferRepository)
 	authorizationService := new(MockAuthorizationService)
 
-	transferService := NewTransferService(userUsecase, walletService, transferRepo, authorizationService)
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
 
 	payerID := 1
 	payeeID := 2
 	value := decimal.NewFromFloat(100.0)
+	payerWallet := &wallet.Wallet{ID: payerID, Balance: decimal.NewFromFloat(200.0), Version: 1}
 
 	payer := &user.User{ID: payerID, UserType: "common_user", FullName: "Payer Name"}
 	payee := &user.User{ID: payeeID, FullName: "Payee Name"}
 	userUsecase.On("GetUser", payerID).Return(payer, nil)
 	userUsecase.On("GetUser", payeeID).Return(payee, nil)
 	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(200.0), nil)
-	walletService.On("GetBalance", payeeID).Return(decimal.NewFromFloat(50.0), nil)
 	authorizationService.On("CheckAuthorization").Return(true, nil)
 	transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
-	walletService.On("UpdateBalance", payerID, decimal.NewFromFloat(100.0)).Return(nil)
-	walletService.On("UpdateBalance", payeeID, decimal.NewFromFloat(150.0)).Return(nil)
-	transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+	walletService.On("GetWallet", payerID).Return(payerWallet, nil)
+	walletService.On("Transfer", payerWallet, payeeID, value, mock.Anything).Return(nil)
+	transferRepo.On("CreateTransaction", mock.MatchedBy(func(transaction *Transaction) bool { return transaction.Status == StatusCreated })).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.Anything).Return(nil)
 
 	err := transferService.Transfer(value, payerID, payeeID)
 
 	assert.NoError(t, err)
+	transferRepo.AssertNumberOfCalls(t, "UpdateTransactionStatus", 3)
 	userUsecase.AssertExpectations(t)
 	walletService.AssertExpectations(t)
 	transferRepo.AssertExpectations(t)
@@ -127,7 +236,7 @@ func TestTransferErrorInsufficientBalance(t *testing.T) {
 	transferRepo := new(MockTransferRepository)
 	authorizationService := new(MockAuthorizationService)
 
-	transferService := NewTransferService(userUsecase, walletService, transferRepo, authorizationService)
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
 
 	payerID := 1
 	payeeID := 2
@@ -139,12 +248,15 @@ func TestTransferErrorInsufficientBalance(t *testing.T) {
 	userUsecase.On("GetUser", payeeID).Return(payee, nil)
 
 	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(50.0), nil)
-	walletService.On("GetBalance", payeeID).Return(decimal.NewFromFloat(50.0), nil)
+	transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
+	transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.MatchedBy(func(change *StatusChange) bool { return change.To == StatusFailed })).Return(nil)
 
 	err := transferService.Transfer(value, payerID, payeeID)
 
 	assert.Error(t, err)
 	assert.Equal(t, "saldo insuficiente para a transferência", err.Error())
+	assert.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
 
 	userUsecase.AssertExpectations(t)
 	walletService.AssertExpectations(t)
@@ -152,14 +264,13 @@ func TestTransferErrorInsufficientBalance(t *testing.T) {
 	authorizationService.AssertExpectations(t)
 }
 
-
 func TestTransferErrorAuthorizationFailed(t *testing.T) {
 	userUsecase := new(MockUserUsecase)
 	walletService := new(MockWalletService)
 	transferRepo := new(MockTransferRepository)
 	authorizationService := new(MockAuthorizationService)
 
-	transferService := NewTransferService(userUsecase, walletService, transferRepo, authorizationService)
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
 
 	payerID := 1
 	payeeID := 2
@@ -170,13 +281,17 @@ func TestTransferErrorAuthorizationFailed(t *testing.T) {
 	userUsecase.On("GetUser", payerID).Return(payer, nil)
 	userUsecase.On("GetUser", payeeID).Return(payee, nil)
 	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(200.0), nil)
-	walletService.On("GetBalance", payeeID).Return(decimal.NewFromFloat(50.0), nil)
 	authorizationService.On("CheckAuthorization").Return(false, nil)
+	transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
+	transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.MatchedBy(func(change *StatusChange) bool { return change.To == StatusAuthorizing })).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.MatchedBy(func(change *StatusChange) bool { return change.To == StatusDeclined })).Return(nil)
 
 	err := transferService.Transfer(value, payerID, payeeID)
 
 	assert.Error(t, err)
 	assert.Equal(t, "transferência não autorizada", err.Error())
+	assert.ErrorIs(t, err, apperrors.ErrAuthorizationDenied)
 
 	userUsecase.AssertExpectations(t)
 	walletService.AssertExpectations(t)
@@ -190,7 +305,7 @@ func TestTransferErrorRepositorySave(t *testing.T) {
 	transferRepo := new(MockTransferRepository)
 	authorizationService := new(MockAuthorizationService)
 
-	transferService := NewTransferService(userUsecase, walletService, transferRepo, authorizationService)
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
 
 	payerID := 1
 	payeeID := 2
@@ -200,9 +315,6 @@ func TestTransferErrorRepositorySave(t *testing.T) {
 	payee := &user.User{ID: payeeID, FullName: "Payee Name"}
 	userUsecase.On("GetUser", payerID).Return(payer, nil)
 	userUsecase.On("GetUser", payeeID).Return(payee, nil)
-	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(200.0), nil)
-	walletService.On("GetBalance", payeeID).Return(decimal.NewFromFloat(50.0), nil)
-	authorizationService.On("CheckAuthorization").Return(true, nil)
 	transferRepo.On("CreateTransfer", mock.Anything).Return(fmt.Errorf("erro ao salvar transferência"))
 
 	err := transferService.Transfer(value, payerID, payeeID)
@@ -216,35 +328,227 @@ func TestTransferErrorRepositorySave(t *testing.T) {
 	authorizationService.AssertExpectations(t)
 }
 
-func TestTransferErrorUpdateBalance(t *testing.T) {
+func TestTransferErrorWalletTransfer(t *testing.T) {
 	userUsecase := new(MockUserUsecase)
 	walletService := new(MockWalletService)
 	transferRepo := new(MockTransferRepository)
 	authorizationService := new(MockAuthorizationService)
 
-	transferService := NewTransferService(userUsecase, walletService, transferRepo, authorizationService)
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
 
 	payerID := 1
 	payeeID := 2
 	value := decimal.NewFromFloat(100.0)
+	payerWallet := &wallet.Wallet{ID: payerID, Balance: decimal.NewFromFloat(200.0), Version: 1}
 
 	payer := &user.User{ID: payerID, UserType: "common_user", FullName: "Payer Name"}
 	payee := &user.User{ID: payeeID, FullName: "Payee Name"}
 	userUsecase.On("GetUser", payerID).Return(payer, nil)
 	userUsecase.On("GetUser", payeeID).Return(payee, nil)
 	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(200.0), nil)
-	walletService.On("GetBalance", payeeID).Return(decimal.NewFromFloat(50.0), nil)
 	authorizationService.On("CheckAuthorization").Return(true, nil)
 	transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
-	walletService.On("UpdateBalance", payerID, decimal.NewFromFloat(100.0)).Return(fmt.Errorf("erro ao atualizar saldo do pagador"))
+	transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.MatchedBy(func(change *StatusChange) bool { return change.To == StatusAuthorizing })).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.MatchedBy(func(change *StatusChange) bool { return change.To == StatusAuthorized })).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.MatchedBy(func(change *StatusChange) bool { return change.To == StatusFailed })).Return(nil)
+	walletService.On("GetWallet", payerID).Return(payerWallet, nil)
+	walletService.On("Transfer", payerWallet, payeeID, value, mock.Anything).Return(fmt.Errorf("%w %s", ledger.ErrInsufficientBalance, "wallet:1"))
 
 	err := transferService.Transfer(value, payerID, payeeID)
 
 	assert.Error(t, err)
-	assert.Equal(t, "falha ao atualizar o saldo do pagador 1: erro ao atualizar saldo do pagador", err.Error())
+	assert.Equal(t, "falha ao movimentar o saldo de 1 para 2: saldo insuficiente na conta wallet:1", err.Error())
+	assert.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
 
 	userUsecase.AssertExpectations(t)
 	walletService.AssertExpectations(t)
 	transferRepo.AssertExpectations(t)
 	authorizationService.AssertExpectations(t)
 }
+
+func TestTransferRetriesOnVersionConflict(t *testing.T) {
+	userUsecase := new(MockUserUsecase)
+	walletService := new(MockWalletService)
+	transferRepo := new(MockTransferRepository)
+	authorizationService := new(MockAuthorizationService)
+
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+
+	payerID := 1
+	payeeID := 2
+	value := decimal.NewFromFloat(100.0)
+	staleWallet := &wallet.Wallet{ID: payerID, Balance: decimal.NewFromFloat(200.0), Version: 1}
+	freshWallet := &wallet.Wallet{ID: payerID, Balance: decimal.NewFromFloat(150.0), Version: 2}
+
+	userUsecase.On("GetUser", payerID).Return(&user.User{ID: payerID, UserType: "common_user"}, nil)
+	userUsecase.On("GetUser", payeeID).Return(&user.User{ID: payeeID}, nil)
+	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(200.0), nil)
+	authorizationService.On("CheckAuthorization").Return(true, nil)
+	walletService.On("GetWallet", payerID).Return(staleWallet, nil).Once()
+	walletService.On("GetWallet", payerID).Return(freshWallet, nil).Once()
+	transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
+	walletService.On("Transfer", staleWallet, payeeID, value, mock.Anything).Return(&wallet.ConflictError{UserID: payerID, ExpectedVersion: 1, ActualVersion: 2}).Once()
+	walletService.On("Transfer", freshWallet, payeeID, value, mock.Anything).Return(nil).Once()
+	transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.Anything).Return(nil)
+
+	err := transferService.Transfer(value, payerID, payeeID)
+
+	assert.NoError(t, err)
+	walletService.AssertNumberOfCalls(t, "GetWallet", 2)
+	walletService.AssertExpectations(t)
+}
+
+func TestTransferGivesUpAfterRepeatedConflicts(t *testing.T) {
+	userUsecase := new(MockUserUsecase)
+	walletService := new(MockWalletService)
+	transferRepo := new(MockTransferRepository)
+	authorizationService := new(MockAuthorizationService)
+
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+
+	payerID := 1
+	payeeID := 2
+	value := decimal.NewFromFloat(100.0)
+	payerWallet := &wallet.Wallet{ID: payerID, Balance: decimal.NewFromFloat(200.0), Version: 1}
+
+	userUsecase.On("GetUser", payerID).Return(&user.User{ID: payerID, UserType: "common_user"}, nil)
+	userUsecase.On("GetUser", payeeID).Return(&user.User{ID: payeeID}, nil)
+	walletService.On("GetBalance", payerID).Return(decimal.NewFromFloat(200.0), nil)
+	authorizationService.On("CheckAuthorization").Return(true, nil)
+	walletService.On("GetWallet", payerID).Return(payerWallet, nil)
+	transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
+	transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+	transferRepo.On("UpdateTransactionStatus", mock.Anything).Return(nil)
+	walletService.On("Transfer", payerWallet, payeeID, value, mock.Anything).Return(&wallet.ConflictError{UserID: payerID, ExpectedVersion: 1, ActualVersion: 2})
+
+	err := transferService.Transfer(value, payerID, payeeID)
+
+	var conflict *wallet.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, apperrors.ErrConcurrentUpdate)
+	walletService.AssertNumberOfCalls(t, "Transfer", 2)
+}
+
+func TestTransferDomainErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    decimal.Decimal
+		setup    func(userUsecase *MockUserUsecase, authorizationService *MockAuthorizationService, walletService *MockWalletService)
+		expected error
+	}{
+		{
+			name:     "valor inválido",
+			value:    decimal.Zero,
+			setup:    func(*MockUserUsecase, *MockAuthorizationService, *MockWalletService) {},
+			expected: apperrors.ErrInvalidAmount,
+		},
+		{
+			name:  "pagador não encontrado",
+			value: decimal.NewFromInt(10),
+			setup: func(userUsecase *MockUserUsecase, _ *MockAuthorizationService, _ *MockWalletService) {
+				userUsecase.On("GetUser", 1).Return((*user.User)(nil), fmt.Errorf("%w: ID 1", user.ErrUserNotFound))
+			},
+			expected: apperrors.ErrPayerNotFound,
+		},
+		{
+			name:  "recebedor não encontrado",
+			value: decimal.NewFromInt(10),
+			setup: func(userUsecase *MockUserUsecase, _ *MockAuthorizationService, _ *MockWalletService) {
+				userUsecase.On("GetUser", 1).Return(&user.User{ID: 1, UserType: user.CommonUser}, nil)
+				userUsecase.On("GetUser", 2).Return((*user.User)(nil), fmt.Errorf("%w: ID 2", user.ErrUserNotFound))
+			},
+			expected: apperrors.ErrPayeeNotFound,
+		},
+		{
+			name:  "lojista pagando",
+			value: decimal.NewFromInt(10),
+			setup: func(userUsecase *MockUserUsecase, _ *MockAuthorizationService, _ *MockWalletService) {
+				userUsecase.On("GetUser", 1).Return(&user.User{ID: 1, UserType: user.Merchant}, nil)
+				userUsecase.On("GetUser", 2).Return(&user.User{ID: 2}, nil)
+			},
+			expected: apperrors.ErrMerchantCannotPay,
+		},
+		{
+			name:  "autorizador indisponível",
+			value: decimal.NewFromInt(10),
+			setup: func(userUsecase *MockUserUsecase, authorizationService *MockAuthorizationService, walletService *MockWalletService) {
+				userUsecase.On("GetUser", 1).Return(&user.User{ID: 1, UserType: user.CommonUser}, nil)
+				userUsecase.On("GetUser", 2).Return(&user.User{ID: 2}, nil)
+				walletService.On("GetBalance", 1).Return(decimal.NewFromInt(100), nil)
+				authorizationService.On("CheckAuthorization").Return(false, fmt.Errorf("timeout"))
+			},
+			expected: apperrors.ErrAuthorizerUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userUsecase := new(MockUserUsecase)
+			walletService := new(MockWalletService)
+			transferRepo := new(MockTransferRepository)
+			authorizationService := new(MockAuthorizationService)
+			tt.setup(userUsecase, authorizationService, walletService)
+			transferRepo.On("CreateTransfer", mock.Anything).Return(nil)
+			transferRepo.On("CreateTransaction", mock.Anything).Return(nil)
+			transferRepo.On("UpdateTransactionStatus", mock.Anything).Return(nil)
+
+			transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
+
+			err := transferService.Transfer(tt.value, 1, 2)
+
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestTransferRejectsSelfTransfer(t *testing.T) {
+	userUsecase := new(MockUserUsecase)
+	walletService := new(MockWalletService)
+	transferRepo := new(MockTransferRepository)
+	authorizationService := new(MockAuthorizationService)
+
+	transferService := NewTransferService(userUsecase, walletService, transferRepo, &MockUnitOfWork{transferRepo, walletService}, authorizationService)
+
+	err := transferService.Transfer(decimal.NewFromInt(10), 1, 1)
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	transferRepo.AssertNotCalled(t, "CreateTransfer", mock.Anything)
+	transferRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+	authorizationService.AssertNotCalled(t, "CheckAuthorization")
+	walletService.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferUserLookupErrors(t *testing.T) {
+	outage := errors.New("conexão com o banco perdida")
+	payer := &user.User{ID: 1, UserType: user.CommonUser}
+
+	cases := []struct {
+		name     string
+		payerErr error
+		payeeErr error
+		want     error
+		notFound bool
+	}{
+		{"pagador inexistente", user.ErrUserNotFound, nil, apperrors.ErrPayerNotFound, true},
+		{"recebedor inexistente", nil, user.ErrUserNotFound, apperrors.ErrPayeeNotFound, true},
+		{"banco fora do ar", outage, nil, outage, false},
+		{"banco fora do ar no recebedor", nil, outage, outage, false},
+	}
+
+	for _, c := range cases {
+		userUsecase := new(MockUserUsecase)
+		userUsecase.On("GetUser", 1).Return(payer, c.payerErr)
+		userUsecase.On("GetUser", 2).Return(&user.User{ID: 2}, c.payeeErr)
+		transferService := NewTransferService(userUsecase, new(MockWalletService), new(MockTransferRepository), &MockUnitOfWork{}, new(MockAuthorizationService))
+
+		err := transferService.Transfer(decimal.NewFromInt(10), 1, 2)
+		assert.True(t, errors.Is(err, c.want), "%s: %v", c.name, err)
+		if !c.notFound {
+			assert.False(t, errors.Is(err, apperrors.ErrPayerNotFound) || errors.Is(err, apperrors.ErrPayeeNotFound), "%s: %v", c.name, err)
+		}
+	}
+}
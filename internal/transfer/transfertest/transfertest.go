@@ -0,0 +1,424 @@
+package transfertest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/pagination"
+	"pag-simples/internal/transfer"
+	"pag-simples/pkg/authorization"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func RunTransferRepositoryTests(t *testing.T, newRepo func(t *testing.T) transfer.TransferRepository) {
+	t.Run("CreateTransferAndTransaction", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		require.NoError(t, repo.CreateTransaction(newTransaction("tx1", "t1")))
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx1", transfer.StatusSettled, transfer.StatusRefunded)))
+	})
+
+	t.Run("Uniqueness", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		require.NoError(t, repo.CreateTransaction(newTransaction("tx1", "t1")))
+
+		err := repo.CreateTransfer(newTransfer("t1"))
+		assert.True(t, errors.Is(err, transfer.ErrTransferAlreadyExists), "CreateTransfer: %v", err)
+
+		err = repo.CreateTransaction(newTransaction("tx1", "t1"))
+		assert.True(t, errors.Is(err, transfer.ErrTransactionAlreadyExists), "CreateTransaction: %v", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.UpdateTransactionStatus(change("inexistente", transfer.StatusSettled, transfer.StatusRefunded))
+		assert.True(t, errors.Is(err, transfer.ErrTransactionNotFound), "UpdateTransactionStatus: %v", err)
+	})
+
+	t.Run("GetTransferAndTransactions", func(t *testing.T) {
+		repo := newRepo(t)
+		created := newTransfer("t1")
+		require.NoError(t, repo.CreateTransfer(created))
+		transaction := newTransaction("tx1", "t1")
+		require.NoError(t, repo.CreateTransaction(transaction))
+
+		found, err := repo.GetTransfer("t1")
+		require.NoError(t, err)
+		assert.Equal(t, "t1", found.ID)
+		assert.True(t, found.Value.Equal(created.Value))
+		assert.Equal(t, 1, found.Payer)
+		assert.Equal(t, 2, found.Payee)
+		assert.Equal(t, created.CreatedAt.UnixMilli(), found.CreatedAt.UnixMilli())
+
+		transactions, err := repo.GetTransactions("t1")
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, "tx1", transactions[0].ID)
+		assert.Equal(t, transfer.StatusSettled, transactions[0].Status)
+		assert.Equal(t, transaction.CreatedAt.UnixMilli(), transactions[0].CreatedAt.UnixMilli())
+
+		_, err = repo.GetTransfer("inexistente")
+		assert.True(t, errors.Is(err, transfer.ErrTransferNotFound), "GetTransfer: %v", err)
+
+		transactions, err = repo.GetTransactions("inexistente")
+		require.NoError(t, err)
+		assert.Empty(t, transactions)
+	})
+
+	t.Run("ListTransfersNewestFirstWithCursor", func(t *testing.T) {
+		repo := newRepo(t)
+		for i := 1; i <= 5; i++ {
+			require.NoError(t, repo.CreateTransfer(newTransfer(fmt.Sprintf("t%d", i))))
+		}
+
+		first, cursor, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t5", "t4"}, transferIDs(first))
+		require.NotEmpty(t, cursor)
+
+		second, cursor, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, Limit: 2, Cursor: cursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t3", "t2"}, transferIDs(second))
+		require.NotEmpty(t, cursor)
+
+		last, cursor, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, Limit: 2, Cursor: cursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t1"}, transferIDs(last))
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("ListTransfersFilters", func(t *testing.T) {
+		repo := newRepo(t)
+		base := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+		sent := newTransfer("enviada")
+		sent.CreatedAt = base
+		received := newTransfer("recebida")
+		received.Payer, received.Payee = 2, 1
+		received.CreatedAt = base.Add(24 * time.Hour)
+		unrelated := newTransfer("outra")
+		unrelated.Payer, unrelated.Payee = 2, 3
+		unrelated.CreatedAt = base
+
+		for _, tr := range []*transfer.Transfer{sent, received, unrelated} {
+			require.NoError(t, repo.CreateTransfer(tr))
+		}
+
+		all, _, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"recebida", "enviada"}, transferIDs(all))
+
+		onlySent, _, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, Direction: transfer.Sent})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"enviada"}, transferIDs(onlySent))
+
+		onlyReceived, _, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, Direction: transfer.Received})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"recebida"}, transferIDs(onlyReceived))
+
+		inRange, _, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, From: base.Add(time.Hour), To: base.Add(48 * time.Hour)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"recebida"}, transferIDs(inRange))
+
+		excludesTo, _, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, To: base.Add(24 * time.Hour)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"enviada"}, transferIDs(excludesTo))
+	})
+
+	t.Run("GetRefunds", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		require.NoError(t, repo.CreateTransfer(newTransfer("outra")))
+
+		for _, id := range []string{"e1", "e2"} {
+			refund := newTransfer(id)
+			refund.Payer, refund.Payee = 2, 1
+			refund.RefundOf = "t1"
+			refund.Reason = transfer.ReasonCustomerReturn
+			require.NoError(t, repo.CreateTransfer(refund))
+		}
+
+		refunds, err := repo.GetRefunds("t1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e1", "e2"}, transferIDs(refunds))
+		assert.Equal(t, "t1", refunds[0].RefundOf)
+		assert.Equal(t, transfer.ReasonCustomerReturn, refunds[0].Reason)
+
+		refunds, err = repo.GetRefunds("outra")
+		require.NoError(t, err)
+		assert.Empty(t, refunds)
+
+		original, err := repo.GetTransfer("t1")
+		require.NoError(t, err)
+		assert.Empty(t, original.RefundOf)
+	})
+
+	t.Run("ClaimPendingTransfers", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.UnixMilli(time.Now().UnixMilli())
+		for _, id := range []string{"t1", "t2", "t3"} {
+			tr := newTransfer(id)
+			tr.CallbackURL = "https://loja.example/" + id
+			require.NoError(t, repo.CreateTransfer(tr))
+		}
+		for _, id := range []string{"t1", "t3"} {
+			created := newTransaction("tx-"+id, id)
+			created.Status = transfer.StatusCreated
+			require.NoError(t, repo.CreateTransaction(created))
+		}
+		require.NoError(t, repo.CreateTransaction(newTransaction("tx-t2", "t2")))
+
+		pending, err := repo.ClaimPendingTransfers("a", now, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t1", "t3"}, transferIDs(pending))
+		assert.Equal(t, "https://loja.example/t1", pending[0].CallbackURL)
+		assert.Equal(t, "a", pending[0].LeaseOwner)
+		assert.True(t, pending[0].LeaseUntil.Equal(now.Add(time.Minute)))
+
+		// Enquanto o lease de a vale, b não fica com nada.
+		pending, err = repo.ClaimPendingTransfers("b", now.Add(time.Second), now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		extended, err := repo.ExtendLease("t1", "a", now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, extended)
+		extended, err = repo.ExtendLease("t1", "b", now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, extended)
+
+		// authorizing e authorized também ficam pendentes até um desfecho.
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx-t1", transfer.StatusCreated, transfer.StatusAuthorizing)))
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx-t3", transfer.StatusCreated, transfer.StatusAuthorizing)))
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx-t3", transfer.StatusAuthorizing, transfer.StatusAuthorized)))
+		pending, err = repo.ClaimPendingTransfers("b", now.Add(2*time.Minute), now.Add(3*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t3"}, transferIDs(pending), "t1 teve o lease renovado por a")
+
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx-t1", transfer.StatusAuthorizing, transfer.StatusDeclined)))
+		pending, err = repo.ClaimPendingTransfers("b", now.Add(2*time.Hour), now.Add(3*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t3"}, transferIDs(pending))
+		assert.Equal(t, "b", pending[0].LeaseOwner)
+
+		extended, err = repo.ExtendLease("inexistente", "b", now)
+		require.NoError(t, err)
+		assert.False(t, extended)
+	})
+
+	t.Run("ConcurrentClaims", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.UnixMilli(time.Now().UnixMilli())
+		for i := 0; i < 5; i++ {
+			id := fmt.Sprintf("t%d", i)
+			require.NoError(t, repo.CreateTransfer(newTransfer(id)))
+			created := newTransaction("tx-"+id, id)
+			created.Status = transfer.StatusCreated
+			require.NoError(t, repo.CreateTransaction(created))
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		claims := map[string]int{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(owner string) {
+				defer wg.Done()
+				claimed, err := repo.ClaimPendingTransfers(owner, now, now.Add(time.Minute))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, tr := range claimed {
+					claims[tr.ID]++
+				}
+			}(fmt.Sprintf("instancia-%d", i))
+		}
+		wg.Wait()
+
+		assert.Len(t, claims, 5)
+		for id, count := range claims {
+			assert.Equal(t, 1, count, id)
+		}
+	})
+
+	t.Run("ListTransfersRejectsInvalidCursor", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, _, err := repo.ListTransfers(transfer.TransferFilter{UserID: 1, Cursor: "%%%"})
+		assert.True(t, errors.Is(err, pagination.ErrInvalidCursor), "ListTransfers: %v", err)
+	})
+
+	t.Run("ConcurrentCreates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				transferID := fmt.Sprintf("t%d", i)
+				assert.NoError(t, repo.CreateTransfer(newTransfer(transferID)))
+				assert.NoError(t, repo.CreateTransaction(newTransaction("tx-"+transferID, transferID)))
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, repo.UpdateTransactionStatus(change(fmt.Sprintf("tx-t%d", i), transfer.StatusSettled, transfer.StatusRefunded)))
+		}
+	})
+
+	t.Run("StatusHistory", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		created := newTransaction("tx1", "t1")
+		created.Status = transfer.StatusCreated
+		require.NoError(t, repo.CreateTransaction(created))
+
+		for _, next := range []transfer.Status{transfer.StatusAuthorizing, transfer.StatusAuthorized, transfer.StatusSettled} {
+			current, err := repo.GetTransactions("t1")
+			require.NoError(t, err)
+			require.NoError(t, repo.UpdateTransactionStatus(change("tx1", current[0].Status, next)))
+		}
+
+		history, err := repo.GetStatusHistory("t1")
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		assert.Equal(t, transfer.Status(""), history[0].From)
+		assert.Equal(t, transfer.StatusCreated, history[0].To)
+		assert.Equal(t, transfer.StatusAuthorized, history[3].From)
+		assert.Equal(t, transfer.StatusSettled, history[3].To)
+		assert.Equal(t, "tx1", history[3].TransactionID)
+
+		history, err = repo.GetStatusHistory("inexistente")
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("StoresAuthorizationDecision", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		created := newTransaction("tx1", "t1")
+		created.Status = transfer.StatusCreated
+		require.NoError(t, repo.CreateTransaction(created))
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx1", transfer.StatusCreated, transfer.StatusAuthorizing)))
+
+		decision := &authorization.Decision{Authorized: true, ReasonCode: authorization.ReasonApproved, ReferenceID: "ref-1"}
+		authorized := change("tx1", transfer.StatusAuthorizing, transfer.StatusAuthorized)
+		authorized.Decision = decision
+		require.NoError(t, repo.UpdateTransactionStatus(authorized))
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx1", transfer.StatusAuthorized, transfer.StatusSettled)))
+
+		transactions, err := repo.GetTransactions("t1")
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, decision, transactions[0].Authorization, "transições sem decisão mantêm a gravada")
+	})
+
+	t.Run("RejectsInvalidTransitions", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		require.NoError(t, repo.CreateTransaction(newTransaction("tx1", "t1")))
+
+		invalid := newTransaction("tx2", "t1")
+		invalid.Status = transfer.StatusRefunded
+		err := repo.CreateTransaction(invalid)
+		assert.True(t, errors.Is(err, transfer.ErrInvalidTransition), "CreateTransaction: %v", err)
+
+		err = repo.UpdateTransactionStatus(change("tx1", transfer.StatusSettled, transfer.StatusAuthorizing))
+		assert.True(t, errors.Is(err, transfer.ErrInvalidTransition), "UpdateTransactionStatus: %v", err)
+
+		// From desatualizado: outra transição já tirou a transação de created.
+		err = repo.UpdateTransactionStatus(change("tx1", transfer.StatusCreated, transfer.StatusAuthorizing))
+		assert.True(t, errors.Is(err, transfer.ErrInvalidTransition), "UpdateTransactionStatus: %v", err)
+
+		require.NoError(t, repo.UpdateTransactionStatus(change("tx1", transfer.StatusSettled, transfer.StatusRefunded)))
+		err = repo.UpdateTransactionStatus(change("tx1", transfer.StatusRefunded, transfer.StatusPartiallyRefunded))
+		assert.True(t, errors.Is(err, transfer.ErrInvalidTransition), "UpdateTransactionStatus: %v", err)
+
+		transactions, err := repo.GetTransactions("t1")
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, transfer.StatusRefunded, transactions[0].Status)
+
+		history, err := repo.GetStatusHistory("t1")
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+
+	t.Run("ConcurrentTransitionsFromSameStatus", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.CreateTransfer(newTransfer("t1")))
+		require.NoError(t, repo.CreateTransaction(newTransaction("tx1", "t1")))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := repo.UpdateTransactionStatus(change("tx1", transfer.StatusSettled, transfer.StatusReversed)); err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, succeeded)
+		history, err := repo.GetStatusHistory("t1")
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+}
+
+func change(transactionID string, from transfer.Status, to transfer.Status) *transfer.StatusChange {
+	return &transfer.StatusChange{
+		TransactionID: transactionID,
+		From:          from,
+		To:            to,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func newTransfer(id string) *transfer.Transfer {
+	return &transfer.Transfer{
+		ID:        id,
+		Value:     decimal.NewFromInt(100),
+		Payer:     1,
+		Payee:     2,
+		CreatedAt: time.Now(),
+	}
+}
+
+func transferIDs(transfers []transfer.Transfer) []string {
+	ids := make([]string, 0, len(transfers))
+	for _, tr := range transfers {
+		ids = append(ids, tr.ID)
+	}
+	return ids
+}
+
+func newTransaction(id string, transferID string) *transfer.Transaction {
+	return &transfer.Transaction{
+		ID:         id,
+		TransferID: transferID,
+		Amount:     decimal.NewFromInt(100),
+		Status:     transfer.StatusSettled,
+		CreatedAt:  time.Now(),
+	}
+}
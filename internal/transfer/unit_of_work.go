@@ -0,0 +1,99 @@
+package transfer
+
+import (
+	"pag-simples/internal/database"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/wallet"
+)
+
+type Tx interface {
+	Transfers() TransferRepository
+	Wallets() wallet.WalletUseCase
+	Outbox() outbox.OutboxRepository
+}
+
+type UnitOfWork interface {
+	Do(fn func(tx Tx) error) error
+}
+
+type MemoryUnitOfWork struct {
+	transferRepo *MemoryTransferRepository
+	ledgerRepo   *ledger.MemoryLedgerRepository
+	outboxRepo   *outbox.MemoryOutboxRepository
+}
+
+func NewMemoryUnitOfWork(transferRepo *MemoryTransferRepository, ledgerRepo *ledger.MemoryLedgerRepository, outboxRepo *outbox.MemoryOutboxRepository) *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{
+		transferRepo: transferRepo,
+		ledgerRepo:   ledgerRepo,
+		outboxRepo:   outboxRepo,
+	}
+}
+
+func (u *MemoryUnitOfWork) Do(fn func(tx Tx) error) error {
+	transferTx := u.transferRepo.Begin()
+	ledgerTx := u.ledgerRepo.Begin()
+	outboxTx := u.outboxRepo.Begin()
+
+	rollback := func() {
+		outboxTx.Rollback()
+		ledgerTx.Rollback()
+		transferTx.Rollback()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(&repositoryTx{transfers: transferTx, wallets: wallet.NewWalletService(ledgerTx), outbox: outboxTx}); err != nil {
+		rollback()
+		return err
+	}
+
+	outboxTx.Commit()
+	ledgerTx.Commit()
+	transferTx.Commit()
+	return nil
+}
+
+type repositoryTx struct {
+	transfers TransferRepository
+	wallets   wallet.WalletUseCase
+	outbox    outbox.OutboxRepository
+}
+
+func (t *repositoryTx) Transfers() TransferRepository {
+	return t.transfers
+}
+
+func (t *repositoryTx) Wallets() wallet.WalletUseCase {
+	return t.wallets
+}
+
+func (t *repositoryTx) Outbox() outbox.OutboxRepository {
+	return t.outbox
+}
+
+type SQLUnitOfWork struct {
+	db *database.DB
+}
+
+func NewSQLUnitOfWork(db *database.DB) *SQLUnitOfWork {
+	return &SQLUnitOfWork{
+		db: db,
+	}
+}
+
+func (u *SQLUnitOfWork) Do(fn func(tx Tx) error) error {
+	return database.RunInTx(u.db, func(tx database.Executor) error {
+		return fn(&repositoryTx{
+			transfers: NewSQLTransferRepository(tx),
+			wallets:   wallet.NewWalletService(ledger.NewSQLLedgerRepository(tx)),
+			outbox:    outbox.NewSQLOutboxRepository(tx),
+		})
+	})
+}
@@ -0,0 +1,257 @@
+package transfer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/outbox"
+	"pag-simples/internal/user"
+	"pag-simples/internal/wallet"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type faultyUnitOfWork struct {
+	inner  UnitOfWork
+	failAt string
+}
+
+func (u *faultyUnitOfWork) Do(fn func(tx Tx) error) error {
+	return u.inner.Do(func(tx Tx) error {
+		return fn(&faultyTx{inner: tx, failAt: u.failAt})
+	})
+}
+
+type faultyTx struct {
+	inner  Tx
+	failAt string
+}
+
+func (t *faultyTx) Transfers() TransferRepository {
+	return &faultyTransferRepository{inner: t.inner.Transfers(), failAt: t.failAt}
+}
+
+func (t *faultyTx) Wallets() wallet.WalletUseCase {
+	return &faultyWalletService{WalletUseCase: t.inner.Wallets(), failAt: t.failAt}
+}
+
+func (t *faultyTx) Outbox() outbox.OutboxRepository {
+	return &faultyOutboxRepository{OutboxRepository: t.inner.Outbox(), failAt: t.failAt}
+}
+
+type faultyTransferRepository struct {
+	inner  TransferRepository
+	failAt string
+}
+
+func (r *faultyTransferRepository) CreateTransfer(transfer *Transfer) error {
+	if err := r.inner.CreateTransfer(transfer); err != nil {
+		return err
+	}
+	if r.failAt == "create_transfer" {
+		return fmt.Errorf("falha injetada")
+	}
+	return nil
+}
+
+func (r *faultyTransferRepository) CreateTransaction(transaction *Transaction) error {
+	if err := r.inner.CreateTransaction(transaction); err != nil {
+		return err
+	}
+	if r.failAt == "create_transaction" {
+		return fmt.Errorf("falha injetada")
+	}
+	return nil
+}
+
+func (r *faultyTransferRepository) UpdateTransactionStatus(change *StatusChange) error {
+	if err := r.inner.UpdateTransactionStatus(change); err != nil {
+		return err
+	}
+	if r.failAt == "update_transaction_status" {
+		return fmt.Errorf("falha injetada")
+	}
+	return nil
+}
+
+func (r *faultyTransferRepository) GetStatusHistory(transferID string) ([]StatusChange, error) {
+	return r.inner.GetStatusHistory(transferID)
+}
+
+func (r *faultyTransferRepository) GetTransfer(transferID string) (*Transfer, error) {
+	return r.inner.GetTransfer(transferID)
+}
+
+func (r *faultyTransferRepository) GetTransactions(transferID string) ([]Transaction, error) {
+	return r.inner.GetTransactions(transferID)
+}
+
+func (r *faultyTransferRepository) ListTransfers(filter TransferFilter) ([]Transfer, string, error) {
+	return r.inner.ListTransfers(filter)
+}
+
+func (r *faultyTransferRepository) GetRefunds(transferID string) ([]Transfer, error) {
+	return r.inner.GetRefunds(transferID)
+}
+
+func (r *faultyTransferRepository) ClaimPendingTransfers(owner string, now time.Time, until time.Time) ([]Transfer, error) {
+	return r.inner.ClaimPendingTransfers(owner, now, until)
+}
+
+func (r *faultyTransferRepository) ExtendLease(transferID string, owner string, until time.Time) (bool, error) {
+	return r.inner.ExtendLease(transferID, owner, until)
+}
+
+type faultyWalletService struct {
+	wallet.WalletUseCase
+	failAt string
+}
+
+func (s *faultyWalletService) Transfer(payer *wallet.Wallet, payeeID int, amount decimal.Decimal, reference string) error {
+	if err := s.WalletUseCase.Transfer(payer, payeeID, amount, reference); err != nil {
+		return err
+	}
+	if s.failAt == "wallet_transfer" {
+		return fmt.Errorf("falha injetada")
+	}
+	return nil
+}
+
+type faultyOutboxRepository struct {
+	outbox.OutboxRepository
+	failAt string
+}
+
+func (r *faultyOutboxRepository) Add(message *outbox.Message) error {
+	if err := r.OutboxRepository.Add(message); err != nil {
+		return err
+	}
+	if r.failAt == "outbox_add" {
+		return fmt.Errorf("falha injetada")
+	}
+	return nil
+}
+
+func TestTransferRollsBackWhenAnyStepFails(t *testing.T) {
+	// recorded vazio indica que nem a transferência chegou a ser gravada;
+	// falhas depois do registro deixam a transação como failed.
+	steps := []struct {
+		failAt   string
+		expected string
+		recorded Status
+	}{
+		{"create_transfer", "falha ao salvar a transferência: falha injetada", ""},
+		{"create_transaction", "falha ao salvar a transação: falha injetada", ""},
+		{"wallet_transfer", "falha ao movimentar o saldo de 1 para 2: falha injetada", StatusFailed},
+		{"update_transaction_status", "falha ao atualizar a transação: falha injetada", StatusFailed},
+		{"outbox_add", "falha ao registrar a notificação: falha injetada", StatusFailed},
+	}
+
+	for _, step := range steps {
+		t.Run(step.failAt, func(t *testing.T) {
+			userRepo := user.NewMemoryUserRepository()
+			userRepo.SaveUser(&user.User{ID: 1, FullName: "Payer", Email: "payer@email.com", DocumentNumber: "1", UserType: user.CommonUser})
+			userRepo.SaveUser(&user.User{ID: 2, FullName: "Payee", Email: "payee@email.com", DocumentNumber: "2", UserType: user.CommonUser})
+
+			ledgerRepo := ledger.NewMemoryLedgerRepository()
+			walletService := wallet.NewWalletService(ledgerRepo)
+			walletService.CreateWallet(1, decimal.NewFromInt(1000))
+			walletService.CreateWallet(2, decimal.NewFromInt(500))
+
+			transferRepo := NewMemoryTransferRepository()
+			outboxRepo := outbox.NewMemoryOutboxRepository()
+			unitOfWork := &faultyUnitOfWork{
+				inner:  NewMemoryUnitOfWork(transferRepo, ledgerRepo, outboxRepo),
+				failAt: step.failAt,
+			}
+
+			authorizationService := new(MockAuthorizationService)
+			authorizationService.On("CheckAuthorization").Return(true, nil)
+
+			transferService := NewTransferService(user.NewUserService(userRepo), walletService, transferRepo, unitOfWork, authorizationService)
+
+			err := transferService.Transfer(decimal.NewFromInt(100), 1, 2)
+
+			assert.Error(t, err)
+			assert.Equal(t, step.expected, err.Error())
+
+			payerBalance, _ := walletService.GetBalance(1)
+			payeeBalance, _ := walletService.GetBalance(2)
+			fundingBalance, _ := ledgerRepo.GetBalance(ledger.FundingAccountID)
+			assert.True(t, payerBalance.Equal(decimal.NewFromInt(1000)))
+			assert.True(t, payeeBalance.Equal(decimal.NewFromInt(500)))
+			assert.True(t, fundingBalance.Equal(decimal.NewFromInt(-1500)))
+
+			entries, _ := ledgerRepo.GetEntries(ledger.WalletAccountID(1))
+			assert.Len(t, entries, 1)
+
+			messages, _, err := outboxRepo.List(outbox.MessageFilter{})
+			require.NoError(t, err)
+			assert.Empty(t, messages)
+			if step.recorded == "" {
+				assert.Empty(t, transferRepo.transfers)
+				assert.Empty(t, transferRepo.transactions)
+				return
+			}
+
+			require.Len(t, transferRepo.transactions, 1)
+			for _, transaction := range transferRepo.transactions {
+				assert.Equal(t, step.recorded, transaction.Status)
+			}
+		})
+	}
+}
+
+func TestMemoryUnitOfWorkCommitsAllWrites(t *testing.T) {
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	walletService := wallet.NewWalletService(ledgerRepo)
+	walletService.CreateWallet(1, decimal.NewFromInt(1000))
+	walletService.CreateWallet(2, decimal.NewFromInt(500))
+	transferRepo := NewMemoryTransferRepository()
+
+	err := NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository()).Do(func(tx Tx) error {
+		if err := tx.Transfers().CreateTransfer(&Transfer{ID: "t1", Value: decimal.NewFromInt(100), Payer: 1, Payee: 2}); err != nil {
+			return err
+		}
+		payer, err := tx.Wallets().GetWallet(1)
+		if err != nil {
+			return err
+		}
+		if err := tx.Wallets().Transfer(payer, 2, decimal.NewFromInt(100), "t1"); err != nil {
+			return err
+		}
+		return tx.Transfers().CreateTransaction(&Transaction{ID: "tx1", TransferID: "t1", Amount: decimal.NewFromInt(100), Status: StatusSettled})
+	})
+
+	assert.NoError(t, err)
+
+	payerBalance, _ := walletService.GetBalance(1)
+	payeeBalance, _ := walletService.GetBalance(2)
+	assert.True(t, payerBalance.Equal(decimal.NewFromInt(900)))
+	assert.True(t, payeeBalance.Equal(decimal.NewFromInt(600)))
+	assert.Len(t, transferRepo.transfers, 1)
+	assert.Len(t, transferRepo.transactions, 1)
+}
+
+func TestMemoryUnitOfWorkRollsBackOnPanic(t *testing.T) {
+	ledgerRepo := ledger.NewMemoryLedgerRepository()
+	walletService := wallet.NewWalletService(ledgerRepo)
+	walletService.CreateWallet(1, decimal.NewFromInt(1000))
+	walletService.CreateWallet(2, decimal.NewFromInt(500))
+	transferRepo := NewMemoryTransferRepository()
+
+	assert.Panics(t, func() {
+		NewMemoryUnitOfWork(transferRepo, ledgerRepo, outbox.NewMemoryOutboxRepository()).Do(func(tx Tx) error {
+			payer, _ := tx.Wallets().GetWallet(1)
+			tx.Wallets().Transfer(payer, 2, decimal.NewFromInt(100), "t1")
+			panic("boom")
+		})
+	})
+
+	payerBalance, _ := walletService.GetBalance(1)
+	assert.True(t, payerBalance.Equal(decimal.NewFromInt(1000)))
+}
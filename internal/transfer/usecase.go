@@ -1,7 +1,18 @@
 package transfer
 
-import "github.com/shopspring/decimal"
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
 
 type TransferUsecase interface {
 	Transfer(value decimal.Decimal, payerID int, payeeID int) error
-}
\ No newline at end of file
+	Execute(ctx context.Context, request TransferRequest) error
+	Submit(request TransferRequest) (*TransferDetails, error)
+	Resume() (int, error)
+	GetTransfer(transferID string) (*TransferDetails, error)
+	GetStatusHistory(transferID string) ([]StatusChange, error)
+	ListTransfers(filter TransferFilter) (*TransferPage, error)
+	Refund(transferID string, request RefundRequest) (*Transfer, error)
+}
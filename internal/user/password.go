@@ -0,0 +1,72 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	MinPasswordLength = 8
+	// MaxPasswordBytes é o limite do bcrypt; bytes além dele seriam ignorados.
+	MaxPasswordBytes = 72
+
+	ResetTokenTTL = 30 * time.Minute
+)
+
+// passwordCost é reduzido nos testes.
+var passwordCost = bcrypt.DefaultCost
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar hash da senha: %v", err)
+	}
+	return string(hash), nil
+}
+
+// legacyPassword indica uma senha gravada em texto puro, antes do bcrypt.
+func legacyPassword(stored string) bool {
+	return !strings.HasPrefix(stored, "$2a$") && !strings.HasPrefix(stored, "$2b$") && !strings.HasPrefix(stored, "$2y$")
+}
+
+func checkPassword(stored string, password string) bool {
+	if legacyPassword(stored) {
+		return stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
+
+// PasswordReset é um pedido de redefinição de senha. Só o hash do token é
+// gravado; o token em si vai apenas para o usuário.
+type PasswordReset struct {
+	TokenHash string
+	UserID    int
+	ExpiresAt time.Time
+	UsedAt    time.Time
+	CreatedAt time.Time
+}
+
+func (r *PasswordReset) Usable(now time.Time) bool {
+	return r.UsedAt.IsZero() && now.Before(r.ExpiresAt)
+}
+
+func newResetToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("erro ao gerar token de redefinição: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
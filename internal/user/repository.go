@@ -1,6 +1,17 @@
 package user
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"pag-simples/internal/apperrors"
+)
+
+var (
+	ErrUserNotFound      = apperrors.ErrUserNotFound
+	ErrUserAlreadyExists = apperrors.ErrUserAlreadyExists
+)
 
 type UserRepository interface {
 	GetUserByEmail(email string) (*User, error)
@@ -12,6 +23,7 @@ type UserRepository interface {
 }
 
 type MemoryUserRepository struct {
+	mu    sync.RWMutex
 	users []User
 }
 
@@ -22,47 +34,82 @@ func NewMemoryUserRepository() *MemoryUserRepository {
 }
 
 func (r *MemoryUserRepository) GetUserByEmail(email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, user := range r.users {
 		if user.Email == email {
 			return &user, nil
 		}
 	}
-	return nil, nil
+	return nil, fmt.Errorf("%w: e-mail %s", ErrUserNotFound, email)
 }
 
 func (r *MemoryUserRepository) GetUserByDocumentNumber(documentNumber string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, user := range r.users {
 		if user.DocumentNumber == documentNumber {
 			return &user, nil
 		}
 	}
-	return nil, nil
+	return nil, fmt.Errorf("%w: documento %s", ErrUserNotFound, documentNumber)
 }
 
 func (r *MemoryUserRepository) GetUser(userID int) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, user := range r.users {
 		if user.ID == userID {
 			return &user, nil
 		}
 	}
-	return nil, fmt.Errorf("usuário com ID %d não encontrado", userID)
+	return nil, fmt.Errorf("%w: ID %d", ErrUserNotFound, userID)
 }
 
 func (r *MemoryUserRepository) GetAllUsers() ([]User, error) {
-	return r.users, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := append([]User{}, r.users...)
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users, nil
 }
 
 func (r *MemoryUserRepository) SaveUser(user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nextID := 1
+	for _, u := range r.users {
+		if u.ID == user.ID || u.Email == user.Email || u.DocumentNumber == user.DocumentNumber {
+			return fmt.Errorf("%w: ID %d", ErrUserAlreadyExists, user.ID)
+		}
+		if u.ID >= nextID {
+			nextID = u.ID + 1
+		}
+	}
+
+	if user.ID == 0 {
+		user.ID = nextID
+	}
 	r.users = append(r.users, *user)
 	return nil
 }
 
 func (r *MemoryUserRepository) UpdateUser(user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	for i, u := range r.users {
 		if u.ID == user.ID {
 			r.users[i] = *user
 			return nil
 		}
 	}
-	return fmt.Errorf("usuário com ID %d não encontrado", user.ID)
+	return fmt.Errorf("%w: ID %d", ErrUserNotFound, user.ID)
 }
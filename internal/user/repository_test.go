@@ -0,0 +1,79 @@
+package user_test
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"pag-simples/internal/database"
+	"pag-simples/internal/database/databasetest"
+	"pag-simples/internal/user"
+	"pag-simples/internal/user/usertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	usertest.RunUserRepositoryTests(t, func(t *testing.T) user.UserRepository {
+		return user.NewMemoryUserRepository()
+	})
+}
+
+func TestSQLUserRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			usertest.RunUserRepositoryTests(t, func(t *testing.T) user.UserRepository {
+				return user.NewSQLUserRepository(databasetest.Open(t, driver))
+			})
+		})
+	}
+}
+
+// skipExistingCheck faz a verificação prévia do SaveUser não encontrar nada,
+// como quando um cadastro concorrente é gravado entre ela e o INSERT.
+type skipExistingCheck struct {
+	database.Executor
+}
+
+func (e skipExistingCheck) QueryRow(query string, args ...any) *sql.Row {
+	if strings.HasPrefix(query, "SELECT COUNT(*) FROM users") {
+		return e.Executor.QueryRow("SELECT 0")
+	}
+	return e.Executor.QueryRow(query, args...)
+}
+
+func TestSQLSaveUserReportsUniqueViolationAsDuplicate(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			repo := user.NewSQLUserRepository(skipExistingCheck{databasetest.Open(t, driver)})
+			require.NoError(t, repo.SaveUser(&user.User{FullName: "joao", DocumentNumber: "doc-joao", Email: "joao@email.com", UserType: user.CommonUser}))
+
+			sameEmail := &user.User{FullName: "outro", DocumentNumber: "doc-outro", Email: "joao@email.com", UserType: user.CommonUser}
+			err := repo.SaveUser(sameEmail)
+			assert.True(t, errors.Is(err, user.ErrUserAlreadyExists), "sem ID: %v", err)
+
+			sameID := &user.User{ID: 1, FullName: "outro", DocumentNumber: "doc-outro", Email: "outro@email.com", UserType: user.CommonUser}
+			err = repo.SaveUser(sameID)
+			assert.True(t, errors.Is(err, user.ErrUserAlreadyExists), "com ID: %v", err)
+		})
+	}
+}
+
+func TestMemoryPasswordResetRepository(t *testing.T) {
+	usertest.RunPasswordResetRepositoryTests(t, func(t *testing.T) (user.UserRepository, user.PasswordResetRepository) {
+		return user.NewMemoryUserRepository(), user.NewMemoryPasswordResetRepository()
+	})
+}
+
+func TestSQLPasswordResetRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers {
+		t.Run(driver, func(t *testing.T) {
+			usertest.RunPasswordResetRepositoryTests(t, func(t *testing.T) (user.UserRepository, user.PasswordResetRepository) {
+				db := databasetest.Open(t, driver)
+				return user.NewSQLUserRepository(db), user.NewSQLPasswordResetRepository(db)
+			})
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package user
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+)
+
+var ErrInvalidResetToken = apperrors.ErrInvalidResetToken
+
+type PasswordResetRepository interface {
+	CreateReset(reset *PasswordReset) error
+	// ConsumeReset marca o pedido como usado se ele ainda valer em now; um
+	// token só pode ser consumido uma vez.
+	ConsumeReset(tokenHash string, now time.Time) (*PasswordReset, error)
+}
+
+type MemoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	resets map[string]PasswordReset
+}
+
+func NewMemoryPasswordResetRepository() *MemoryPasswordResetRepository {
+	return &MemoryPasswordResetRepository{
+		resets: make(map[string]PasswordReset),
+	}
+}
+
+func (r *MemoryPasswordResetRepository) CreateReset(reset *PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.resets[reset.TokenHash]; exists {
+		return fmt.Errorf("pedido de redefinição já existe")
+	}
+	r.resets[reset.TokenHash] = *reset
+	return nil
+}
+
+func (r *MemoryPasswordResetRepository) ConsumeReset(tokenHash string, now time.Time) (*PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reset, exists := r.resets[tokenHash]
+	if !exists || !reset.Usable(now) {
+		return nil, ErrInvalidResetToken
+	}
+
+	reset.UsedAt = now
+	r.resets[tokenHash] = reset
+	return &reset, nil
+}
@@ -0,0 +1,36 @@
+package user
+
+// Role define o que o usuário pode fazer na API. Clientes e lojistas só
+// enxergam os próprios dados; suporte e administradores leem tudo.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleMerchant Role = "merchant"
+	RoleSupport  Role = "support"
+	RoleAdmin    Role = "admin"
+)
+
+var Roles = []Role{RoleCustomer, RoleMerchant, RoleSupport, RoleAdmin}
+
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Staff indica os papéis que podem ler dados de qualquer usuário.
+func (r Role) Staff() bool {
+	return r == RoleSupport || r == RoleAdmin
+}
+
+// defaultRole é o papel de quem se cadastra sem um papel definido.
+func defaultRole(userType UserType) Role {
+	if userType == Merchant {
+		return RoleMerchant
+	}
+	return RoleCustomer
+}
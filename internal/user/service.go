@@ -1,43 +1,254 @@
 package user
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/pkg/notification"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = apperrors.ErrInvalidCredentials
+
+// NotificationSender entrega o token de redefinição de senha; é satisfeito
+// por *notification.Service.
+type NotificationSender interface {
+	Send(recipient notification.Recipient, event notification.Event, data notification.Data) error
+}
+
+// SessionRevoker encerra as sessões abertas de um usuário; é satisfeito pelo
+// repositório de refresh tokens.
+type SessionRevoker interface {
+	RevokeUser(userID int, now time.Time) error
+}
 
 type UserService struct {
-    repo UserRepository
+	repo     UserRepository
+	resets   PasswordResetRepository
+	sender   NotificationSender
+	sessions SessionRevoker
+	now      func() time.Time
 }
 
-func NewUserService(repo UserRepository) UserUsecase {
-    return &UserService{
-        repo: repo,
-    }
+type Option func(*UserService)
+
+// WithPasswordReset guarda os pedidos de redefinição em resets e envia os
+// tokens por sender. Sem sender, RequestPasswordReset falha.
+func WithPasswordReset(resets PasswordResetRepository, sender NotificationSender) Option {
+	return func(s *UserService) {
+		s.resets = resets
+		s.sender = sender
+	}
+}
+
+// WithSessionRevoker encerra as sessões do usuário a cada troca ou
+// redefinição de senha, para que um refresh token roubado deixe de valer.
+func WithSessionRevoker(sessions SessionRevoker) Option {
+	return func(s *UserService) {
+		s.sessions = sessions
+	}
+}
+
+func NewUserService(repo UserRepository, opts ...Option) UserUsecase {
+	service := &UserService{
+		repo:   repo,
+		resets: NewMemoryPasswordResetRepository(),
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
 }
 
 func (s *UserService) GetUser(userID int) (*User, error) {
-    return s.repo.GetUser(userID)
+	return s.repo.GetUser(userID)
 }
 
 func (s *UserService) GetAllUsers() ([]User, error) {
-    return s.repo.GetAllUsers()
+	return s.repo.GetAllUsers()
 }
 
 func (s *UserService) ValidateUniqueUser(cpf, email string) error {
-	userByDoc, _ := s.repo.GetUserByDocumentNumber(cpf)
-	if userByDoc != nil {
-			return fmt.Errorf("CPF já cadastrado: %s", cpf)
+	_, err := s.repo.GetUserByDocumentNumber(cpf)
+	if err == nil {
+		return fmt.Errorf("%w: documento %s", ErrUserAlreadyExists, cpf)
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
 	}
 
-	userByEmail, _ := s.repo.GetUserByEmail(email)
-	if userByEmail != nil {
-			return fmt.Errorf("e-mail já cadastrado: %s", email)
+	_, err = s.repo.GetUserByEmail(email)
+	if err == nil {
+		return fmt.Errorf("%w: e-mail %s", ErrUserAlreadyExists, email)
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
 	}
 
 	return nil
 }
 
+// SaveUser grava o usuário com a senha já convertida em hash; user.Password
+// deixa de ter a senha informada.
 func (s *UserService) SaveUser(user *User) error {
+	if err := validateUser(user); err != nil {
+		return err
+	}
+
 	if err := s.ValidateUniqueUser(user.DocumentNumber, user.Email); err != nil {
-			return err
+		return err
+	}
+
+	hash, err := hashPassword(user.Password)
+	if err != nil {
+		return err
 	}
+	user.Password = hash
 
 	return s.repo.SaveUser(user)
 }
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// Authenticate confere e-mail e senha. Senhas ainda em texto puro são
+// convertidas para bcrypt no primeiro login bem-sucedido.
+func (s *UserService) Authenticate(email string, password string) (*User, error) {
+	user, err := s.repo.GetUserByEmail(email)
+	if errors.Is(err, ErrUserNotFound) {
+		// Compara com um hash qualquer para que e-mails inexistentes levem o
+		// mesmo tempo que senhas erradas.
+		dummyHashOnce.Do(func() { dummyHash, _ = bcrypt.GenerateFromPassword([]byte("senha-inexistente"), passwordCost) })
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !checkPassword(user.Password, password) {
+		log.Printf("Tentativa de login inválida para o usuário %d", user.ID)
+		return nil, ErrInvalidCredentials
+	}
+
+	if legacyPassword(user.Password) {
+		if err := s.updatePassword(user, password); err != nil {
+			log.Printf("Erro ao converter a senha legada do usuário %d: %v", user.ID, err)
+		} else {
+			log.Printf("Senha legada do usuário %d convertida para bcrypt", user.ID)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UserService) ChangePassword(userID int, current string, next string) error {
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !checkPassword(user.Password, current) {
+		log.Printf("Senha atual incorreta na troca de senha do usuário %d", userID)
+		return ErrInvalidCredentials
+	}
+
+	if err := validatePassword("new_password", next); err != nil {
+		return err
+	}
+
+	return s.updatePassword(user, next)
+}
+
+// RequestPasswordReset envia um token de uso único ao usuário. Para não
+// revelar quais e-mails estão cadastrados, e-mails desconhecidos não são erro.
+func (s *UserService) RequestPasswordReset(email string) error {
+	if s.sender == nil {
+		return fmt.Errorf("envio do token de redefinição de senha não configurado")
+	}
+
+	user, err := s.repo.GetUserByEmail(email)
+	if errors.Is(err, ErrUserNotFound) {
+		log.Printf("Pedido de redefinição de senha para e-mail não cadastrado")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	err = s.resets.CreateReset(&PasswordReset{
+		TokenHash: hashResetToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(ResetTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// A redefinição chega mesmo a quem desativou as notificações.
+	recipient := user.Recipient()
+	if recipient.Channel == notification.ChannelNone {
+		recipient.Channel = notification.ChannelEmail
+	}
+	if err := s.sender.Send(recipient, notification.EventPasswordReset, notification.Data{Token: token}); err != nil {
+		return fmt.Errorf("erro ao enviar token de redefinição ao usuário %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (s *UserService) ResetPassword(token string, password string) error {
+	if err := validatePassword("password", password); err != nil {
+		return err
+	}
+
+	reset, err := s.resets.ConsumeReset(hashResetToken(token), s.now())
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUser(reset.UserID)
+	if err != nil {
+		return err
+	}
+
+	return s.updatePassword(user, password)
+}
+
+func (s *UserService) updatePassword(user *User, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hash
+	user.PasswordChangedAt = s.now()
+	if err := s.repo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	if s.sessions == nil {
+		return nil
+	}
+	if err := s.sessions.RevokeUser(user.ID, s.now()); err != nil {
+		log.Printf("Erro ao encerrar as sessões do usuário %d após a troca de senha: %v", user.ID, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/validation"
+	"pag-simples/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveUserDefaultsNotificationPreference(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	service := NewUserService(repo)
+
+	require.NoError(t, service.SaveUser(&User{FullName: "João", DocumentNumber: "52998224725", Email: "joao@email.com", Password: "senha123", UserType: CommonUser}))
+
+	saved, err := repo.GetUser(1)
+	require.NoError(t, err)
+	assert.Equal(t, notification.ChannelEmail, saved.NotificationChannel)
+	assert.Equal(t, notification.LocalePtBR, saved.Locale)
+}
+
+func TestSaveUserValidatesNotificationPreference(t *testing.T) {
+	cases := map[string]*User{
+		"canal desconhecido":  {NotificationChannel: "pombo"},
+		"idioma desconhecido": {Locale: "fr-FR"},
+		"sms sem telefone":    {NotificationChannel: notification.ChannelSMS},
+		"webhook sem url":     {NotificationChannel: notification.ChannelWebhook},
+		"webhook inválido":    {NotificationChannel: notification.ChannelWebhook, WebhookURL: "ftp://loja.example"},
+		"webhook interno":     {NotificationChannel: notification.ChannelWebhook, WebhookURL: "http://169.254.169.254/latest/meta-data/"},
+	}
+
+	for name, candidate := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := NewMemoryUserRepository()
+			candidate.FullName, candidate.DocumentNumber, candidate.Email, candidate.Password, candidate.UserType = "João", "52998224725", "joao@email.com", "senha123", CommonUser
+
+			err := NewUserService(repo).SaveUser(candidate)
+			assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "SaveUser: %v", err)
+
+			users, _ := repo.GetAllUsers()
+			assert.Empty(t, users)
+		})
+	}
+
+	valid := &User{
+		FullName:            "Loja",
+		DocumentNumber:      "39053344705",
+		Email:               "loja@email.com",
+		Password:            "senha123",
+		UserType:            CommonUser,
+		WebhookURL:          "https://loja.example/notificacoes",
+		NotificationChannel: notification.ChannelWebhook,
+	}
+	assert.NoError(t, NewUserService(NewMemoryUserRepository()).SaveUser(valid))
+}
+
+type sentNotification struct {
+	recipient notification.Recipient
+	event     notification.Event
+	data      notification.Data
+}
+
+type recordingSender struct {
+	sent []sentNotification
+}
+
+func (s *recordingSender) Send(recipient notification.Recipient, event notification.Event, data notification.Data) error {
+	s.sent = append(s.sent, sentNotification{recipient, event, data})
+	return nil
+}
+
+func newPasswordService(t *testing.T) (*UserService, *MemoryUserRepository, *recordingSender) {
+	repo := NewMemoryUserRepository()
+	sender := &recordingSender{}
+	service := NewUserService(repo, WithPasswordReset(NewMemoryPasswordResetRepository(), sender)).(*UserService)
+
+	require.NoError(t, service.SaveUser(&User{FullName: "João", DocumentNumber: "52998224725", Email: "joao@email.com", Password: "senha123", UserType: CommonUser}))
+	return service, repo, sender
+}
+
+func TestSaveUserHashesPassword(t *testing.T) {
+	service, repo, _ := newPasswordService(t)
+
+	saved, err := repo.GetUser(1)
+	require.NoError(t, err)
+	assert.NotEqual(t, "senha123", saved.Password)
+	assert.False(t, legacyPassword(saved.Password))
+
+	authenticated, err := service.Authenticate("joao@email.com", "senha123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, authenticated.ID)
+
+	for name, password := range map[string]string{"curta": "1234567", "longa": strings.Repeat("a", MaxPasswordBytes+1)} {
+		err := service.SaveUser(&User{FullName: "Maria", DocumentNumber: "39053344705", Email: "maria@email.com", Password: password, UserType: CommonUser})
+		assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "%s: %v", name, err)
+	}
+}
+
+func TestAuthenticateRejectsInvalidCredentials(t *testing.T) {
+	service, _, _ := newPasswordService(t)
+
+	_, err := service.Authenticate("joao@email.com", "errada123")
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "senha errada: %v", err)
+
+	_, err = service.Authenticate("ninguem@email.com", "senha123")
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "e-mail desconhecido: %v", err)
+}
+
+func TestAuthenticateRehashesLegacyPassword(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	require.NoError(t, repo.SaveUser(&User{FullName: "João", DocumentNumber: "52998224725", Email: "joao@email.com", Password: "antiga", UserType: CommonUser}))
+	service := NewUserService(repo)
+
+	_, err := service.Authenticate("joao@email.com", "antiga")
+	require.NoError(t, err)
+
+	saved, err := repo.GetUser(1)
+	require.NoError(t, err)
+	assert.False(t, legacyPassword(saved.Password))
+
+	_, err = service.Authenticate("joao@email.com", "antiga")
+	assert.NoError(t, err)
+}
+
+func TestChangePassword(t *testing.T) {
+	service, _, _ := newPasswordService(t)
+
+	err := service.ChangePassword(1, "errada123", "novasenha1")
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "senha atual errada: %v", err)
+
+	err = service.ChangePassword(1, "senha123", "curta")
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "senha nova inválida: %v", err)
+
+	require.NoError(t, service.ChangePassword(1, "senha123", "novasenha1"))
+
+	_, err = service.Authenticate("joao@email.com", "senha123")
+	assert.True(t, errors.Is(err, ErrInvalidCredentials), "senha antiga: %v", err)
+	_, err = service.Authenticate("joao@email.com", "novasenha1")
+	assert.NoError(t, err)
+}
+
+func TestResetPassword(t *testing.T) {
+	service, _, sender := newPasswordService(t)
+
+	require.NoError(t, service.RequestPasswordReset("joao@email.com"))
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, notification.EventPasswordReset, sender.sent[0].event)
+	assert.Equal(t, "joao@email.com", sender.sent[0].recipient.Email)
+	token := sender.sent[0].data.Token
+	require.NotEmpty(t, token)
+
+	err := service.ResetPassword(token, "curta")
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "senha inválida: %v", err)
+
+	// Uma senha inválida não gasta o token.
+	require.NoError(t, service.ResetPassword(token, "redefinida1"))
+	_, err = service.Authenticate("joao@email.com", "redefinida1")
+	assert.NoError(t, err)
+
+	err = service.ResetPassword(token, "outrasenha1")
+	assert.True(t, errors.Is(err, ErrInvalidResetToken), "token reutilizado: %v", err)
+}
+
+func TestResetPasswordExpiredToken(t *testing.T) {
+	service, _, sender := newPasswordService(t)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	require.NoError(t, service.RequestPasswordReset("joao@email.com"))
+	now = now.Add(ResetTokenTTL)
+
+	err := service.ResetPassword(sender.sent[0].data.Token, "redefinida1")
+	assert.True(t, errors.Is(err, ErrInvalidResetToken), "token expirado: %v", err)
+}
+
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	service, _, sender := newPasswordService(t)
+
+	assert.NoError(t, service.RequestPasswordReset("ninguem@email.com"))
+	assert.Empty(t, sender.sent)
+}
+
+func TestSaveUserDefaultsRole(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	service := NewUserService(repo)
+
+	require.NoError(t, service.SaveUser(&User{FullName: "João", DocumentNumber: "52998224725", Email: "joao@email.com", Password: "senha123", UserType: CommonUser}))
+	require.NoError(t, service.SaveUser(&User{FullName: "Loja", DocumentNumber: "11222333000181", Email: "loja@email.com", Password: "senha123", UserType: Merchant}))
+	require.NoError(t, service.SaveUser(&User{FullName: "Ana", DocumentNumber: "39053344705", Email: "ana@email.com", Password: "senha123", UserType: CommonUser, Role: RoleSupport}))
+
+	for id, role := range map[int]Role{1: RoleCustomer, 2: RoleMerchant, 3: RoleSupport} {
+		saved, err := repo.GetUser(id)
+		require.NoError(t, err)
+		assert.Equal(t, role, saved.Role, "usuário %d", id)
+	}
+
+	err := service.SaveUser(&User{FullName: "Rei", DocumentNumber: "11144477735", Email: "rei@email.com", Password: "senha123", UserType: CommonUser, Role: "root"})
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "SaveUser: %v", err)
+}
+
+func TestSaveUserValidatesDocument(t *testing.T) {
+	cases := []struct {
+		name     string
+		userType UserType
+		document string
+		stored   string
+	}{
+		{"CPF", CommonUser, "52998224725", "52998224725"},
+		{"CPF formatado", CommonUser, "529.982.247-25", "52998224725"},
+		{"CNPJ", Merchant, "11.222.333/0001-81", "11222333000181"},
+		{"CNPJ alfanumérico", Merchant, "12.abc.345/01de-35", "12ABC34501DE35"},
+		{"CPF inválido", CommonUser, "52998224724", ""},
+		{"CNPJ inválido", Merchant, "11222333000182", ""},
+		{"usuário comum com CNPJ", CommonUser, "11222333000181", ""},
+		{"lojista com CPF", Merchant, "52998224725", ""},
+		{"vazio", CommonUser, "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := NewMemoryUserRepository()
+			err := NewUserService(repo).SaveUser(&User{FullName: "João", DocumentNumber: c.document, Email: "joao@email.com", Password: "senha123", UserType: c.userType})
+
+			if c.stored == "" {
+				assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "SaveUser: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			saved, err := repo.GetUser(1)
+			require.NoError(t, err)
+			assert.Equal(t, c.stored, saved.DocumentNumber)
+		})
+	}
+}
+
+func TestSaveUserRejectsDuplicateFormattedDocument(t *testing.T) {
+	service := NewUserService(NewMemoryUserRepository())
+	require.NoError(t, service.SaveUser(&User{FullName: "João", DocumentNumber: "52998224725", Email: "joao@email.com", Password: "senha123", UserType: CommonUser}))
+
+	err := service.SaveUser(&User{FullName: "João", DocumentNumber: "529.982.247-25", Email: "outro@email.com", Password: "senha123", UserType: CommonUser})
+	assert.True(t, errors.Is(err, ErrUserAlreadyExists), "SaveUser: %v", err)
+}
+
+func TestSaveUserReportsEveryInvalidField(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	err := NewUserService(repo).SaveUser(&User{
+		FullName:       " Jo ",
+		DocumentNumber: "123",
+		Email:          "João <joao@email.com>",
+		Password:       "somenteletras",
+		UserType:       "admin",
+	})
+	require.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "SaveUser: %v", err)
+
+	codes := map[string]string{}
+	for _, field := range validation.Fields(err) {
+		codes[field.Field] = field.Code
+	}
+	assert.Equal(t, map[string]string{
+		"FullName":       validation.CodeTooShort,
+		"Email":          validation.CodeInvalidFormat,
+		"Password":       validation.CodeWeakPassword,
+		"UserType":       validation.CodeNotAllowed,
+		"DocumentNumber": validation.CodeInvalidFormat,
+	}, codes)
+
+	users, _ := repo.GetAllUsers()
+	assert.Empty(t, users)
+}
+
+func TestSaveUserValidatesEmail(t *testing.T) {
+	cases := map[string]bool{
+		"joao@email.com":         true,
+		"joao.silva+pix@loja.br": true,
+		"":                       false,
+		"joao":                   false,
+		"joao@localhost":         false,
+		"joao@email.":            false,
+		"joao silva@email.com":   false,
+		"<joao@email.com>":       false,
+		strings.Repeat("a", MaxEmailLength) + "@email.com": false,
+	}
+
+	for email, valid := range cases {
+		var errs validation.Errors
+		validateEmail(&errs, email)
+		assert.Equal(t, valid, len(errs) == 0, "%q: %v", email, errs)
+	}
+}
+
+func TestValidatePasswordStrength(t *testing.T) {
+	cases := map[string]string{
+		"senha123":                             "",
+		"":                                     validation.CodeRequired,
+		"abc123":                               validation.CodeTooShort,
+		"somenteletras":                        validation.CodeWeakPassword,
+		"12345678":                             validation.CodeWeakPassword,
+		strings.Repeat("a1", MaxPasswordBytes): validation.CodeTooLong,
+	}
+
+	for password, code := range cases {
+		fields := validation.Fields(validatePassword("password", password))
+		if code == "" {
+			assert.Empty(t, fields, "%q", password)
+			continue
+		}
+		require.Len(t, fields, 1, "%q", password)
+		assert.Equal(t, "password", fields[0].Field)
+		assert.Equal(t, code, fields[0].Code, "%q", password)
+	}
+}
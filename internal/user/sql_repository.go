@@ -0,0 +1,162 @@
+package user
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pag-simples/internal/database"
+)
+
+const userColumns = "id, full_name, document_number, email, password, user_type, phone, webhook_url, notification_channel, locale, role, password_changed_at"
+
+type SQLUserRepository struct {
+	db database.Executor
+}
+
+func NewSQLUserRepository(db database.Executor) *SQLUserRepository {
+	return &SQLUserRepository{
+		db: db,
+	}
+}
+
+func (r *SQLUserRepository) GetUserByEmail(email string) (*User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = $1", email))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: e-mail %s", ErrUserNotFound, email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário por e-mail: %v", err)
+	}
+	return user, nil
+}
+
+func (r *SQLUserRepository) GetUserByDocumentNumber(documentNumber string) (*User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE document_number = $1", documentNumber))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: documento %s", ErrUserNotFound, documentNumber)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário por documento: %v", err)
+	}
+	return user, nil
+}
+
+func (r *SQLUserRepository) GetUser(userID int) (*User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: ID %d", ErrUserNotFound, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário %d: %v", userID, err)
+	}
+	return user, nil
+}
+
+func (r *SQLUserRepository) GetAllUsers() ([]User, error) {
+	rows, err := r.db.Query("SELECT " + userColumns + " FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar usuários: %v", err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler usuário: %v", err)
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func (r *SQLUserRepository) SaveUser(user *User) error {
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM users WHERE id = $1 OR email = $2 OR document_number = $3",
+		user.ID, user.Email, user.DocumentNumber,
+	).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar usuário existente: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: ID %d", ErrUserAlreadyExists, user.ID)
+	}
+
+	if user.ID == 0 {
+		err := r.db.QueryRow(
+			"INSERT INTO users (full_name, document_number, email, password, user_type, phone, webhook_url, notification_channel, locale, role, password_changed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id",
+			user.FullName, user.DocumentNumber, user.Email, user.Password, user.UserType, user.Phone, user.WebhookURL, user.NotificationChannel, user.Locale, user.Role, millis(user.PasswordChangedAt),
+		).Scan(&user.ID)
+		if err != nil {
+			return r.saveError(user, err)
+		}
+		return nil
+	}
+
+	_, err = r.db.Exec(
+		"INSERT INTO users ("+userColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+		user.ID, user.FullName, user.DocumentNumber, user.Email, user.Password, user.UserType, user.Phone, user.WebhookURL, user.NotificationChannel, user.Locale, user.Role, millis(user.PasswordChangedAt),
+	)
+	if err != nil {
+		return r.saveError(user, err)
+	}
+
+	if query := r.db.Dialect().ResetSequence("users", "id"); query != "" {
+		if _, err := r.db.Exec(query); err != nil {
+			return fmt.Errorf("erro ao ajustar sequência de usuários: %v", err)
+		}
+	}
+	return nil
+}
+
+// saveError converte a violação de UNIQUE em ErrUserAlreadyExists: a
+// verificação do SaveUser não impede que um cadastro concorrente chegue antes
+// ao INSERT.
+func (r *SQLUserRepository) saveError(user *User, err error) error {
+	if r.db.Dialect().IsUniqueViolation(err) {
+		return fmt.Errorf("%w: ID %d", ErrUserAlreadyExists, user.ID)
+	}
+	return fmt.Errorf("erro ao salvar usuário: %v", err)
+}
+
+func (r *SQLUserRepository) UpdateUser(user *User) error {
+	result, err := r.db.Exec(
+		"UPDATE users SET full_name = $1, document_number = $2, email = $3, password = $4, user_type = $5, phone = $6, webhook_url = $7, notification_channel = $8, locale = $9, role = $10, password_changed_at = $11 WHERE id = $12",
+		user.FullName, user.DocumentNumber, user.Email, user.Password, user.UserType, user.Phone, user.WebhookURL, user.NotificationChannel, user.Locale, user.Role, millis(user.PasswordChangedAt), user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar usuário %d: %v", user.ID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar usuário %d: %v", user.ID, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: ID %d", ErrUserNotFound, user.ID)
+	}
+	return nil
+}
+
+func scanUser(row database.RowScanner) (*User, error) {
+	var user User
+	var passwordChangedAt int64
+	if err := row.Scan(&user.ID, &user.FullName, &user.DocumentNumber, &user.Email, &user.Password, &user.UserType, &user.Phone, &user.WebhookURL, &user.NotificationChannel, &user.Locale, &user.Role, &passwordChangedAt); err != nil {
+		return nil, err
+	}
+	if passwordChangedAt != 0 {
+		user.PasswordChangedAt = time.UnixMilli(passwordChangedAt)
+	}
+	return &user, nil
+}
+
+// millis grava o instante zero como 0, e não como o UnixMilli negativo do
+// ano 1.
+func millis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,68 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"pag-simples/internal/database"
+)
+
+type SQLPasswordResetRepository struct {
+	db database.Executor
+}
+
+func NewSQLPasswordResetRepository(db database.Executor) *SQLPasswordResetRepository {
+	return &SQLPasswordResetRepository{
+		db: db,
+	}
+}
+
+func (r *SQLPasswordResetRepository) CreateReset(reset *PasswordReset) error {
+	_, err := r.db.Exec(
+		"INSERT INTO password_resets (token_hash, user_id, expires_at, used_at, created_at) VALUES ($1, $2, $3, 0, $4)",
+		reset.TokenHash, reset.UserID, reset.ExpiresAt.UnixMilli(), reset.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar pedido de redefinição do usuário %d: %v", reset.UserID, err)
+	}
+	return nil
+}
+
+// ConsumeReset só marca o pedido se ele ainda não foi usado, o que impede que
+// duas requisições concorrentes usem o mesmo token.
+func (r *SQLPasswordResetRepository) ConsumeReset(tokenHash string, now time.Time) (*PasswordReset, error) {
+	var reset *PasswordReset
+	err := database.RunInTx(r.db, func(tx database.Executor) error {
+		result, err := tx.Exec(
+			"UPDATE password_resets SET used_at = $1 WHERE token_hash = $2 AND used_at = 0 AND expires_at > $1",
+			now.UnixMilli(), tokenHash,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao consumir pedido de redefinição: %v", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("erro ao consumir pedido de redefinição: %v", err)
+		}
+		if affected == 0 {
+			return ErrInvalidResetToken
+		}
+
+		var expiresAt, usedAt, createdAt int64
+		reset = &PasswordReset{TokenHash: tokenHash}
+		err = tx.QueryRow("SELECT user_id, expires_at, used_at, created_at FROM password_resets WHERE token_hash = $1", tokenHash).
+			Scan(&reset.UserID, &expiresAt, &usedAt, &createdAt)
+		if err != nil {
+			return fmt.Errorf("erro ao consumir pedido de redefinição: %v", err)
+		}
+		reset.ExpiresAt = time.UnixMilli(expiresAt)
+		reset.UsedAt = time.UnixMilli(usedAt)
+		reset.CreatedAt = time.UnixMilli(createdAt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reset, nil
+}
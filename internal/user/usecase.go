@@ -1,8 +1,12 @@
 package user
 
 type UserUsecase interface {
-    GetUser(userID int) (*User, error)
-    GetAllUsers() ([]User, error)
-		ValidateUniqueUser(cpf, email string) error
-		SaveUser(user *User) error
-}
\ No newline at end of file
+	GetUser(userID int) (*User, error)
+	GetAllUsers() ([]User, error)
+	ValidateUniqueUser(cpf, email string) error
+	SaveUser(user *User) error
+	Authenticate(email string, password string) (*User, error)
+	ChangePassword(userID int, current string, next string) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token string, password string) error
+}
@@ -1,6 +1,13 @@
 package user
 
-import "pag-simples/internal/wallet"
+import (
+	"encoding/json"
+	"time"
+
+	"pag-simples/internal/wallet"
+	"pag-simples/pkg/document"
+	"pag-simples/pkg/notification"
+)
 
 type UserType string
 
@@ -9,12 +16,53 @@ const (
 	Merchant   UserType = "merchant"
 )
 
+var UserTypes = []UserType{CommonUser, Merchant}
+
+func (t UserType) Valid() bool {
+	for _, userType := range UserTypes {
+		if t == userType {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
-	ID             int
-	FullName       string
-	DocumentNumber string
-	Email          string
-	Password       string
-	UserType       UserType
-	Wallet         wallet.Wallet
+	ID                  int
+	FullName            string
+	DocumentNumber      string
+	Email               string
+	Password            string `json:"-"`
+	UserType            UserType
+	Role                Role
+	Phone               string
+	WebhookURL          string
+	NotificationChannel notification.Channel
+	Locale              notification.Locale
+	Wallet              wallet.Wallet
+	// PasswordChangedAt é o momento da última troca ou redefinição de senha;
+	// access tokens emitidos antes dele deixam de valer.
+	PasswordChangedAt time.Time `json:"-"`
+}
+
+// MarshalJSON acrescenta FormattedDocumentNumber, o CPF ou CNPJ com a
+// pontuação usual; DocumentNumber continua como é gravado.
+func (u User) MarshalJSON() ([]byte, error) {
+	type fields User
+	return json.Marshal(struct {
+		fields
+		FormattedDocumentNumber string
+	}{fields(u), document.Format(u.DocumentNumber)})
+}
+
+// Recipient devolve os contatos do usuário para o envio de notificações.
+func (u *User) Recipient() notification.Recipient {
+	return notification.Recipient{
+		Name:       u.FullName,
+		Email:      u.Email,
+		Phone:      u.Phone,
+		WebhookURL: u.WebhookURL,
+		Channel:    u.NotificationChannel,
+		Locale:     u.Locale,
+	}
 }
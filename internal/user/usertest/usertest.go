@@ -0,0 +1,256 @@
+package usertest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pag-simples/internal/user"
+	"pag-simples/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func RunUserRepositoryTests(t *testing.T, newRepo func(t *testing.T) user.UserRepository) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveUser(newUser(7, "joao")))
+
+		byID, err := repo.GetUser(7)
+		require.NoError(t, err)
+		assert.Equal(t, newUser(7, "joao"), byID)
+
+		byEmail, err := repo.GetUserByEmail("joao@email.com")
+		require.NoError(t, err)
+		assert.Equal(t, 7, byEmail.ID)
+
+		byDocument, err := repo.GetUserByDocumentNumber("doc-joao")
+		require.NoError(t, err)
+		assert.Equal(t, 7, byDocument.ID)
+	})
+
+	t.Run("AssignsIDWhenMissing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first := newUser(0, "primeiro")
+		require.NoError(t, repo.SaveUser(first))
+		assert.Equal(t, 1, first.ID)
+
+		require.NoError(t, repo.SaveUser(newUser(5, "explicito")))
+
+		next := newUser(0, "proximo")
+		require.NoError(t, repo.SaveUser(next))
+		assert.Equal(t, 6, next.ID)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		found, err := repo.GetUser(42)
+		assert.Nil(t, found)
+		assert.True(t, errors.Is(err, user.ErrUserNotFound), "GetUser: %v", err)
+
+		found, err = repo.GetUserByEmail("ninguem@email.com")
+		assert.Nil(t, found)
+		assert.True(t, errors.Is(err, user.ErrUserNotFound), "GetUserByEmail: %v", err)
+
+		found, err = repo.GetUserByDocumentNumber("000")
+		assert.Nil(t, found)
+		assert.True(t, errors.Is(err, user.ErrUserNotFound), "GetUserByDocumentNumber: %v", err)
+
+		err = repo.UpdateUser(newUser(42, "ninguem"))
+		assert.True(t, errors.Is(err, user.ErrUserNotFound), "UpdateUser: %v", err)
+	})
+
+	t.Run("Uniqueness", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveUser(newUser(1, "joao")))
+
+		sameID := newUser(1, "outro")
+		sameEmail := newUser(2, "outro")
+		sameEmail.Email = "joao@email.com"
+		sameDocument := newUser(3, "outro")
+		sameDocument.DocumentNumber = "doc-joao"
+
+		for name, duplicate := range map[string]*user.User{"id": sameID, "email": sameEmail, "document": sameDocument} {
+			err := repo.SaveUser(duplicate)
+			assert.True(t, errors.Is(err, user.ErrUserAlreadyExists), "%s: %v", name, err)
+		}
+
+		users, err := repo.GetAllUsers()
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.SaveUser(newUser(1, "joao")))
+
+		updated := newUser(1, "joao")
+		updated.FullName = "João Atualizado"
+		updated.WebhookURL = "https://joao.example/notificacoes"
+		updated.NotificationChannel = notification.ChannelWebhook
+		updated.PasswordChangedAt = time.Now()
+		require.NoError(t, repo.UpdateUser(updated))
+
+		found, err := repo.GetUser(1)
+		require.NoError(t, err)
+		assert.Equal(t, "João Atualizado", found.FullName)
+		assert.Equal(t, "https://joao.example/notificacoes", found.WebhookURL)
+		assert.Equal(t, notification.ChannelWebhook, found.NotificationChannel)
+		assert.Equal(t, updated.PasswordChangedAt.UnixMilli(), found.PasswordChangedAt.UnixMilli())
+	})
+
+	t.Run("GetAllUsersOrderedByID", func(t *testing.T) {
+		repo := newRepo(t)
+
+		users, err := repo.GetAllUsers()
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		for _, id := range []int{3, 1, 2} {
+			require.NoError(t, repo.SaveUser(newUser(id, fmt.Sprintf("usuario%d", id))))
+		}
+
+		users, err = repo.GetAllUsers()
+		require.NoError(t, err)
+		require.Len(t, users, 3)
+		for i, u := range users {
+			assert.Equal(t, i+1, u.ID)
+		}
+	})
+
+	t.Run("ConcurrentSaves", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var wg sync.WaitGroup
+		for i := 1; i <= 20; i++ {
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				assert.NoError(t, repo.SaveUser(newUser(id, fmt.Sprintf("usuario%d", id))))
+			}(i)
+		}
+		wg.Wait()
+
+		users, err := repo.GetAllUsers()
+		require.NoError(t, err)
+		assert.Len(t, users, 20)
+	})
+
+	t.Run("ConcurrentDuplicateEmail", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 1; i <= 10; i++ {
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				candidate := newUser(id, fmt.Sprintf("usuario%d", id))
+				candidate.Email = "disputado@email.com"
+				err := repo.SaveUser(candidate)
+				if err != nil {
+					assert.True(t, errors.Is(err, user.ErrUserAlreadyExists), "SaveUser: %v", err)
+					return
+				}
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, succeeded)
+	})
+}
+
+func newUser(id int, name string) *user.User {
+	return &user.User{
+		ID:                  id,
+		FullName:            name,
+		DocumentNumber:      "doc-" + name,
+		Email:               name + "@email.com",
+		Password:            "senha",
+		UserType:            user.CommonUser,
+		Role:                user.RoleSupport,
+		Phone:               "+5511999990000",
+		NotificationChannel: notification.ChannelSMS,
+		Locale:              notification.LocaleEnUS,
+	}
+}
+
+// RunPasswordResetRepositoryTests recebe também o repositório de usuários, já
+// que todo pedido de redefinição pertence a um usuário existente.
+func RunPasswordResetRepositoryTests(t *testing.T, newRepos func(t *testing.T) (user.UserRepository, user.PasswordResetRepository)) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	newReset := func(t *testing.T, users user.UserRepository, tokenHash string, expiresAt time.Time) *user.PasswordReset {
+		require.NoError(t, users.SaveUser(newUser(0, tokenHash)))
+		saved, err := users.GetUserByEmail(tokenHash + "@email.com")
+		require.NoError(t, err)
+
+		return &user.PasswordReset{TokenHash: tokenHash, UserID: saved.ID, ExpiresAt: expiresAt, CreatedAt: now}
+	}
+
+	t.Run("CreateAndConsume", func(t *testing.T) {
+		users, resets := newRepos(t)
+		reset := newReset(t, users, "hash", now.Add(time.Minute))
+		require.NoError(t, resets.CreateReset(reset))
+
+		consumed, err := resets.ConsumeReset("hash", now)
+		require.NoError(t, err)
+		assert.Equal(t, reset.UserID, consumed.UserID)
+		assert.True(t, consumed.ExpiresAt.Equal(reset.ExpiresAt))
+		assert.True(t, consumed.UsedAt.Equal(now))
+	})
+
+	t.Run("ConsumesOnlyOnce", func(t *testing.T) {
+		users, resets := newRepos(t)
+		require.NoError(t, resets.CreateReset(newReset(t, users, "hash", now.Add(time.Minute))))
+
+		_, err := resets.ConsumeReset("hash", now)
+		require.NoError(t, err)
+
+		_, err = resets.ConsumeReset("hash", now)
+		assert.True(t, errors.Is(err, user.ErrInvalidResetToken), "ConsumeReset: %v", err)
+	})
+
+	t.Run("RejectsExpiredAndUnknown", func(t *testing.T) {
+		users, resets := newRepos(t)
+		require.NoError(t, resets.CreateReset(newReset(t, users, "hash", now)))
+
+		_, err := resets.ConsumeReset("hash", now)
+		assert.True(t, errors.Is(err, user.ErrInvalidResetToken), "expirado: %v", err)
+
+		_, err = resets.ConsumeReset("outro", now)
+		assert.True(t, errors.Is(err, user.ErrInvalidResetToken), "desconhecido: %v", err)
+	})
+
+	t.Run("ConcurrentConsume", func(t *testing.T) {
+		users, resets := newRepos(t)
+		require.NoError(t, resets.CreateReset(newReset(t, users, "hash", now.Add(time.Minute))))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		consumed := 0
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := resets.ConsumeReset("hash", now); err == nil {
+					mu.Lock()
+					consumed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, consumed)
+	})
+}
@@ -0,0 +1,178 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"pag-simples/internal/validation"
+	"pag-simples/pkg/document"
+	"pag-simples/pkg/notification"
+	"pag-simples/pkg/webhook"
+)
+
+const (
+	MinNameLength = 3
+	MaxNameLength = 120
+	// MaxEmailLength é o limite de um endereço pela RFC 5321.
+	MaxEmailLength = 254
+)
+
+// validateUser confere todos os campos de um novo usuário e devolve todos os
+// problemas de uma vez. Também completa os padrões (canal, idioma e papel) e
+// normaliza nome e documento.
+func validateUser(user *User) error {
+	user.FullName = strings.TrimSpace(user.FullName)
+	if user.NotificationChannel == "" {
+		user.NotificationChannel = notification.ChannelEmail
+	}
+	if user.Locale == "" {
+		user.Locale = notification.DefaultLocale
+	}
+	if user.Role == "" {
+		user.Role = defaultRole(user.UserType)
+	}
+
+	var errs validation.Errors
+	validateName(&errs, user.FullName)
+	validateEmail(&errs, user.Email)
+	passwordStrength(&errs, "Password", user.Password)
+	validateUserType(&errs, user.UserType)
+	validateDocument(&errs, user)
+	validateRole(&errs, user.Role)
+	validateNotificationPreference(&errs, user)
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	user.DocumentNumber = document.Normalize(user.DocumentNumber)
+	return nil
+}
+
+// validatePassword confere só a senha, na troca e na redefinição; field é o
+// nome do campo na requisição.
+func validatePassword(field string, password string) error {
+	var errs validation.Errors
+	passwordStrength(&errs, field, password)
+	return errs.Err()
+}
+
+func validateName(errs *validation.Errors, name string) {
+	length := utf8.RuneCountInString(name)
+	switch {
+	case length == 0:
+		errs.Add("FullName", validation.CodeRequired, "nome é obrigatório")
+	case length < MinNameLength:
+		errs.Add("FullName", validation.CodeTooShort, fmt.Sprintf("nome deve ter ao menos %d caracteres", MinNameLength))
+	case length > MaxNameLength:
+		errs.Add("FullName", validation.CodeTooLong, fmt.Sprintf("nome deve ter no máximo %d caracteres", MaxNameLength))
+	}
+}
+
+// validateEmail aceita só o endereço, sem nome ("João <joao@email.com>") nem
+// espaços, e exige um domínio com ponto.
+func validateEmail(errs *validation.Errors, email string) {
+	if email == "" {
+		errs.Add("Email", validation.CodeRequired, "e-mail é obrigatório")
+		return
+	}
+	if len(email) > MaxEmailLength {
+		errs.Add("Email", validation.CodeTooLong, fmt.Sprintf("e-mail deve ter no máximo %d caracteres", MaxEmailLength))
+		return
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		errs.Add("Email", validation.CodeInvalidFormat, "e-mail inválido")
+		return
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		errs.Add("Email", validation.CodeInvalidFormat, "e-mail inválido")
+	}
+}
+
+// passwordStrength exige de MinPasswordLength caracteres a MaxPasswordBytes
+// bytes, com ao menos uma letra e um número.
+func passwordStrength(errs *validation.Errors, field string, password string) {
+	switch {
+	case password == "":
+		errs.Add(field, validation.CodeRequired, "senha é obrigatória")
+		return
+	case utf8.RuneCountInString(password) < MinPasswordLength:
+		errs.Add(field, validation.CodeTooShort, fmt.Sprintf("senha deve ter ao menos %d caracteres", MinPasswordLength))
+		return
+	case len(password) > MaxPasswordBytes:
+		errs.Add(field, validation.CodeTooLong, fmt.Sprintf("senha deve ter no máximo %d bytes", MaxPasswordBytes))
+		return
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	if !letter || !digit {
+		errs.Add(field, validation.CodeWeakPassword, "senha deve ter letras e números")
+	}
+}
+
+func validateUserType(errs *validation.Errors, userType UserType) {
+	if userType == "" {
+		errs.Add("UserType", validation.CodeRequired, "tipo de usuário é obrigatório")
+		return
+	}
+	if !userType.Valid() {
+		errs.Add("UserType", validation.CodeNotAllowed, fmt.Sprintf("tipo de usuário deve ser um de %v", UserTypes))
+	}
+}
+
+// validateDocument exige CNPJ de lojistas e CPF dos demais usuários.
+func validateDocument(errs *validation.Errors, user *User) {
+	kind := document.CPF
+	if user.UserType == Merchant {
+		kind = document.CNPJ
+	}
+
+	if user.DocumentNumber == "" {
+		errs.Add("DocumentNumber", validation.CodeRequired, "documento é obrigatório")
+		return
+	}
+	if !document.Valid(kind, user.DocumentNumber) {
+		errs.Add("DocumentNumber", validation.CodeInvalidFormat, fmt.Sprintf("%s inválido", strings.ToUpper(string(kind))))
+	}
+}
+
+func validateRole(errs *validation.Errors, role Role) {
+	if !role.Valid() {
+		errs.Add("Role", validation.CodeNotAllowed, fmt.Sprintf("papel deve ser um de %v", Roles))
+	}
+}
+
+// validateNotificationPreference exige o contato que o canal escolhido usa.
+func validateNotificationPreference(errs *validation.Errors, user *User) {
+	if !user.Locale.Valid() {
+		errs.Add("Locale", validation.CodeNotAllowed, fmt.Sprintf("idioma deve ser um de %v", notification.Locales))
+	}
+
+	if !user.NotificationChannel.Valid() {
+		errs.Add("NotificationChannel", validation.CodeNotAllowed, fmt.Sprintf("canal de notificação deve ser um de %v", notification.Channels))
+	}
+
+	if user.NotificationChannel == notification.ChannelSMS && user.Phone == "" {
+		errs.Add("Phone", validation.CodeRequired, "telefone é obrigatório para notificações por SMS")
+	}
+
+	if user.WebhookURL != "" || user.NotificationChannel == notification.ChannelWebhook {
+		switch err := webhook.ValidateURL(user.WebhookURL); {
+		case errors.Is(err, webhook.ErrPrivateAddress):
+			errs.Add("WebhookURL", validation.CodeNotAllowed, "webhook_url não pode apontar para a rede interna")
+		case err != nil:
+			errs.Add("WebhookURL", validation.CodeInvalidFormat, "webhook_url inválida")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package validation acumula os problemas encontrados em cada campo de uma
+// requisição, para que o cliente receba todos de uma vez.
+package validation
+
+import (
+	"errors"
+
+	"pag-simples/internal/apperrors"
+)
+
+const (
+	CodeRequired      = "required"
+	CodeInvalidFormat = "invalid_format"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeWeakPassword  = "weak_password"
+	CodeNotAllowed    = "not_allowed"
+	CodeUnknownField  = "unknown_field"
+	CodeInvalidType   = "invalid_type"
+)
+
+// FieldError descreve um problema em um campo. Field usa o nome do campo no
+// JSON da requisição.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Errors []FieldError
+
+func (e *Errors) Add(field string, code string, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err devolve nil sem problemas ou um invalid_request com a lista em
+// details.fields.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return apperrors.New(apperrors.CodeInvalidRequest, "dados inválidos").WithDetails(map[string]interface{}{
+		"fields": e,
+	})
+}
+
+// Fields devolve a lista de um erro criado por Err, ou nil para outros erros.
+func Fields(err error) Errors {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return nil
+	}
+	fields, _ := appErr.Details["fields"].(Errors)
+	return fields
+}
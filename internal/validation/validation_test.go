@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"pag-simples/internal/apperrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	assert.NoError(t, errs.Err())
+
+	errs.Add("Email", CodeRequired, "e-mail é obrigatório")
+	errs.Add("Password", CodeWeakPassword, "senha deve ter letras e números")
+
+	err := fmt.Errorf("cadastro: %w", errs.Err())
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidRequest), "%v", err)
+	assert.Equal(t, errs, Fields(err))
+
+	assert.Nil(t, Fields(errors.New("outro erro")))
+	assert.Nil(t, Fields(apperrors.ErrInvalidRequest))
+}
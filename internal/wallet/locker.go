@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"sort"
+	"sync"
+)
+
+type Locker struct {
+	mu    sync.Mutex
+	locks map[int]*walletLock
+}
+
+type walletLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func NewLocker() *Locker {
+	return &Locker{
+		locks: make(map[int]*walletLock),
+	}
+}
+
+// Lock bloqueia as wallets sempre em ordem crescente de ID, evitando deadlock
+// entre transferências A→B e B→A simultâneas.
+func (l *Locker) Lock(userIDs ...int) (unlock func()) {
+	ids := append([]int(nil), userIDs...)
+	sort.Ints(ids)
+
+	acquired := make([]int, 0, len(ids))
+	for i, id := range ids {
+		if i > 0 && ids[i-1] == id {
+			continue
+		}
+		l.acquire(id)
+		acquired = append(acquired, id)
+	}
+
+	return func() {
+		for i := len(acquired) - 1; i >= 0; i-- {
+			l.release(acquired[i])
+		}
+	}
+}
+
+func (l *Locker) acquire(userID int) {
+	l.mu.Lock()
+	lock, exists := l.locks[userID]
+	if !exists {
+		lock = &walletLock{}
+		l.locks[userID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+}
+
+func (l *Locker) release(userID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock := l.locks[userID]
+	lock.mu.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, userID)
+	}
+}
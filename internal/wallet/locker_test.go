@@ -0,0 +1,81 @@
+package wallet
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockerOpposingOrdersDoNotDeadlock(t *testing.T) {
+	locker := NewLocker()
+	done := make(chan struct{})
+
+	go func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 1000; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				unlock := locker.Lock(1, 2)
+				unlock()
+			}()
+			go func() {
+				defer wg.Done()
+				unlock := locker.Lock(2, 1)
+				unlock()
+			}()
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock ao bloquear wallets em ordens opostas")
+	}
+
+	assert.Empty(t, locker.locks)
+}
+
+func TestLockerSerializesSameWallet(t *testing.T) {
+	locker := NewLocker()
+	unlock := locker.Lock(1, 1)
+
+	acquired := make(chan struct{})
+	go func() {
+		release := locker.Lock(1)
+		close(acquired)
+		release()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("wallet bloqueada foi adquirida por outra goroutine")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	<-acquired
+}
+
+func TestLockerUnrelatedWalletsRunInParallel(t *testing.T) {
+	locker := NewLocker()
+	unlock := locker.Lock(1, 2)
+	defer unlock()
+
+	acquired := make(chan struct{})
+	go func() {
+		release := locker.Lock(3, 4)
+		close(acquired)
+		release()
+	}()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("wallets não relacionadas não deveriam bloquear")
+	}
+}
@@ -1,39 +1,240 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/ledger"
+	"pag-simples/internal/pagination"
+
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
 type WalletService struct {
-	repo *MemoryWalletRepository
+	ledgerRepo ledger.LedgerRepository
 }
 
-func NewWalletService(repo *MemoryWalletRepository) *WalletService {
+func NewWalletService(ledgerRepo ledger.LedgerRepository) *WalletService {
 	return &WalletService{
-		repo: repo,
+		ledgerRepo: ledgerRepo,
 	}
 }
 
 func (s *WalletService) CreateWallet(userID int, balance decimal.Decimal) error {
-	if _, exists := s.repo.wallets[userID]; exists {
-		return fmt.Errorf("wallet já existe para o usuário %d", userID)
+	if balance.IsNegative() {
+		return apperrors.ErrInvalidAmount.WithDetails(map[string]interface{}{"reason": "saldo inicial não pode ser negativo", "user": userID})
+	}
+
+	if _, err := s.ledgerRepo.GetAccount(ledger.WalletAccountID(userID)); err == nil {
+		return fmt.Errorf("%w para o usuário %d", apperrors.ErrWalletAlreadyExists, userID)
+	}
+
+	if err := s.ensureFundingAccount(); err != nil {
+		return err
+	}
+
+	account := &ledger.Account{
+		ID:        ledger.WalletAccountID(userID),
+		Type:      ledger.WalletAccount,
+		CreatedAt: time.Now(),
+	}
+	if err := s.ledgerRepo.CreateAccount(account); err != nil {
+		return fmt.Errorf("falha ao criar a wallet do usuário %d: %v", userID, err)
+	}
+
+	if balance.IsZero() {
+		return nil
+	}
+
+	return s.post("Saldo inicial", "", ledger.FundingAccountID, account.ID, balance)
+}
+
+func (s *WalletService) GetBalance(userID int) (decimal.Decimal, error) {
+	balance, err := s.ledgerRepo.GetBalance(ledger.WalletAccountID(userID))
+	if errors.Is(err, ledger.ErrAccountNotFound) {
+		return decimal.Zero, fmt.Errorf("%w para o usuário %d", apperrors.ErrWalletNotFound, userID)
+	}
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("falha ao obter o saldo da wallet do usuário %d: %v", userID, err)
+	}
+	return balance, nil
+}
+
+// GetWallet lê a versão antes do saldo: se houver escrita entre as duas
+// leituras, a versão fica defasada e o próximo Transfer falha com conflito.
+func (s *WalletService) GetWallet(userID int) (*Wallet, error) {
+	account, err := s.ledgerRepo.GetAccount(ledger.WalletAccountID(userID))
+	if errors.Is(err, ledger.ErrAccountNotFound) {
+		return nil, fmt.Errorf("%w para o usuário %d", apperrors.ErrWalletNotFound, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter a wallet do usuário %d: %v", userID, err)
 	}
 
-	wallet := &Wallet{
+	balance, err := s.GetBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Wallet{
 		ID:      userID,
 		Balance: balance,
+		Version: account.Version,
+	}, nil
+}
+
+func (s *WalletService) UpdateBalance(userID int, amount decimal.Decimal) error {
+	if amount.IsNegative() {
+		return s.post("Ajuste de saldo", "", ledger.WalletAccountID(userID), ledger.FundingAccountID, amount.Neg())
+	}
+	return s.post("Ajuste de saldo", "", ledger.FundingAccountID, ledger.WalletAccountID(userID), amount)
+}
+
+func (s *WalletService) Transfer(payer *Wallet, payeeID int, amount decimal.Decimal, reference string) error {
+	if !amount.IsPositive() {
+		return apperrors.ErrInvalidAmount.WithDetails(map[string]interface{}{"reason": "valor da transferência deve ser positivo"})
 	}
 
-	s.repo.wallets[userID] = wallet
+	payerAccountID := ledger.WalletAccountID(payer.ID)
+	err := s.postEntry(s.newEntry("Transferência", reference, payerAccountID, ledger.WalletAccountID(payeeID), amount, map[string]int64{
+		payerAccountID: payer.Version,
+	}))
+
+	var conflict *ledger.VersionConflictError
+	if errors.As(err, &conflict) {
+		return &ConflictError{UserID: payer.ID, ExpectedVersion: conflict.ExpectedVersion, ActualVersion: conflict.ActualVersion}
+	}
+	return err
+}
+
+// GetStatement calcula o saldo acumulado sobre todo o histórico da wallet e só
+// depois aplica filtros e paginação, para que cada linha mostre o saldo real.
+func (s *WalletService) GetStatement(userID int, filter StatementFilter) (*Statement, error) {
+	before, err := pagination.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID := ledger.WalletAccountID(userID)
+	entries, err := s.ledgerRepo.GetEntries(accountID)
+	if errors.Is(err, ledger.ErrAccountNotFound) {
+		return nil, fmt.Errorf("%w para o usuário %d", apperrors.ErrWalletNotFound, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter o extrato da wallet do usuário %d: %v", userID, err)
+	}
+
+	lines := make([]StatementLine, 0, len(entries))
+	balance := decimal.Zero
+	for i := range entries {
+		amount, err := statementAmount(&entries[i], accountID)
+		if err != nil {
+			return nil, fmt.Errorf("extrato da wallet do usuário %d inconsistente: %v", userID, err)
+		}
+		balance = balance.Add(amount)
+
+		direction := Received
+		if amount.IsNegative() {
+			direction = Sent
+		}
+
+		lines = append(lines, StatementLine{
+			EntryID:     entries[i].ID,
+			Reference:   entries[i].Reference,
+			Description: entries[i].Description,
+			Direction:   direction,
+			Amount:      amount,
+			Balance:     balance,
+			CreatedAt:   entries[i].CreatedAt,
+			position:    int64(i + 1),
+		})
+	}
+
+	statement := &Statement{
+		UserID:  userID,
+		Balance: balance,
+		Lines:   []StatementLine{},
+	}
+
+	limit := pagination.NormalizeLimit(filter.Limit)
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if before > 0 && line.position >= before {
+			continue
+		}
+		if !filter.matches(&line) {
+			continue
+		}
+		if len(statement.Lines) == limit {
+			statement.NextCursor = pagination.EncodeCursor(statement.Lines[limit-1].position)
+			break
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+
+	return statement, nil
+}
+
+func (s *WalletService) post(description string, reference string, from string, to string, amount decimal.Decimal) error {
+	return s.postEntry(s.newEntry(description, reference, from, to, amount, nil))
+}
+
+func (s *WalletService) newEntry(description string, reference string, from string, to string, amount decimal.Decimal, expectedVersions map[string]int64) *ledger.Entry {
+	return &ledger.Entry{
+		ID:          uuid.New().String(),
+		Description: description,
+		Reference:   reference,
+		Postings: []ledger.Posting{
+			{AccountID: from, Amount: amount.Neg()},
+			{AccountID: to, Amount: amount},
+		},
+		CreatedAt:        time.Now(),
+		ExpectedVersions: expectedVersions,
+	}
+}
+
+func (s *WalletService) postEntry(entry *ledger.Entry) error {
+	if err := s.ledgerRepo.PostEntry(entry); err != nil {
+		return fmt.Errorf("falha ao registrar lançamento: %w", err)
+	}
 	return nil
 }
 
-func (s *WalletService) GetBalance(userID int) (decimal.Decimal, error) {
-	return s.repo.GetBalance(userID)
+func (s *WalletService) ensureFundingAccount() error {
+	if _, err := s.ledgerRepo.GetAccount(ledger.FundingAccountID); err == nil {
+		return nil
+	}
+
+	err := s.ledgerRepo.CreateAccount(&ledger.Account{
+		ID:            ledger.FundingAccountID,
+		Type:          ledger.SystemAccount,
+		AllowNegative: true,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		if _, getErr := s.ledgerRepo.GetAccount(ledger.FundingAccountID); getErr == nil {
+			return nil
+		}
+		return fmt.Errorf("falha ao criar a conta de aporte: %v", err)
+	}
+	return nil
 }
 
-func (s *WalletService) UpdateBalance(userID int, amount decimal.Decimal) error {
-	return s.repo.UpdateBalance(userID, amount)
+// statementAmount devolve quanto o lançamento movimenta accountID. Um
+// lançamento desbalanceado ou que não toca a conta indica dados corrompidos e
+// não pode virar uma linha de valor zero no extrato.
+func statementAmount(entry *ledger.Entry, accountID string) (decimal.Decimal, error) {
+	if err := entry.Validate(); err != nil {
+		return decimal.Zero, err
+	}
+
+	deltas, _ := entry.Deltas()
+	amount, ok := deltas[accountID]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("lançamento %s não movimenta a conta %s", entry.ID, accountID)
+	}
+	return amount, nil
 }
@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Direction string
+
+const (
+	Sent     Direction = "sent"
+	Received Direction = "received"
+)
+
+func (d Direction) Valid() bool {
+	return d == "" || d == Sent || d == Received
+}
+
+type StatementLine struct {
+	EntryID     string          `json:"entry_id"`
+	Reference   string          `json:"reference,omitempty"`
+	Description string          `json:"description"`
+	Direction   Direction       `json:"direction"`
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+	CreatedAt   time.Time       `json:"created_at"`
+	position    int64
+}
+
+// StatementFilter segue as mesmas regras de TransferFilter: From inclusivo,
+// To exclusivo e Direction vazio para débitos e créditos.
+type StatementFilter struct {
+	Direction Direction
+	From      time.Time
+	To        time.Time
+	Cursor    string
+	Limit     int
+}
+
+type Statement struct {
+	UserID     int             `json:"user_id"`
+	Balance    decimal.Decimal `json:"balance"`
+	Lines      []StatementLine `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (f StatementFilter) matches(line *StatementLine) bool {
+	if f.Direction != "" && line.Direction != f.Direction {
+		return false
+	}
+	if !f.From.IsZero() && line.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !line.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pag-simples/internal/apperrors"
+	"pag-simples/internal/ledger"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatementFixture(t *testing.T) *WalletService {
+	service := NewWalletService(ledger.NewMemoryLedgerRepository())
+	require.NoError(t, service.CreateWallet(1, decimal.NewFromInt(100)))
+	require.NoError(t, service.CreateWallet(2, decimal.Zero))
+
+	for _, amount := range []int64{30, 20} {
+		payer, err := service.GetWallet(1)
+		require.NoError(t, err)
+		require.NoError(t, service.Transfer(payer, 2, decimal.NewFromInt(amount), "ref"))
+	}
+
+	payer, err := service.GetWallet(2)
+	require.NoError(t, err)
+	require.NoError(t, service.Transfer(payer, 1, decimal.NewFromInt(5), "estorno"))
+	return service
+}
+
+func amounts(lines []StatementLine) []string {
+	values := make([]string, 0, len(lines))
+	for _, line := range lines {
+		values = append(values, line.Amount.String()+"="+line.Balance.String())
+	}
+	return values
+}
+
+func TestStatementRunningBalance(t *testing.T) {
+	service := newStatementFixture(t)
+
+	statement, err := service.GetStatement(1, StatementFilter{})
+	require.NoError(t, err)
+
+	assert.True(t, statement.Balance.Equal(decimal.NewFromInt(55)))
+	assert.Equal(t, []string{"5=55", "-20=50", "-30=70", "100=100"}, amounts(statement.Lines))
+	assert.Equal(t, Received, statement.Lines[0].Direction)
+	assert.Equal(t, Sent, statement.Lines[1].Direction)
+	assert.Empty(t, statement.NextCursor)
+}
+
+func TestStatementPaginationKeepsBalances(t *testing.T) {
+	service := newStatementFixture(t)
+
+	first, err := service.GetStatement(1, StatementFilter{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5=55", "-20=50", "-30=70"}, amounts(first.Lines))
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := service.GetStatement(1, StatementFilter{Limit: 3, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100=100"}, amounts(second.Lines))
+	assert.Empty(t, second.NextCursor)
+}
+
+func TestStatementFilters(t *testing.T) {
+	service := newStatementFixture(t)
+
+	sent, err := service.GetStatement(1, StatementFilter{Direction: Sent})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-20=50", "-30=70"}, amounts(sent.Lines))
+
+	future, err := service.GetStatement(1, StatementFilter{From: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, future.Lines)
+	assert.True(t, future.Balance.Equal(decimal.NewFromInt(55)))
+}
+
+func TestStatementUnknownWallet(t *testing.T) {
+	service := NewWalletService(ledger.NewMemoryLedgerRepository())
+
+	_, err := service.GetStatement(9, StatementFilter{})
+	assert.True(t, errors.Is(err, apperrors.ErrWalletNotFound), "GetStatement: %v", err)
+}
+
+// corruptedLedger devolve, além dos lançamentos reais, um lançamento gravado
+// por fora das validações.
+type corruptedLedger struct {
+	ledger.LedgerRepository
+	extra ledger.Entry
+}
+
+func (r *corruptedLedger) GetEntries(accountID string) ([]ledger.Entry, error) {
+	entries, err := r.LedgerRepository.GetEntries(accountID)
+	return append(entries, r.extra), err
+}
+
+func TestStatementRejectsMalformedEntries(t *testing.T) {
+	cases := map[string][]ledger.Posting{
+		"desbalanceado": {
+			{AccountID: ledger.WalletAccountID(1), Amount: decimal.NewFromInt(-10)},
+			{AccountID: ledger.WalletAccountID(2), Amount: decimal.NewFromInt(5)},
+		},
+		"outra conta": {
+			{AccountID: ledger.WalletAccountID(2), Amount: decimal.NewFromInt(-10)},
+			{AccountID: ledger.WalletAccountID(3), Amount: decimal.NewFromInt(10)},
+		},
+	}
+
+	for name, postings := range cases {
+		repo := &corruptedLedger{LedgerRepository: ledger.NewMemoryLedgerRepository(), extra: ledger.Entry{ID: "e-ruim", Postings: postings}}
+		service := NewWalletService(repo)
+		require.NoError(t, service.CreateWallet(1, decimal.NewFromInt(100)))
+
+		_, err := service.GetStatement(1, StatementFilter{})
+		assert.ErrorContains(t, err, "e-ruim", name)
+
+		var appErr *apperrors.Error
+		assert.False(t, errors.As(err, &appErr), "%s: erro interno não deve ter código próprio: %v", name, err)
+	}
+}
@@ -5,5 +5,8 @@ import "github.com/shopspring/decimal"
 type WalletUseCase interface {
 	CreateWallet(int, decimal.Decimal) error
 	GetBalance(userID int) (decimal.Decimal, error)
+	GetWallet(userID int) (*Wallet, error)
 	UpdateBalance(userID int, amount decimal.Decimal) error
+	Transfer(payer *Wallet, payeeID int, amount decimal.Decimal, reference string) error
+	GetStatement(userID int, filter StatementFilter) (*Statement, error)
 }
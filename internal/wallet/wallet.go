@@ -1,8 +1,23 @@
 package wallet
 
-import "github.com/shopspring/decimal"
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
 
 type Wallet struct {
 	ID      int
 	Balance decimal.Decimal
-}
\ No newline at end of file
+	Version int64
+}
+
+type ConflictError struct {
+	UserID          int
+	ExpectedVersion int64
+	ActualVersion   int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("wallet do usuário %d foi alterada concorrentemente: versão esperada %d, atual %d", e.UserID, e.ExpectedVersion, e.ActualVersion)
+}
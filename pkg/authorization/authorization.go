@@ -1,11 +1,53 @@
 package authorization
 
+import "github.com/shopspring/decimal"
+
 type Authorization struct {
-	ID     int   `json:"id"`
+	ID     int    `json:"id"`
 	Status string `json:"status"`
-	Data   Data   `json:"data"` 
+	Data   Data   `json:"data"`
 }
 
 type Data struct {
-	Authorization bool `json:"authorization"`
-}
\ No newline at end of file
+	Authorization bool   `json:"authorization"`
+	ReasonCode    string `json:"reason_code,omitempty"`
+	ReferenceID   string `json:"reference_id,omitempty"`
+}
+
+type Party struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+}
+
+// Metadata descreve de onde veio a requisição; todos os campos são opcionais.
+type Metadata struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	DeviceID  string `json:"device_id,omitempty"`
+}
+
+type Request struct {
+	TransferID string          `json:"transfer_id"`
+	Payer      Party           `json:"payer"`
+	Payee      Party           `json:"payee"`
+	Amount     decimal.Decimal `json:"amount"`
+	Metadata   Metadata        `json:"metadata"`
+}
+
+const (
+	ReasonApproved = "approved"
+	ReasonDenied   = "denied"
+)
+
+// Decision é guardada na transação para auditoria. ReasonCode vem do
+// autorizador ou, se ele não informar, é ReasonApproved ou ReasonDenied;
+// Source e Explanation dizem quem decidiu e por quê.
+type Decision struct {
+	Authorized  bool   `json:"authorized"`
+	ReasonCode  string `json:"reason_code"`
+	ReferenceID string `json:"reference_id,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+	// Steps guarda as decisões de cada serviço consultado por Chain, em ordem.
+	Steps []Decision `json:"steps,omitempty"`
+}
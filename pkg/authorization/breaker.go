@@ -0,0 +1,116 @@
+package authorization
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker do autorizador aberto")
+
+// BreakerConfig abre o circuito depois de FailureThreshold falhas seguidas.
+// Passado OpenTimeout, uma única requisição de teste decide se ele fecha.
+type BreakerConfig struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+type breaker struct {
+	config   BreakerConfig
+	now      func() time.Time
+	onChange func(BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(config BreakerConfig, onChange func(BreakerState)) *breaker {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+
+	b := &breaker{
+		config:   config,
+		now:      time.Now,
+		onChange: onChange,
+		state:    BreakerClosed,
+	}
+	onChange(BreakerClosed)
+	return b
+}
+
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow devolve ErrCircuitOpen enquanto o circuito está aberto ou enquanto a
+// requisição de teste do half-open ainda não voltou.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.setState(BreakerHalfOpen)
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	b.setState(BreakerClosed)
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.probing = false
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// release devolve a vaga do half-open quando a requisição terminou sem dizer
+// nada sobre a saúde do autorizador, como num cancelamento.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	b.onChange(state)
+}
@@ -0,0 +1,265 @@
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SourceRules  = "rules"
+	SourceRemote = "remote"
+)
+
+// Códigos das negações do motor de regras; cada um corresponde a uma regra de
+// Rules.
+const (
+	ReasonPayeeBlocked          = "payee_blocked"
+	ReasonMaxAmountExceeded     = "max_amount_exceeded"
+	ReasonNewPayeeLimitExceeded = "new_payee_limit_exceeded"
+	ReasonDailyLimitExceeded    = "daily_limit_exceeded"
+	ReasonMonthlyLimitExceeded  = "monthly_limit_exceeded"
+)
+
+// Rules é o arquivo de regras do motor local, em YAML como a configuração;
+// JSON também é aceito. Limites zerados ou ausentes não são aplicados.
+type Rules struct {
+	MaxAmount     decimal.Decimal `yaml:"max_amount"`
+	DailyLimit    decimal.Decimal `yaml:"daily_limit"`
+	MonthlyLimit  decimal.Decimal `yaml:"monthly_limit"`
+	NewPayeeLimit decimal.Decimal `yaml:"new_payee_limit"`
+	BlockedPayees []int           `yaml:"blocked_payees"`
+}
+
+func (r *Rules) Validate() error {
+	limits := map[string]decimal.Decimal{
+		"max_amount":      r.MaxAmount,
+		"daily_limit":     r.DailyLimit,
+		"monthly_limit":   r.MonthlyLimit,
+		"new_payee_limit": r.NewPayeeLimit,
+	}
+	for name, limit := range limits {
+		if limit.IsNegative() {
+			return fmt.Errorf("regra %s não pode ser negativa: %s", name, limit.String())
+		}
+	}
+	return nil
+}
+
+// History dá ao motor de regras o que já foi liquidado pelo pagador.
+type History interface {
+	// Spent soma o que o pagador enviou desde since.
+	Spent(payerID int, since time.Time) (decimal.Decimal, error)
+	// HasPaid indica se o pagador já concluiu alguma transferência para o recebedor.
+	HasPaid(payerID int, payeeID int) (bool, error)
+}
+
+// RulesEngine autoriza transferências localmente a partir de um arquivo de
+// regras, que pode ser recarregado sem reiniciar a aplicação.
+type RulesEngine struct {
+	path    string
+	history History
+	now     func() time.Time
+
+	mu      sync.RWMutex
+	rules   Rules
+	version string
+}
+
+// NewRulesEngine falha se o arquivo não puder ser lido ou for inválido: sem
+// regras o motor aprovaria tudo.
+func NewRulesEngine(path string, history History) (*RulesEngine, error) {
+	engine := &RulesEngine{
+		path:    path,
+		history: history,
+		now:     time.Now,
+	}
+	if _, err := engine.Reload(); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Reload relê o arquivo e indica se as regras mudaram. Em caso de erro as
+// regras anteriores continuam valendo.
+func (e *RulesEngine) Reload() (bool, error) {
+	content, err := os.ReadFile(e.path)
+	if err != nil {
+		return false, fmt.Errorf("erro ao ler regras de autorização %s: %v", e.path, err)
+	}
+
+	sum := sha256.Sum256(content)
+	version := hex.EncodeToString(sum[:])[:12]
+	if version == e.Version() {
+		return false, nil
+	}
+
+	var rules Rules
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return false, fmt.Errorf("erro ao decodificar regras de autorização %s: %v", e.path, err)
+	}
+	if err := rules.Validate(); err != nil {
+		return false, fmt.Errorf("regras de autorização %s inválidas: %v", e.path, err)
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.version = version
+	e.mu.Unlock()
+	return true, nil
+}
+
+// Version identifica o conteúdo do arquivo de regras em uso e vai no
+// ReferenceID das decisões.
+func (e *RulesEngine) Version() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.version
+}
+
+// Watch recarrega o arquivo a cada interval até ctx ser cancelado.
+func (e *RulesEngine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := e.Reload()
+			if err != nil {
+				log.Printf("Erro ao recarregar regras de autorização: %v", err)
+				continue
+			}
+			if changed {
+				log.Printf("Regras de autorização recarregadas, versão %s", e.Version())
+			}
+		}
+	}
+}
+
+func (e *RulesEngine) CheckAuthorization(ctx context.Context, request Request) (*Decision, error) {
+	e.mu.RLock()
+	rules, version := e.rules, e.version
+	e.mu.RUnlock()
+
+	payerID, payeeID, amount := request.Payer.ID, request.Payee.ID, request.Amount
+
+	for _, blocked := range rules.BlockedPayees {
+		if blocked == payeeID {
+			return rulesDecision(version, ReasonPayeeBlocked, "recebedor %d está bloqueado", payeeID), nil
+		}
+	}
+
+	if rules.MaxAmount.IsPositive() && amount.GreaterThan(rules.MaxAmount) {
+		return rulesDecision(version, ReasonMaxAmountExceeded, "valor %s excede o limite por transferência de %s", amount.StringFixed(2), rules.MaxAmount.StringFixed(2)), nil
+	}
+
+	if rules.NewPayeeLimit.IsPositive() && amount.GreaterThan(rules.NewPayeeLimit) {
+		paid, err := e.history.HasPaid(payerID, payeeID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao consultar transferências de %d para %d: %v", payerID, payeeID, err)
+		}
+		if !paid {
+			return rulesDecision(version, ReasonNewPayeeLimitExceeded, "valor %s excede o limite de %s para a primeira transferência a %d", amount.StringFixed(2), rules.NewPayeeLimit.StringFixed(2), payeeID), nil
+		}
+	}
+
+	return e.checkLimits(rules, version, request)
+}
+
+// CheckLimits reaplica só os limites diário e mensal. Quem liquida a
+// transferência a chama de novo depois de ler a versão da wallet do pagador:
+// se outra liquidação do mesmo pagador entrar antes do lançamento, a versão
+// muda, o lançamento é recusado e a conferência se repete com o novo total,
+// mesmo com várias instâncias no mesmo banco.
+func (e *RulesEngine) CheckLimits(request Request) (*Decision, error) {
+	e.mu.RLock()
+	rules, version := e.rules, e.version
+	e.mu.RUnlock()
+
+	return e.checkLimits(rules, version, request)
+}
+
+func (e *RulesEngine) checkLimits(rules Rules, version string, request Request) (*Decision, error) {
+	payerID, amount := request.Payer.ID, request.Amount
+
+	now := e.now()
+	windows := []struct {
+		limit  decimal.Decimal
+		since  time.Time
+		reason string
+		period string
+	}{
+		{rules.DailyLimit, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), ReasonDailyLimitExceeded, "diário"},
+		{rules.MonthlyLimit, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), ReasonMonthlyLimitExceeded, "mensal"},
+	}
+	for _, window := range windows {
+		if !window.limit.IsPositive() {
+			continue
+		}
+		spent, err := e.history.Spent(payerID, window.since)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao consultar o volume enviado por %d: %v", payerID, err)
+		}
+		if spent.Add(amount).GreaterThan(window.limit) {
+			return rulesDecision(version, window.reason, "valor %s somado aos %s já enviados excede o limite %s de %s", amount.StringFixed(2), spent.StringFixed(2), window.period, window.limit.StringFixed(2)), nil
+		}
+	}
+
+	return rulesDecision(version, ReasonApproved, "transferência atende a todas as regras"), nil
+}
+
+func rulesDecision(version string, reason string, explanation string, args ...interface{}) *Decision {
+	return &Decision{
+		Authorized:  reason == ReasonApproved,
+		ReasonCode:  reason,
+		ReferenceID: SourceRules + "@" + version,
+		Source:      SourceRules,
+		Explanation: fmt.Sprintf(explanation, args...),
+	}
+}
+
+type chain []AuthorizationService
+
+// Chain consulta os serviços em ordem e para na primeira negação ou erro. A
+// decisão devolvida é a negação ou, se todos aprovarem, a do último; quando
+// mais de um serviço foi consultado, Steps traz a decisão de cada um, para que
+// a referência e a explicação do motor de regras fiquem gravadas junto com a
+// do autorizador externo.
+func Chain(services ...AuthorizationService) AuthorizationService {
+	return chain(services)
+}
+
+func (c chain) CheckAuthorization(ctx context.Context, request Request) (*Decision, error) {
+	var steps []Decision
+	for _, service := range c {
+		decision, err := service.CheckAuthorization(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, *decision)
+		if !decision.Authorized || len(steps) == len(c) {
+			return chained(decision, steps), nil
+		}
+	}
+	return nil, fmt.Errorf("nenhum serviço de autorização configurado")
+}
+
+func chained(decision *Decision, steps []Decision) *Decision {
+	if len(steps) < 2 {
+		return decision
+	}
+	result := *decision
+	result.Steps = steps
+	return &result
+}
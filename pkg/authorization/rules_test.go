@@ -0,0 +1,196 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHistory struct {
+	spent map[time.Time]decimal.Decimal
+	paid  map[int]bool
+	err   error
+}
+
+func (h *fakeHistory) Spent(payerID int, since time.Time) (decimal.Decimal, error) {
+	return h.spent[since], h.err
+}
+
+func (h *fakeHistory) HasPaid(payerID int, payeeID int) (bool, error) {
+	return h.paid[payeeID], h.err
+}
+
+const testRules = `
+max_amount: 1000
+daily_limit: "1500"
+monthly_limit: 3000
+new_payee_limit: 200.00
+blocked_payees: [9]
+`
+
+func writeRules(t *testing.T, path string, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func newTestEngine(t *testing.T, history History) *RulesEngine {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRules(t, path, testRules)
+
+	engine, err := NewRulesEngine(path, history)
+	require.NoError(t, err)
+	engine.now = func() time.Time { return time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC) }
+	return engine
+}
+
+func TestRulesEngine(t *testing.T) {
+	today := time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)
+	month := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		payee   int
+		amount  string
+		spent   map[time.Time]decimal.Decimal
+		reason  string
+		explain string
+	}{
+		{"Approved", 2, "500", nil, ReasonApproved, "transferência atende a todas as regras"},
+		{"BlockedPayee", 9, "10", nil, ReasonPayeeBlocked, "recebedor 9 está bloqueado"},
+		{"MaxAmount", 2, "1000.01", nil, ReasonMaxAmountExceeded, "valor 1000.01 excede o limite por transferência de 1000.00"},
+		{"NewPayee", 3, "250", nil, ReasonNewPayeeLimitExceeded, "valor 250.00 excede o limite de 200.00 para a primeira transferência a 3"},
+		{"NewPayeeWithinLimit", 3, "200", nil, ReasonApproved, "transferência atende a todas as regras"},
+		{"DailyLimit", 2, "600", map[time.Time]decimal.Decimal{today: decimal.NewFromInt(1000)}, ReasonDailyLimitExceeded, "valor 600.00 somado aos 1000.00 já enviados excede o limite diário de 1500.00"},
+		{"DailyLimitReached", 2, "500", map[time.Time]decimal.Decimal{today: decimal.NewFromInt(1000)}, ReasonApproved, "transferência atende a todas as regras"},
+		{"MonthlyLimit", 2, "500", map[time.Time]decimal.Decimal{month: decimal.NewFromInt(2600)}, ReasonMonthlyLimitExceeded, "valor 500.00 somado aos 2600.00 já enviados excede o limite mensal de 3000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestEngine(t, &fakeHistory{spent: tt.spent, paid: map[int]bool{2: true}})
+			request := testRequest
+			request.Payee.ID = tt.payee
+			request.Amount = decimal.RequireFromString(tt.amount)
+
+			decision, err := engine.CheckAuthorization(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.reason == ReasonApproved, decision.Authorized)
+			assert.Equal(t, tt.reason, decision.ReasonCode)
+			assert.Equal(t, tt.explain, decision.Explanation)
+			assert.Equal(t, SourceRules, decision.Source)
+			assert.Equal(t, "rules@"+engine.Version(), decision.ReferenceID)
+		})
+	}
+}
+
+func TestRulesEngineCheckLimits(t *testing.T) {
+	today := time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)
+	engine := newTestEngine(t, &fakeHistory{spent: map[time.Time]decimal.Decimal{today: decimal.NewFromInt(1000)}})
+
+	request := testRequest
+	request.Payee.ID = 9
+	request.Amount = decimal.NewFromInt(500)
+	decision, err := engine.CheckLimits(request)
+	require.NoError(t, err)
+	assert.True(t, decision.Authorized, "CheckLimits só aplica os limites de volume")
+
+	request.Amount = decimal.NewFromInt(600)
+	decision, err = engine.CheckLimits(request)
+	require.NoError(t, err)
+	assert.Equal(t, ReasonDailyLimitExceeded, decision.ReasonCode)
+	assert.Equal(t, "rules@"+engine.Version(), decision.ReferenceID)
+}
+
+func TestRulesEngineHistoryError(t *testing.T) {
+	engine := newTestEngine(t, &fakeHistory{err: errors.New("banco indisponível")})
+
+	_, err := engine.CheckAuthorization(context.Background(), testRequest)
+	assert.EqualError(t, err, "erro ao consultar o volume enviado por 1: banco indisponível")
+}
+
+func TestRulesEngineReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{"max_amount": "5"}`)
+
+	engine, err := NewRulesEngine(path, &fakeHistory{})
+	require.NoError(t, err)
+	version := engine.Version()
+
+	decision, err := engine.CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, ReasonMaxAmountExceeded, decision.ReasonCode)
+
+	changed, err := engine.Reload()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	writeRules(t, path, `{"max_amount": "50"}`)
+	changed, err = engine.Reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotEqual(t, version, engine.Version())
+
+	decision, err = engine.CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.True(t, decision.Authorized)
+
+	writeRules(t, path, `{"max_amount": "-1"}`)
+	_, err = engine.Reload()
+	assert.Error(t, err)
+	writeRules(t, path, `{"max_amount":`)
+	_, err = engine.Reload()
+	assert.Error(t, err)
+
+	decision, err = engine.CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.True(t, decision.Authorized, "regras inválidas mantêm as anteriores")
+}
+
+func TestNewRulesEngineRejectsInvalidFile(t *testing.T) {
+	_, err := NewRulesEngine(filepath.Join(t.TempDir(), "inexistente.json"), &fakeHistory{})
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{"daily_limit": "-10"}`)
+	_, err = NewRulesEngine(path, &fakeHistory{})
+	assert.EqualError(t, err, "regras de autorização "+path+" inválidas: regra daily_limit não pode ser negativa: -10")
+}
+
+type staticService struct {
+	decision *Decision
+	err      error
+	calls    int
+}
+
+func (s *staticService) CheckAuthorization(ctx context.Context, request Request) (*Decision, error) {
+	s.calls++
+	return s.decision, s.err
+}
+
+func TestChain(t *testing.T) {
+	approved := &Decision{Authorized: true, ReasonCode: ReasonApproved, Source: SourceRules}
+	remote := &Decision{Authorized: true, ReasonCode: ReasonApproved, Source: SourceRemote}
+	denied := &Decision{ReasonCode: ReasonPayeeBlocked, Source: SourceRules}
+
+	local, external := &staticService{decision: approved}, &staticService{decision: remote}
+	decision, err := Chain(local, external).CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &Decision{Authorized: true, ReasonCode: ReasonApproved, Source: SourceRemote, Steps: []Decision{*approved, *remote}}, decision)
+
+	local, external = &staticService{decision: denied}, &staticService{decision: remote}
+	decision, err = Chain(local, external).CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, denied, decision)
+	assert.Equal(t, 0, external.calls, "negação local não consulta o autorizador externo")
+
+	local, external = &staticService{err: errors.New("falha")}, &staticService{decision: remote}
+	_, err = Chain(local, external).CheckAuthorization(context.Background(), testRequest)
+	assert.EqualError(t, err, "falha")
+	assert.Equal(t, 0, external.calls)
+}
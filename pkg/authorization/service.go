@@ -1,66 +1,240 @@
 package authorization
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
 type AuthorizationService interface {
-	CheckAuthorization() (bool, error)
+	CheckAuthorization(ctx context.Context, request Request) (*Decision, error)
 }
 
-type authorizationService struct{}
+// RetryPolicy repete falhas transitórias (rede, timeout, 429 e 5xx), dobrando
+// a espera a cada tentativa até MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
 
-func NewAuthorizationService() AuthorizationService {
-	return &authorizationService{}
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return delay
 }
 
-func (s *authorizationService) CheckAuthorization() (bool, error) {
-	url := "https://util.devi.tools/api/v2/authorize"
+type Config struct {
+	BaseURL string
+	// Timeout vale para cada tentativa, não para a autorização inteira.
+	Timeout time.Duration
+	Retry   RetryPolicy
+	Breaker BreakerConfig
+}
 
-	log.Printf("Iniciando requisição de autorização para o serviço: %s", url)
+// DefaultConfig completa as opções não informadas; BaseURL não tem padrão e
+// vem da configuração da aplicação.
+var DefaultConfig = Config{
+	Timeout: 5 * time.Second,
+	Retry: RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	},
+	Breaker: BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	},
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Printf("Erro ao criar requisição: %v", err)
-		return false, fmt.Errorf("erro ao criar requisição: %v", err)
+// metrics fica em /debug/vars como "authorizer".
+var metrics = expvar.NewMap("authorizer")
+
+type authorizationService struct {
+	url     string
+	client  *http.Client
+	retry   RetryPolicy
+	breaker *breaker
+}
+
+func NewAuthorizationService(config Config) AuthorizationService {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultConfig.Timeout
+	}
+	if config.Retry.MaxAttempts < 1 {
+		config.Retry.MaxAttempts = 1
+	}
+
+	state := new(expvar.String)
+	metrics.Set("breaker_state", state)
+
+	return &authorizationService{
+		url:    config.BaseURL + "/authorize",
+		client: &http.Client{Timeout: config.Timeout},
+		retry:  config.Retry,
+		breaker: newBreaker(config.Breaker, func(s BreakerState) {
+			log.Printf("Circuit breaker do autorizador: %s", s)
+			state.Set(string(s))
+		}),
+	}
+}
+
+// transientError marca as falhas que valem uma nova tentativa.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+func (s *authorizationService) CheckAuthorization(ctx context.Context, request Request) (*Decision, error) {
+	var err error
+	for attempt := 1; attempt <= s.retry.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(s.retry.delay(attempt - 1)):
+			}
+			log.Printf("Nova tentativa de autorização (%d de %d): %v", attempt, s.retry.MaxAttempts, err)
+		}
+
+		var decision *Decision
+		decision, err = s.attempt(ctx, request)
+		if err == nil {
+			return decision, nil
+		}
+
+		var transient *transientError
+		if !errors.As(err, &transient) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (s *authorizationService) attempt(ctx context.Context, request Request) (*Decision, error) {
+	if err := s.breaker.allow(); err != nil {
+		metrics.Add("rejected", 1)
+		return nil, err
+	}
+	metrics.Add("requests", 1)
+
+	decision, err := s.request(ctx, request)
+	switch {
+	case err == nil:
+		s.breaker.success()
+	case ctx.Err() != nil:
+		// Cancelado por quem chamou: não diz nada sobre o autorizador.
+		s.breaker.release()
+		return nil, ctx.Err()
+	default:
+		metrics.Add("failures", 1)
+		s.breaker.failure()
 	}
+	return decision, err
+}
+
+// query leva a requisição na query string: o autorizador só aceita GET.
+func query(request Request) url.Values {
+	values := url.Values{}
+	values.Set("transfer_id", request.TransferID)
+	values.Set("payer", strconv.Itoa(request.Payer.ID))
+	values.Set("payer_type", request.Payer.Type)
+	values.Set("payee", strconv.Itoa(request.Payee.ID))
+	values.Set("payee_type", request.Payee.Type)
+	values.Set("amount", request.Amount.String())
+
+	metadata := map[string]string{
+		"ip":         request.Metadata.IP,
+		"user_agent": request.Metadata.UserAgent,
+		"device_id":  request.Metadata.DeviceID,
+	}
+	for key, value := range metadata {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values
+}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+func (s *authorizationService) request(ctx context.Context, request Request) (*Decision, error) {
+	log.Printf("Iniciando requisição de autorização da transferência %s para o serviço: %s", request.TransferID, s.url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"?"+query(request).Encode(), nil)
+	if err != nil {
+		log.Printf("Erro ao criar requisição: %v", err)
+		return nil, fmt.Errorf("erro ao criar requisição: %v", err)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		log.Printf("Erro ao enviar requisição para %s: %v", url, err)
-		return false, fmt.Errorf("erro ao enviar requisição: %v", err)
+		log.Printf("Erro ao enviar requisição para %s: %v", s.url, err)
+		return nil, &transientError{fmt.Errorf("erro ao enviar requisição: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	log.Printf("Resposta do serviço de autorização, status code: %d", resp.StatusCode)
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return nil, &transientError{fmt.Errorf("erro ao consultar serviço de autorização, código de status: %d", resp.StatusCode)}
+	}
+
+	// O autorizador responde 403 com o corpo normal quando nega a transferência.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusForbidden {
 		log.Printf("Erro ao consultar serviço de autorização, código de status: %d", resp.StatusCode)
-		return false, fmt.Errorf("erro ao consultar serviço de autorização, código de status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("erro ao consultar serviço de autorização, código de status: %d", resp.StatusCode)
 	}
 
 	var authorizationResponse Authorization
 	if err := json.NewDecoder(resp.Body).Decode(&authorizationResponse); err != nil {
 		log.Printf("Erro ao decodificar a resposta da autorização: %v", err)
-		return false, fmt.Errorf("erro ao decodificar a resposta: %v", err)
+		return nil, fmt.Errorf("erro ao decodificar a resposta: %v", err)
 	}
 
 	log.Printf("Resposta do serviço de autorização: %s", authorizationResponse.Status)
 
-	if authorizationResponse.Status != "success" {
+	if resp.StatusCode != http.StatusForbidden && authorizationResponse.Status != "success" {
 		log.Printf("Serviço de autorização falhou, status: %s", authorizationResponse.Status)
-		return false, fmt.Errorf("serviço de autorização falhou, status: %s", authorizationResponse.Status)
+		return nil, fmt.Errorf("serviço de autorização falhou, status: %s", authorizationResponse.Status)
 	}
 
-	log.Println("Serviço de autorização bem-sucedido")
-
-	return authorizationResponse.Data.Authorization, nil
+	data := authorizationResponse.Data
+	decision := &Decision{
+		Authorized:  resp.StatusCode == http.StatusOK && data.Authorization,
+		ReasonCode:  data.ReasonCode,
+		ReferenceID: data.ReferenceID,
+		Source:      SourceRemote,
+		Explanation: "negada pelo autorizador externo",
+	}
+	if decision.Authorized {
+		decision.Explanation = "aprovada pelo autorizador externo"
+	}
+	if decision.ReasonCode == "" {
+		decision.ReasonCode = ReasonDenied
+		if decision.Authorized {
+			decision.ReasonCode = ReasonApproved
+		}
+	}
+	if decision.ReferenceID == "" {
+		decision.ReferenceID = resp.Header.Get("X-Request-Id")
+	}
+	return decision, nil
 }
@@ -0,0 +1,235 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	authorizedBody = `{"status": "success", "data": {"authorization": true}}`
+	deniedBody     = `{"status": "fail", "data": {"authorization": false}}`
+)
+
+var testRequest = Request{
+	TransferID: "t1",
+	Payer:      Party{ID: 1, Type: "common_user"},
+	Payee:      Party{ID: 2, Type: "merchant"},
+	Amount:     decimal.RequireFromString("10.5"),
+	Metadata:   Metadata{IP: "203.0.113.7", DeviceID: "device-1"},
+}
+
+type response struct {
+	status int
+	body   string
+	delay  time.Duration
+}
+
+// startAuthorizer responde na ordem de responses e repete a última resposta
+// quando elas acabam.
+func startAuthorizer(t *testing.T, responses ...response) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/authorize", r.URL.Path)
+
+		i := int(atomic.AddInt32(&calls, 1)) - 1
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+
+		select {
+		case <-time.After(responses[i].delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(responses[i].status)
+		w.Write([]byte(responses[i].body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestService(url string, attempts int, threshold int) *authorizationService {
+	return NewAuthorizationService(Config{
+		BaseURL: url,
+		Timeout: 50 * time.Millisecond,
+		Retry:   RetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+		Breaker: BreakerConfig{FailureThreshold: threshold, OpenTimeout: time.Minute},
+	}).(*authorizationService)
+}
+
+func TestCheckAuthorization(t *testing.T) {
+	server, _ := startAuthorizer(t, response{status: http.StatusOK, body: authorizedBody})
+
+	decision, err := newTestService(server.URL, 1, 5).CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &Decision{Authorized: true, ReasonCode: ReasonApproved, Source: SourceRemote, Explanation: "aprovada pelo autorizador externo"}, decision)
+}
+
+func TestCheckAuthorizationSendsRequest(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Write([]byte(`{"status": "success", "data": {"authorization": true, "reason_code": "low_risk"}}`))
+	}))
+	defer server.Close()
+
+	decision, err := newTestService(server.URL, 1, 5).CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &Decision{Authorized: true, ReasonCode: "low_risk", ReferenceID: "req-1", Source: SourceRemote, Explanation: "aprovada pelo autorizador externo"}, decision)
+	assert.Equal(t, url.Values{
+		"transfer_id": {"t1"},
+		"payer":       {"1"},
+		"payer_type":  {"common_user"},
+		"payee":       {"2"},
+		"payee_type":  {"merchant"},
+		"amount":      {"10.5"},
+		"ip":          {"203.0.113.7"},
+		"device_id":   {"device-1"},
+	}, query)
+}
+
+func TestCheckAuthorizationDenied(t *testing.T) {
+	server, calls := startAuthorizer(t, response{status: http.StatusForbidden, body: deniedBody})
+	service := newTestService(server.URL, 3, 1)
+
+	decision, err := service.CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &Decision{ReasonCode: ReasonDenied, Source: SourceRemote, Explanation: "negada pelo autorizador externo"}, decision)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "negação não é repetida")
+	assert.Equal(t, BreakerClosed, service.breaker.State(), "negação não conta como falha")
+}
+
+func TestCheckAuthorizationRetriesServerErrors(t *testing.T) {
+	server, calls := startAuthorizer(t,
+		response{status: http.StatusServiceUnavailable},
+		response{status: http.StatusBadGateway},
+		response{status: http.StatusOK, body: authorizedBody},
+	)
+
+	decision, err := newTestService(server.URL, 3, 5).CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.True(t, decision.Authorized)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestCheckAuthorizationGivesUpAfterMaxAttempts(t *testing.T) {
+	server, calls := startAuthorizer(t, response{status: http.StatusInternalServerError})
+
+	_, err := newTestService(server.URL, 3, 5).CheckAuthorization(context.Background(), testRequest)
+	assert.EqualError(t, err, "erro ao consultar serviço de autorização, código de status: 500")
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestCheckAuthorizationTimesOutSlowResponses(t *testing.T) {
+	server, calls := startAuthorizer(t,
+		response{status: http.StatusOK, body: authorizedBody, delay: time.Second},
+		response{status: http.StatusOK, body: authorizedBody},
+	)
+
+	decision, err := newTestService(server.URL, 2, 5).CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.True(t, decision.Authorized)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestCheckAuthorizationRejectsMalformedBody(t *testing.T) {
+	server, calls := startAuthorizer(t, response{status: http.StatusOK, body: `{"status": "success", "data":`})
+
+	_, err := newTestService(server.URL, 3, 5).CheckAuthorization(context.Background(), testRequest)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "corpo inválido não é repetido")
+}
+
+func TestCheckAuthorizationHonorsContext(t *testing.T) {
+	server, _ := startAuthorizer(t, response{status: http.StatusOK, body: authorizedBody, delay: time.Second})
+	service := newTestService(server.URL, 3, 1)
+	service.client.Timeout = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := service.CheckAuthorization(ctx, testRequest)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "CheckAuthorization: %v", err)
+	assert.Equal(t, BreakerClosed, service.breaker.State(), "cancelamento não conta como falha")
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	healthy := int32(0)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(authorizedBody))
+	}))
+	defer server.Close()
+
+	service := newTestService(server.URL, 1, 2)
+	now := time.Now()
+	service.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, err := service.CheckAuthorization(context.Background(), testRequest)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, BreakerOpen, service.breaker.State())
+	assert.Equal(t, `"open"`, metrics.Get("breaker_state").String())
+
+	_, err := service.CheckAuthorization(context.Background(), testRequest)
+	assert.True(t, errors.Is(err, ErrCircuitOpen), "CheckAuthorization: %v", err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "circuito aberto não chama o autorizador")
+
+	now = now.Add(time.Minute)
+	_, err = service.CheckAuthorization(context.Background(), testRequest)
+	assert.Error(t, err)
+	assert.Equal(t, BreakerOpen, service.breaker.State(), "falha no half-open reabre o circuito")
+
+	now = now.Add(time.Minute)
+	atomic.StoreInt32(&healthy, 1)
+	decision, err := service.CheckAuthorization(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.True(t, decision.Authorized)
+	assert.Equal(t, BreakerClosed, service.breaker.State())
+	assert.Equal(t, `"closed"`, metrics.Get("breaker_state").String())
+}
+
+func TestBreakerAllowsSingleProbeWhenHalfOpen(t *testing.T) {
+	b := newBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second}, func(BreakerState) {})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	assert.True(t, errors.Is(b.allow(), ErrCircuitOpen))
+
+	now = now.Add(time.Second)
+	require.NoError(t, b.allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.True(t, errors.Is(b.allow(), ErrCircuitOpen), "só uma requisição de teste por vez")
+
+	b.release()
+	require.NoError(t, b.allow())
+	b.success()
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, policy.delay(1))
+	assert.Equal(t, 200*time.Millisecond, policy.delay(2))
+	assert.Equal(t, 300*time.Millisecond, policy.delay(3))
+	assert.Equal(t, 300*time.Millisecond, policy.delay(10))
+}
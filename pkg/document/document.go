@@ -0,0 +1,125 @@
+// Package document valida, normaliza e formata CPF e CNPJ.
+package document
+
+import "strings"
+
+type Type string
+
+const (
+	CPF  Type = "cpf"
+	CNPJ Type = "cnpj"
+)
+
+const (
+	cpfLength  = 11
+	cnpjLength = 14
+)
+
+// Normalize remove a pontuação e os espaços e põe as letras em maiúsculas,
+// deixando o documento como é gravado. Outros caracteres são mantidos para
+// que a validação os rejeite.
+func Normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '-', '/', ' ', '\t':
+			return -1
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, value)
+}
+
+// Valid confere os dígitos verificadores de value, com ou sem pontuação.
+func Valid(kind Type, value string) bool {
+	switch kind {
+	case CPF:
+		return ValidCPF(value)
+	case CNPJ:
+		return ValidCNPJ(value)
+	}
+	return false
+}
+
+func ValidCPF(value string) bool {
+	cpf := Normalize(value)
+	if len(cpf) != cpfLength || !digits(cpf) || repeated(cpf) {
+		return false
+	}
+
+	return checkDigit(cpf[:9], cpfWeights(10)) == cpf[9] && checkDigit(cpf[:10], cpfWeights(11)) == cpf[10]
+}
+
+// ValidCNPJ aceita também o CNPJ alfanumérico, em que as 12 primeiras
+// posições podem ter letras; os dígitos verificadores são sempre numéricos.
+func ValidCNPJ(value string) bool {
+	cnpj := Normalize(value)
+	if len(cnpj) != cnpjLength || !alphanumeric(cnpj[:12]) || !digits(cnpj[12:]) || repeated(cnpj) {
+		return false
+	}
+
+	first := []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	second := append([]int{6}, first...)
+	return checkDigit(cnpj[:12], first) == cnpj[12] && checkDigit(cnpj[:13], second) == cnpj[13]
+}
+
+// Format devolve o documento com a pontuação usual, ou value sem alterações
+// quando ele não tem o tamanho de um CPF ou de um CNPJ.
+func Format(value string) string {
+	normalized := Normalize(value)
+	switch len(normalized) {
+	case cpfLength:
+		return normalized[:3] + "." + normalized[3:6] + "." + normalized[6:9] + "-" + normalized[9:]
+	case cnpjLength:
+		return normalized[:2] + "." + normalized[2:5] + "." + normalized[5:8] + "/" + normalized[8:12] + "-" + normalized[12:]
+	}
+	return value
+}
+
+func cpfWeights(first int) []int {
+	weights := make([]int, first-1)
+	for i := range weights {
+		weights[i] = first - i
+	}
+	return weights
+}
+
+// checkDigit calcula o dígito verificador pelo módulo 11. Cada caractere vale
+// o seu código ASCII menos 48, o que dá o próprio valor para dígitos e 17 a 42
+// para as letras do CNPJ alfanumérico.
+func checkDigit(value string, weights []int) byte {
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		sum += int(value[i]-'0') * weights[i]
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+func digits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func alphanumeric(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if (value[i] < '0' || value[i] > '9') && (value[i] < 'A' || value[i] > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// repeated rejeita sequências como 111.111.111-11, que passam no cálculo.
+func repeated(value string) bool {
+	return strings.Count(value, value[:1]) == len(value)
+}
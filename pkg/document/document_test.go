@@ -0,0 +1,89 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidCPF(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"52998224725", true},
+		{"529.982.247-25", true},
+		{" 390.533.447-05 ", true},
+		{"11144477735", true},
+		{"52998224724", false},
+		{"52998224715", false},
+		{"5299822472", false},
+		{"529982247250", false},
+		{"11111111111", false},
+		{"00000000000", false},
+		{"5299822472a", false},
+		{"529,982,247-25", false},
+		{"", false},
+		{"11222333000181", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.valid, ValidCPF(c.value), "ValidCPF(%q)", c.value)
+		assert.Equal(t, c.valid, Valid(CPF, c.value), "Valid(CPF, %q)", c.value)
+	}
+}
+
+func TestValidCNPJ(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"11222333000181", true},
+		{"11.222.333/0001-81", true},
+		{"12ABC34501DE35", true},
+		{"12.abc.345/01de-35", true},
+		{"11222333000182", false},
+		{"11222333000191", false},
+		{"1122233300018", false},
+		{"112223330001811", false},
+		{"00000000000000", false},
+		{"12ABC34501DE3A", false},
+		{"12ABC34501DE36", false},
+		{"12ÁBC34501DE35", false},
+		{"", false},
+		{"52998224725", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.valid, ValidCNPJ(c.value), "ValidCNPJ(%q)", c.value)
+		assert.Equal(t, c.valid, Valid(CNPJ, c.value), "Valid(CNPJ, %q)", c.value)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"529.982.247-25":     "52998224725",
+		"11.222.333/0001-81": "11222333000181",
+		"12.abc.345/01de-35": "12ABC34501DE35",
+		" 529 982 247 25 ":   "52998224725",
+		"529,982,247-25":     "529,982,24725",
+	}
+
+	for value, expected := range cases {
+		assert.Equal(t, expected, Normalize(value), "Normalize(%q)", value)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := map[string]string{
+		"52998224725":    "529.982.247-25",
+		"529.982.247-25": "529.982.247-25",
+		"11222333000181": "11.222.333/0001-81",
+		"12abc34501de35": "12.ABC.345/01DE-35",
+		"123":            "123",
+	}
+
+	for value, expected := range cases {
+		assert.Equal(t, expected, Format(value), "Format(%q)", value)
+	}
+}
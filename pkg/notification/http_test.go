@@ -0,0 +1,72 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pag-simples/pkg/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startHTTPServer(t *testing.T, status int) (*httptest.Server, <-chan map[string]interface{}) {
+	bodies := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server, bodies := startHTTPServer(t, http.StatusNoContent)
+
+	// O servidor de teste está no loopback, que webhook.Send recusa.
+	assert.True(t, errors.Is(WebhookNotifier{}.Notify(Recipient{Name: "Loja", WebhookURL: server.URL}, Message{Body: "olá"}), webhook.ErrPrivateAddress))
+	previous := sendWebhook
+	sendWebhook = webhook.SendTrusted
+	t.Cleanup(func() { sendWebhook = previous })
+
+	err := WebhookNotifier{}.Notify(
+		Recipient{Name: "Loja", WebhookURL: server.URL},
+		Message{Event: EventTransferReceived, Subject: "Transferência recebida", Body: "Você recebeu 10.00 de Maria"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"event":   "transfer_received",
+		"subject": "Transferência recebida",
+		"message": "Você recebeu 10.00 de Maria",
+	}, <-bodies)
+
+	assert.Error(t, WebhookNotifier{}.Notify(Recipient{Name: "Loja"}, Message{Body: "olá"}))
+}
+
+func TestSMSNotifier(t *testing.T) {
+	server, bodies := startHTTPServer(t, http.StatusOK)
+
+	err := NewSMSNotifier(server.URL).Notify(
+		Recipient{Name: "João", Phone: "+5511999990000"},
+		Message{Event: EventTransferSent, Body: "Transferência de 10.00 para Maria foi realizada com sucesso"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"phone_number": "+5511999990000",
+		"message":      "Transferência de 10.00 para Maria foi realizada com sucesso",
+	}, <-bodies)
+
+	assert.Error(t, NewSMSNotifier(server.URL).Notify(Recipient{Name: "João"}, Message{Body: "olá"}))
+}
+
+func TestSMSNotifierFailsOnGatewayError(t *testing.T) {
+	server, _ := startHTTPServer(t, http.StatusServiceUnavailable)
+
+	err := NewSMSNotifier(server.URL).Notify(Recipient{Name: "João", Phone: "+5511999990000"}, Message{Body: "olá"})
+	assert.Error(t, err)
+}
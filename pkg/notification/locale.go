@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type Locale string
+
+const (
+	LocalePtBR Locale = "pt-BR"
+	LocaleEnUS Locale = "en-US"
+	LocaleES   Locale = "es"
+
+	DefaultLocale = LocalePtBR
+)
+
+var Locales = []Locale{LocalePtBR, LocaleEnUS, LocaleES}
+
+func (l Locale) Valid() bool {
+	for _, locale := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+type currencyFormat struct {
+	symbol    string
+	thousands string
+	decimal   string
+}
+
+var currencyFormats = map[Locale]currencyFormat{
+	LocalePtBR: {symbol: "R$ ", thousands: ".", decimal: ","},
+	LocaleEnUS: {symbol: "R$", thousands: ",", decimal: "."},
+	LocaleES:   {symbol: "R$ ", thousands: ".", decimal: ","},
+}
+
+// FormatBRL formata amount em reais com os separadores de locale, como
+// "R$ 1.234,56" em pt-BR e "R$1,234.56" em en-US.
+func FormatBRL(amount decimal.Decimal, locale Locale) string {
+	format, exists := currencyFormats[locale]
+	if !exists {
+		format = currencyFormats[DefaultLocale]
+	}
+
+	fixed := amount.Abs().StringFixed(2)
+	integer, fraction := fixed[:len(fixed)-3], fixed[len(fixed)-2:]
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(format.thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	sign := ""
+	if amount.Round(2).IsNegative() {
+		sign = "-"
+	}
+	return sign + format.symbol + grouped.String() + format.decimal + fraction
+}
@@ -0,0 +1,12 @@
+package notification
+
+import "log"
+
+// LogNotifier só registra a mensagem no log. Serve para quem não quer ser
+// notificado e para canais sem servidor configurado.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(recipient Recipient, message Message) error {
+	log.Printf("Notificação %s para %s: %s", message.Event, recipient.Name, message.Body)
+	return nil
+}
@@ -1,16 +1,69 @@
 package notification
 
-type Notification struct {
-    UserID  int    `json:"user_id"`
-    Status  string `json:"status"`
-		Data	 Data   `json:"data"`
+import "github.com/shopspring/decimal"
+
+type Channel string
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelSMS     Channel = "sms"
+	ChannelWebhook Channel = "webhook"
+	ChannelNone    Channel = "none"
+)
+
+var Channels = []Channel{ChannelEmail, ChannelSMS, ChannelWebhook, ChannelNone}
+
+func (c Channel) Valid() bool {
+	for _, channel := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+type Event string
+
+const (
+	EventTransferSent     Event = "transfer_sent"
+	EventTransferReceived Event = "transfer_received"
+	EventRefundIssued     Event = "refund_issued"
+	EventLowBalance       Event = "low_balance"
+	EventPasswordReset    Event = "password_reset"
+)
+
+// Recipient traz os contatos do usuário e as preferências dele; Channel vazio
+// equivale a ChannelEmail e Locale vazio a DefaultLocale.
+type Recipient struct {
+	Name       string
+	Email      string
+	Phone      string
+	WebhookURL string
+	Channel    Channel
+	Locale     Locale
 }
 
+// Data alimenta os templates; Name é preenchido com o nome do destinatário. Em
+// low_balance, Amount é o saldo da wallet. Token só é usado em password_reset,
+// que é enviado direto, sem passar pelo outbox.
 type Data struct {
-		Message string `json:"message"` 
+	Name         string          `json:"name,omitempty"`
+	Counterparty string          `json:"counterparty"`
+	Amount       decimal.Decimal `json:"amount"`
+	Token        string          `json:"-"`
+}
+
+// Notification é o evento gravado no outbox: o destinatário e o canal são
+// resolvidos só na entrega.
+type Notification struct {
+	Event  Event `json:"event"`
+	UserID int   `json:"user_id"`
+	Data   Data  `json:"data"`
 }
 
-type NotificationRequest struct {
-	Email string `json:"phone_number"`
-	Message     string `json:"message"`
+// Message é a notificação já renderizada, pronta para um Notifier.
+type Message struct {
+	Event   Event  `json:"event"`
+	Subject string `json:"subject"`
+	Body    string `json:"message"`
 }
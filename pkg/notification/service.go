@@ -1,48 +1,66 @@
 package notification
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
+	"sync"
 )
 
-func SendNotification(request NotificationRequest) error {
-	url := "https://util.devi.tools/api/v1/notify"
+// Notifier entrega uma mensagem já renderizada por um canal.
+type Notifier interface {
+	Notify(recipient Recipient, message Message) error
+}
 
-	log.Printf("Iniciando requisição para enviar a notificação para o serviço: %s", url)
+// Service escolhe o Notifier pelo canal preferido do destinatário e monta a
+// mensagem pelo template do evento.
+type Service struct {
+	templates *Templates
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		log.Printf("Erro ao codificar a requisição para JSON: %v", err)
-		return fmt.Errorf("erro ao codificar a requisição: %v", err)
-	}
+	mu        sync.RWMutex
+	notifiers map[Channel]Notifier
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Printf("Erro ao criar a requisição: %v", err)
-		return fmt.Errorf("erro ao criar a requisição: %v", err)
+func NewService(templates *Templates) *Service {
+	return &Service{
+		templates: templates,
+		notifiers: make(map[Channel]Notifier),
 	}
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (s *Service) Register(channel Channel, notifier Notifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifiers[channel] = notifier
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Erro ao enviar a requisição para %s: %v", url, err)
-		return fmt.Errorf("erro ao enviar a requisição: %v", err)
+// Send falha quando o canal do destinatário não está registrado, para que a
+// notificação não seja dada como entregue.
+func (s *Service) Send(recipient Recipient, event Event, data Data) error {
+	channel := recipient.Channel
+	if channel == "" {
+		channel = ChannelEmail
 	}
-	defer resp.Body.Close()
 
-	log.Printf("Resposta do serviço de notificação, status code: %d", resp.StatusCode)
+	s.mu.RLock()
+	notifier, exists := s.notifiers[channel]
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("canal de notificação %s não configurado", channel)
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Erro ao enviar a notificação, status code: %d", resp.StatusCode)
-		return fmt.Errorf("erro ao enviar a notificação, status code: %d", resp.StatusCode)
+	if data.Name == "" {
+		data.Name = recipient.Name
 	}
 
-	log.Println("Notificação enviada com sucesso")
+	message, err := s.templates.Render(recipient.Locale, event, data)
+	if err != nil {
+		log.Printf("Erro ao montar notificação %s: %v", event, err)
+		return err
+	}
 
+	if err := notifier.Notify(recipient, message); err != nil {
+		log.Printf("Erro ao enviar notificação %s por %s: %v", event, channel, err)
+		return err
+	}
 	return nil
 }
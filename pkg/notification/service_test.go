@@ -0,0 +1,143 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	recipients []Recipient
+	messages   []Message
+	err        error
+}
+
+func (n *recordingNotifier) Notify(recipient Recipient, message Message) error {
+	n.recipients = append(n.recipients, recipient)
+	n.messages = append(n.messages, message)
+	return n.err
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	templates := DefaultTemplates()
+	data := Data{Counterparty: "Maria", Amount: decimal.RequireFromString("1234.5"), Token: "abc123"}
+
+	expected := map[Locale]map[Event]string{
+		LocalePtBR: {
+			EventTransferSent:     "Transferência de R$ 1.234,50 para Maria foi realizada com sucesso",
+			EventTransferReceived: "Você recebeu R$ 1.234,50 de Maria",
+			EventRefundIssued:     "Você recebeu um estorno de R$ 1.234,50 de Maria",
+			EventLowBalance:       "Seu saldo está baixo: R$ 1.234,50",
+			EventPasswordReset:    "Use o código abc123 para redefinir sua senha. Se não foi você quem pediu, ignore esta mensagem",
+		},
+		LocaleEnUS: {
+			EventTransferSent:     "Your transfer of R$1,234.50 to Maria was completed successfully",
+			EventTransferReceived: "You received R$1,234.50 from Maria",
+			EventRefundIssued:     "You received a refund of R$1,234.50 from Maria",
+			EventLowBalance:       "Your balance is low: R$1,234.50",
+			EventPasswordReset:    "Use the code abc123 to reset your password. If you did not request it, ignore this message",
+		},
+		LocaleES: {
+			EventTransferSent:     "Tu transferencia de R$ 1.234,50 a Maria se realizó con éxito",
+			EventTransferReceived: "Recibiste R$ 1.234,50 de Maria",
+			EventRefundIssued:     "Recibiste un reembolso de R$ 1.234,50 de Maria",
+			EventLowBalance:       "Tu saldo está bajo: R$ 1.234,50",
+			EventPasswordReset:    "Usa el código abc123 para restablecer tu contraseña. Si no lo solicitaste, ignora este mensaje",
+		},
+	}
+	for locale, events := range expected {
+		for event, body := range events {
+			message, err := templates.Render(locale, event, data)
+			require.NoError(t, err, "%s %s", locale, event)
+			assert.Equal(t, event, message.Event)
+			assert.Equal(t, body, message.Body)
+			assert.NotEmpty(t, message.Subject)
+		}
+	}
+
+	_, err := templates.Render(LocalePtBR, "desconhecido", data)
+	assert.Error(t, err)
+}
+
+func TestRenderFallsBackToDefaultLocale(t *testing.T) {
+	templates, err := NewTemplates(map[Locale]map[Event]Template{
+		LocalePtBR: {EventLowBalance: {Subject: "Saldo baixo", Body: "Saldo: {{money .Amount}}"}},
+		LocaleEnUS: {},
+	})
+	require.NoError(t, err)
+
+	for _, locale := range []Locale{LocaleEnUS, "", "fr-FR"} {
+		message, err := templates.Render(locale, EventLowBalance, Data{Amount: decimal.NewFromInt(5)})
+		require.NoError(t, err, locale)
+		assert.Equal(t, "Saldo: R$ 5,00", message.Body)
+	}
+}
+
+func TestNewTemplatesRejectsInvalidTemplate(t *testing.T) {
+	_, err := NewTemplates(map[Locale]map[Event]Template{
+		LocalePtBR: {EventTransferSent: {Subject: "ok", Body: "{{.Amount"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestFormatBRL(t *testing.T) {
+	cases := []struct {
+		amount   string
+		locale   Locale
+		expected string
+	}{
+		{"1234.56", LocalePtBR, "R$ 1.234,56"},
+		{"1234.56", LocaleEnUS, "R$1,234.56"},
+		{"1234.56", LocaleES, "R$ 1.234,56"},
+		{"0", LocalePtBR, "R$ 0,00"},
+		{"0.5", LocalePtBR, "R$ 0,50"},
+		{"999.999", LocalePtBR, "R$ 1.000,00"},
+		{"1234567.8", LocalePtBR, "R$ 1.234.567,80"},
+		{"123456", LocaleEnUS, "R$123,456.00"},
+		{"-1234.56", LocalePtBR, "-R$ 1.234,56"},
+		{"-0.001", LocalePtBR, "R$ 0,00"},
+		{"10", "fr-FR", "R$ 10,00"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, FormatBRL(decimal.RequireFromString(c.amount), c.locale), "%s em %s", c.amount, c.locale)
+	}
+}
+
+func TestServiceRoutesByPreferredChannel(t *testing.T) {
+	email := &recordingNotifier{}
+	sms := &recordingNotifier{}
+	service := NewService(DefaultTemplates())
+	service.Register(ChannelEmail, email)
+	service.Register(ChannelSMS, sms)
+
+	data := Data{Counterparty: "Maria", Amount: decimal.NewFromInt(10)}
+	require.NoError(t, service.Send(Recipient{Name: "João", Email: "joao@email.com"}, EventTransferReceived, data))
+	require.NoError(t, service.Send(Recipient{Name: "Ana", Phone: "+5511999990000", Channel: ChannelSMS}, EventTransferReceived, data))
+
+	require.NoError(t, service.Send(Recipient{Name: "John", Email: "john@email.com", Locale: LocaleEnUS}, EventTransferReceived, data))
+
+	require.Len(t, email.messages, 2, "canal vazio usa e-mail")
+	assert.Equal(t, "Você recebeu R$ 10,00 de Maria", email.messages[0].Body)
+	assert.Equal(t, "You received R$10.00 from Maria", email.messages[1].Body)
+	require.Len(t, sms.recipients, 1)
+	assert.Equal(t, "Ana", sms.recipients[0].Name)
+
+	err := service.Send(Recipient{Name: "Loja", Channel: ChannelWebhook}, EventTransferReceived, data)
+	assert.EqualError(t, err, "canal de notificação webhook não configurado")
+
+	sms.err = errors.New("gateway fora do ar")
+	err = service.Send(Recipient{Name: "Ana", Phone: "+5511999990000", Channel: ChannelSMS}, EventTransferReceived, data)
+	assert.EqualError(t, err, "gateway fora do ar")
+}
+
+func TestChannelValid(t *testing.T) {
+	for _, channel := range Channels {
+		assert.True(t, channel.Valid(), channel)
+	}
+	assert.False(t, Channel("pombo").Valid())
+	assert.False(t, Channel("").Valid())
+}
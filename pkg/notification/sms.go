@@ -0,0 +1,35 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+
+	"pag-simples/pkg/webhook"
+)
+
+type smsRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Message     string `json:"message"`
+}
+
+// SMSNotifier adapta a mensagem para o gateway de SMS em URL, que vem da
+// configuração e pode estar na rede interna.
+type SMSNotifier struct {
+	URL string
+}
+
+func NewSMSNotifier(url string) *SMSNotifier {
+	return &SMSNotifier{URL: url}
+}
+
+func (n *SMSNotifier) Notify(recipient Recipient, message Message) error {
+	if recipient.Phone == "" {
+		return fmt.Errorf("destinatário %s sem telefone", recipient.Name)
+	}
+
+	log.Printf("Enviando SMS pelo gateway %s", n.URL)
+	if err := webhook.SendTrusted(n.URL, smsRequest{PhoneNumber: recipient.Phone, Message: message.Body}); err != nil {
+		return fmt.Errorf("erro ao enviar SMS: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+)
+
+// SMTPNotifier envia a mensagem por e-mail. Sem Username, a conexão é feita
+// sem autenticação.
+type SMTPNotifier struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (n *SMTPNotifier) Notify(recipient Recipient, message Message) error {
+	if recipient.Email == "" {
+		return fmt.Errorf("destinatário %s sem e-mail", recipient.Name)
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Addr)
+		if err != nil {
+			return fmt.Errorf("endereço SMTP inválido %q: %v", n.Addr, err)
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	if err := smtp.SendMail(n.Addr, auth, n.From, []string{recipient.Email}, n.compose(recipient, message)); err != nil {
+		return fmt.Errorf("erro ao enviar e-mail para %s: %v", recipient.Email, err)
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) compose(recipient Recipient, message Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", recipient.Email)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(message.Body)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
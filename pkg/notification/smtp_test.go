@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer sobe um servidor SMTP mínimo que aceita uma mensagem por
+// conexão e a publica em mails.
+func startSMTPServer(t *testing.T) (string, <-chan receivedMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan receivedMail, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+	return listener.Addr().String(), mails
+}
+
+func serveSMTP(conn net.Conn, mails chan<- receivedMail) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+
+	var mail receivedMail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			text.PrintfLine("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			mail.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			mail.to = append(mail.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 envie a mensagem")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			text.PrintfLine("250 OK")
+			mails <- mail
+		case command == "QUIT":
+			text.PrintfLine("221 tchau")
+			return
+		default:
+			text.PrintfLine("502 comando não suportado")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, mails := startSMTPServer(t)
+	notifier := &SMTPNotifier{Addr: addr, From: "nao-responda@pag-simples.local"}
+
+	err := notifier.Notify(
+		Recipient{Name: "João", Email: "joao@email.com"},
+		Message{Event: EventTransferReceived, Subject: "Transferência recebida", Body: "Você recebeu 10.00 de Maria"},
+	)
+	require.NoError(t, err)
+
+	mail := <-mails
+	assert.Equal(t, "nao-responda@pag-simples.local", mail.from)
+	assert.Equal(t, []string{"joao@email.com"}, mail.to)
+
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(mail.data))).ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, "joao@email.com", headers.Get("To"))
+	assert.Equal(t, "=?utf-8?q?Transfer=C3=AAncia_recebida?=", headers.Get("Subject"))
+	assert.Equal(t, "text/plain; charset=utf-8", headers.Get("Content-Type"))
+	assert.Contains(t, mail.data, "Você recebeu 10.00 de Maria")
+}
+
+func TestSMTPNotifierRequiresEmail(t *testing.T) {
+	notifier := &SMTPNotifier{Addr: "127.0.0.1:1", From: "nao-responda@pag-simples.local"}
+	assert.Error(t, notifier.Notify(Recipient{Name: "João"}, Message{Body: "olá"}))
+}
+
+func TestSMTPNotifierFailsWhenServerIsDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	notifier := &SMTPNotifier{Addr: addr, From: "nao-responda@pag-simples.local"}
+	assert.Error(t, notifier.Notify(Recipient{Name: "João", Email: "joao@email.com"}, Message{Body: "olá"}))
+}
@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/shopspring/decimal"
+)
+
+type Template struct {
+	Subject string
+	Body    string
+}
+
+type parsedTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Templates guarda um template por locale e evento, no formato de
+// text/template. Nos templates, money formata um valor em reais no locale.
+type Templates struct {
+	templates map[Locale]map[Event]parsedTemplate
+}
+
+var defaultTemplates = map[Locale]map[Event]Template{
+	LocalePtBR: {
+		EventTransferSent: {
+			Subject: "Transferência realizada",
+			Body:    "Transferência de {{money .Amount}} para {{.Counterparty}} foi realizada com sucesso",
+		},
+		EventTransferReceived: {
+			Subject: "Transferência recebida",
+			Body:    "Você recebeu {{money .Amount}} de {{.Counterparty}}",
+		},
+		EventRefundIssued: {
+			Subject: "Estorno recebido",
+			Body:    "Você recebeu um estorno de {{money .Amount}} de {{.Counterparty}}",
+		},
+		EventLowBalance: {
+			Subject: "Saldo baixo",
+			Body:    "Seu saldo está baixo: {{money .Amount}}",
+		},
+		EventPasswordReset: {
+			Subject: "Redefinição de senha",
+			Body:    "Use o código {{.Token}} para redefinir sua senha. Se não foi você quem pediu, ignore esta mensagem",
+		},
+	},
+	LocaleEnUS: {
+		EventTransferSent: {
+			Subject: "Transfer completed",
+			Body:    "Your transfer of {{money .Amount}} to {{.Counterparty}} was completed successfully",
+		},
+		EventTransferReceived: {
+			Subject: "Transfer received",
+			Body:    "You received {{money .Amount}} from {{.Counterparty}}",
+		},
+		EventRefundIssued: {
+			Subject: "Refund received",
+			Body:    "You received a refund of {{money .Amount}} from {{.Counterparty}}",
+		},
+		EventLowBalance: {
+			Subject: "Low balance",
+			Body:    "Your balance is low: {{money .Amount}}",
+		},
+		EventPasswordReset: {
+			Subject: "Password reset",
+			Body:    "Use the code {{.Token}} to reset your password. If you did not request it, ignore this message",
+		},
+	},
+	LocaleES: {
+		EventTransferSent: {
+			Subject: "Transferencia realizada",
+			Body:    "Tu transferencia de {{money .Amount}} a {{.Counterparty}} se realizó con éxito",
+		},
+		EventTransferReceived: {
+			Subject: "Transferencia recibida",
+			Body:    "Recibiste {{money .Amount}} de {{.Counterparty}}",
+		},
+		EventRefundIssued: {
+			Subject: "Reembolso recibido",
+			Body:    "Recibiste un reembolso de {{money .Amount}} de {{.Counterparty}}",
+		},
+		EventLowBalance: {
+			Subject: "Saldo bajo",
+			Body:    "Tu saldo está bajo: {{money .Amount}}",
+		},
+		EventPasswordReset: {
+			Subject: "Restablecimiento de contraseña",
+			Body:    "Usa el código {{.Token}} para restablecer tu contraseña. Si no lo solicitaste, ignora este mensaje",
+		},
+	},
+}
+
+func NewTemplates(definitions map[Locale]map[Event]Template) (*Templates, error) {
+	templates := &Templates{templates: make(map[Locale]map[Event]parsedTemplate, len(definitions))}
+	for locale, events := range definitions {
+		locale := locale
+		funcs := template.FuncMap{
+			"money": func(amount decimal.Decimal) string {
+				return FormatBRL(amount, locale)
+			},
+		}
+
+		parsed := make(map[Event]parsedTemplate, len(events))
+		for event, definition := range events {
+			name := string(locale) + "." + string(event)
+			subject, err := template.New(name + ".subject").Funcs(funcs).Parse(definition.Subject)
+			if err != nil {
+				return nil, fmt.Errorf("template de assunto inválido para %s em %s: %v", event, locale, err)
+			}
+
+			body, err := template.New(name + ".body").Funcs(funcs).Parse(definition.Body)
+			if err != nil {
+				return nil, fmt.Errorf("template de mensagem inválido para %s em %s: %v", event, locale, err)
+			}
+
+			parsed[event] = parsedTemplate{subject: subject, body: body}
+		}
+		templates.templates[locale] = parsed
+	}
+	return templates, nil
+}
+
+func DefaultTemplates() *Templates {
+	templates, err := NewTemplates(defaultTemplates)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// Render usa o template de DefaultLocale quando locale não tem um para event.
+func (t *Templates) Render(locale Locale, event Event, data Data) (Message, error) {
+	parsed, exists := t.templates[locale][event]
+	if !exists {
+		parsed, exists = t.templates[DefaultLocale][event]
+	}
+	if !exists {
+		return Message{}, fmt.Errorf("nenhum template para o evento %s", event)
+	}
+
+	var subject, body bytes.Buffer
+	if err := parsed.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("erro ao montar o assunto de %s: %v", event, err)
+	}
+	if err := parsed.body.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("erro ao montar a mensagem de %s: %v", event, err)
+	}
+
+	return Message{Event: event, Subject: subject.String(), Body: body.String()}, nil
+}
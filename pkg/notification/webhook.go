@@ -0,0 +1,21 @@
+package notification
+
+import (
+	"fmt"
+
+	"pag-simples/pkg/webhook"
+)
+
+// sendWebhook é trocado nos testes, que usam servidores locais.
+var sendWebhook = webhook.Send
+
+// WebhookNotifier envia a mensagem em JSON para o WebhookURL do destinatário,
+// que não pode apontar para a rede interna.
+type WebhookNotifier struct{}
+
+func (WebhookNotifier) Notify(recipient Recipient, message Message) error {
+	if recipient.WebhookURL == "" {
+		return fmt.Errorf("destinatário %s sem webhook_url", recipient.Name)
+	}
+	return sendWebhook(recipient.WebhookURL, message)
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress indica um destino na rede interna: loopback, faixas
+// privadas, link-local (como 169.254.169.254) ou não especificado.
+var ErrPrivateAddress = errors.New("endereço de rede interna não permitido")
+
+var (
+	// publicClient atende URLs informadas por clientes. O endereço é conferido
+	// na conexão, já resolvido, para que um DNS que muda de resposta depois da
+	// validação não leve à rede interna; proxies e redirecionamentos não são
+	// seguidos pelo mesmo motivo.
+	publicClient = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				Control: func(network, address string, _ syscall.RawConn) error {
+					host, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+						return ErrPrivateAddress
+					}
+					return nil
+				},
+			}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: noRedirect,
+	}
+
+	// trustedClient atende endereços da configuração, como o gateway de SMS,
+	// que podem estar na rede interna.
+	trustedClient = &http.Client{Timeout: 10 * time.Second, CheckRedirect: noRedirect}
+)
+
+// lookupIP é trocado nos testes.
+var lookupIP = net.DefaultResolver.LookupIPAddr
+
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// ValidateURL aceita só URLs http(s) cujo host não aponte para a rede interna.
+// Um host que não resolve agora é aceito: a conexão em Send confere o
+// endereço de novo.
+func ValidateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("url de webhook inválida: %q", raw)
+	}
+
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if privateIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	addrs, err := lookupIP(ctx, host)
+	if err != nil {
+		log.Printf("Não foi possível resolver o host do webhook %s: %v", host, err)
+		return nil
+	}
+	for _, addr := range addrs {
+		if privateIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// Send envia payload como JSON para uma url informada por clientes, recusando
+// destinos na rede interna; qualquer resposta fora da faixa 2xx, inclusive
+// redirecionamentos, é tratada como falha.
+func Send(url string, payload interface{}) error {
+	return send(publicClient, url, payload)
+}
+
+// SendTrusted é Send para endereços da configuração, que podem estar na rede
+// interna.
+func SendTrusted(url string, payload interface{}) error {
+	return send(trustedClient, url, payload)
+}
+
+func send(client *http.Client, url string, payload interface{}) error {
+	log.Printf("Enviando webhook para %s", url)
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Erro ao codificar o webhook para JSON: %v", err)
+		return fmt.Errorf("erro ao codificar o webhook: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.Printf("Erro ao criar a requisição do webhook: %v", err)
+		return fmt.Errorf("erro ao criar a requisição: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("Erro ao enviar o webhook para %s: %v", url, err)
+		return fmt.Errorf("erro ao enviar o webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("Erro ao enviar o webhook para %s, status code: %d", url, resp.StatusCode)
+		return fmt.Errorf("erro ao enviar o webhook, status code: %d", resp.StatusCode)
+	}
+
+	log.Printf("Webhook enviado para %s", url)
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateURL(t *testing.T) {
+	previous := lookupIP
+	lookupIP = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "loja.example":
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}}, nil
+		case "interno.example":
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return nil, errors.New("host desconhecido")
+	}
+	t.Cleanup(func() { lookupIP = previous })
+
+	for raw, want := range map[string]error{
+		"https://loja.example/notificacoes":        nil,
+		"http://203.0.113.10:8080/callback":        nil,
+		"https://sem-dns.example/callback":         nil,
+		"https://interno.example/callback":         ErrPrivateAddress,
+		"http://127.0.0.1:8080/":                   ErrPrivateAddress,
+		"http://[::1]/":                            ErrPrivateAddress,
+		"http://169.254.169.254/latest/meta-data/": ErrPrivateAddress,
+		"http://192.168.0.10/":                     ErrPrivateAddress,
+		"http://0.0.0.0/":                          ErrPrivateAddress,
+	} {
+		err := ValidateURL(raw)
+		if want == nil {
+			assert.NoError(t, err, raw)
+		} else {
+			assert.True(t, errors.Is(err, want), "%s: %v", raw, err)
+		}
+	}
+
+	for _, raw := range []string{"ftp://loja.example", "loja.example/callback", "https://"} {
+		assert.Error(t, ValidateURL(raw), raw)
+	}
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	err := Send(server.URL, map[string]string{"event": "teste"})
+	assert.True(t, errors.Is(err, ErrPrivateAddress), "Send: %v", err)
+	assert.Zero(t, calls)
+
+	require.NoError(t, SendTrusted(server.URL, map[string]string{"event": "teste"}))
+	assert.Equal(t, 1, calls)
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	target := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/destino" {
+			target++
+			return
+		}
+		http.Redirect(w, r, "/destino", http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(server.Close)
+
+	assert.Error(t, SendTrusted(server.URL, map[string]string{"event": "teste"}))
+	assert.Zero(t, target)
+}